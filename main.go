@@ -12,13 +12,37 @@ import (
 	"strings"
 
 	"cangje-docs-mcp/pkg/mcp"
+	"cangje-docs-mcp/pkg/scanner"
+	"cangje-docs-mcp/pkg/types"
 	"cangje-docs-mcp/pkg/utils"
 )
 
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, "; ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
 	// 定义命令行参数
 	var docRoot = flag.String("dir", "", "仓颉文档根目录路径 (留空则使用默认位置)")
 	var noUpdate = flag.Bool("no-update", false, "禁用自动更新文档")
+	var overlayDir = flag.String("overlay", "", "本地补充文档目录，与官方文档合并索引且不受更新影响 (留空则使用文档目录旁的 CangjieCorpus.local)")
+	var forceUpdate = flag.Bool("force-update", false, "更新文档时丢弃文档目录中的本地修改")
+	var mirrors = flag.String("mirrors", "", "文档仓库镜像列表，逗号分隔，按顺序尝试，可用 #超时 指定单个地址的超时 (如 https://a.git#2m,https://b.git#30s)")
+	var synonymsFile = flag.String("synonyms", "", "同义词文件，覆盖或补充内置的同义词和中英文对照表 (留空则使用补充目录中的 synonyms.txt)")
+	var indexFile = flag.String("index", "", "持久化搜索索引文件，文档不变时启动直接加载语义索引 (留空则使用文档目录旁的 CangjieCorpus.index)")
+	var explainQuery = flag.String("explain", "", "输出该查询每个结果的分数组成后退出，用于离线调整搜索权重")
+	var explainMode = flag.String("mode", types.SearchModeKeyword, "搜索模式 keyword/semantic/hybrid (仅用于 -explain)")
+	var explainResults = flag.Int("n", types.DefaultMaxResults, "输出的结果数 (仅用于 -explain)")
+	var rootSpecs stringList
+	flag.Var(&rootSpecs, "root", "额外文档根目录，可重复指定 (如 name=internal,path=/data/docs,category=internal,title=内部库,priority=2)")
 	var showVersion = flag.Bool("version", false, "显示版本信息")
 	var showHelp = flag.Bool("help", false, "显示帮助信息")
 
@@ -57,11 +81,15 @@ func main() {
 		fmt.Println("    - 其他系统: ~/.config/cangje-docs-mcp/CangjieCorpus")
 		fmt.Println()
 		fmt.Println("  启动时会自动更新文档（除非使用 -no-update 参数）")
+		fmt.Println("  文档目录存在本地修改时会跳过更新，自定义文档请放到补充目录（-overlay）")
 		fmt.Println()
 		fmt.Println("示例:")
 		fmt.Println("  cangje-docs-mcp                                    # 使用默认目录并自动更新")
 		fmt.Println("  cangje-docs-mcp -no-update                         # 使用默认目录但不更新")
 		fmt.Println("  cangje-docs-mcp -dir /path/to/docs                # 指定文档目录")
+		fmt.Println("  cangje-docs-mcp -root name=internal,path=/data/docs,priority=2  # 同时索引团队文档")
+		fmt.Println("  cangje-docs-mcp -mirrors https://a.git#2m,https://b.git  # 指定镜像列表，失败时自动切换")
+		fmt.Println("  cangje-docs-mcp -no-update -explain \"HashMap\" -n 5  # 查看搜索结果的分数组成")
 		return
 	}
 
@@ -71,15 +99,63 @@ func main() {
 		log.Fatalf("获取文档目录失败: %v", err)
 	}
 
+	remotes, err := utils.ParseRemotes(*mirrors)
+	if err != nil {
+		log.Fatalf("解析镜像列表失败: %v", err)
+	}
+
 	// 确保文档存在并更新
-	autoUpdate := !*noUpdate
-	if err := utils.EnsureDocuments(docDir, autoUpdate); err != nil {
+	ensureOpts := utils.EnsureOptions{
+		AutoUpdate:  !*noUpdate,
+		Remotes:     remotes,
+		ForceUpdate: *forceUpdate,
+	}
+	if err := utils.EnsureDocuments(docDir, ensureOpts); err != nil {
 		log.Fatalf("初始化文档失败: %v", err)
 	}
 
+	if *overlayDir == "" {
+		*overlayDir = utils.DefaultOverlayDir(docDir)
+	}
+
+	if *indexFile == "" {
+		*indexFile = utils.DefaultIndexFile(docDir)
+	}
+
+	var roots []types.DocRoot
+	for _, spec := range rootSpecs {
+		root, err := scanner.ParseRootSpec(spec)
+		if err != nil {
+			log.Fatalf("解析文档根配置失败: %v", err)
+		}
+		roots = append(roots, root)
+	}
+
 	ctx := context.Background()
 
-	server := mcp.NewCangJieDocServer(docDir)
+	server := mcp.NewCangJieDocServer(docDir, mcp.ServerOptions{
+		OverlayDir:   *overlayDir,
+		Roots:        roots,
+		SynonymsFile: *synonymsFile,
+		IndexFile:    *indexFile,
+	})
+
+	// 命令行模式：输出分数组成后退出
+	if *explainQuery != "" {
+		if err := server.Load(); err != nil {
+			log.Fatalf("加载文档失败: %v", err)
+		}
+		// 与 cangjie_search 的参数相同，其余参数使用工具的默认值
+		args := map[string]interface{}{
+			"query":       *explainQuery,
+			"mode":        *explainMode,
+			"max_results": float64(*explainResults),
+		}
+		if err := server.ExplainSearch(os.Stdout, args); err != nil {
+			log.Fatalf("搜索失败: %v", err)
+		}
+		return
+	}
 
 	if err := server.Serve(ctx); err != nil {
 		log.Printf("服务器错误: %v", err)
@@ -0,0 +1,269 @@
+package cjlang
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CjpmConfig cjpm.toml 中与文档检索相关的配置
+type CjpmConfig struct {
+	Name             string           // 包名（[package] name）
+	Version          string           // 版本（[package] version）
+	CjcVersion       string           // 编译器版本（[package] cjc-version）
+	OutputType       string           // 输出类型（[package] output-type）
+	Dependencies     []CjpmDependency // 源码依赖（[dependencies]）
+	TestDependencies []CjpmDependency // 测试依赖（[test-dependencies]）
+	WorkspaceMembers []string         // 工作区成员（[workspace] members）
+	BinDependencies  []string         // 二进制依赖路径（[target.*.bin-dependencies] path-option）
+	Sections         []string         // 出现的所有配置节，如 package、target.x86_64-unknown-linux-gnu
+}
+
+// CjpmDependency cjpm 依赖项
+type CjpmDependency struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Git     string `json:"git,omitempty"`
+	Tag     string `json:"tag,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// UsesStdx 判断是否通过二进制依赖引入了 stdx 扩展库
+func (c *CjpmConfig) UsesStdx() bool {
+	for _, path := range c.BinDependencies {
+		if strings.Contains(strings.ToLower(path), "stdx") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadCjpmConfig 读取并解析 cjpm.toml
+func LoadCjpmConfig(path string) (*CjpmConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ParseCjpmConfig(string(content)), nil
+}
+
+// ParseCjpmConfig 解析 cjpm.toml 内容
+// 只支持 cjpm.toml 中常见的 TOML 子集：节、键值对、字符串、数组和内联表
+func ParseCjpmConfig(content string) *CjpmConfig {
+	config := &CjpmConfig{}
+	section := ""
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripTomlComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			config.Sections = append(config.Sections, section)
+			continue
+		}
+
+		key, value, ok := cutTomlKeyValue(line)
+		if !ok {
+			continue
+		}
+		key = unquoteToml(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		// 多行数组或内联表：继续读取直到括号闭合
+		for !tomlValueComplete(value) && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripTomlComment(lines[i]))
+		}
+
+		switch {
+		case section == "package":
+			switch key {
+			case "name":
+				config.Name = unquoteToml(value)
+			case "version":
+				config.Version = unquoteToml(value)
+			case "cjc-version":
+				config.CjcVersion = unquoteToml(value)
+			case "output-type":
+				config.OutputType = unquoteToml(value)
+			}
+		case section == "dependencies":
+			config.Dependencies = append(config.Dependencies, parseDependency(key, value))
+		case section == "test-dependencies":
+			config.TestDependencies = append(config.TestDependencies, parseDependency(key, value))
+		case strings.HasPrefix(section, "dependencies."):
+			config.Dependencies = mergeDependencyField(config.Dependencies, unquoteToml(strings.TrimPrefix(section, "dependencies.")), key, value)
+		case strings.HasPrefix(section, "test-dependencies."):
+			config.TestDependencies = mergeDependencyField(config.TestDependencies, unquoteToml(strings.TrimPrefix(section, "test-dependencies.")), key, value)
+		case section == "workspace" && key == "members":
+			config.WorkspaceMembers = append(config.WorkspaceMembers, parseTomlArray(value)...)
+		case strings.HasSuffix(section, "bin-dependencies") && key == "path-option":
+			config.BinDependencies = append(config.BinDependencies, parseTomlArray(value)...)
+		}
+	}
+
+	sort.Slice(config.Dependencies, func(i, j int) bool {
+		return config.Dependencies[i].Name < config.Dependencies[j].Name
+	})
+	return config
+}
+
+// parseDependency 解析依赖项，值可以是版本字符串或内联表
+func parseDependency(name, value string) CjpmDependency {
+	dep := CjpmDependency{Name: name}
+	if !strings.HasPrefix(value, "{") {
+		dep.Version = unquoteToml(value)
+		return dep
+	}
+	for field, fieldValue := range parseTomlInlineTable(value) {
+		setDependencyField(&dep, field, fieldValue)
+	}
+	return dep
+}
+
+// mergeDependencyField 处理 [dependencies.name] 形式的依赖节
+func mergeDependencyField(deps []CjpmDependency, name, field, value string) []CjpmDependency {
+	for i := range deps {
+		if deps[i].Name == name {
+			setDependencyField(&deps[i], field, unquoteToml(value))
+			return deps
+		}
+	}
+	dep := CjpmDependency{Name: name}
+	setDependencyField(&dep, field, unquoteToml(value))
+	return append(deps, dep)
+}
+
+// setDependencyField 设置依赖项字段
+func setDependencyField(dep *CjpmDependency, field, value string) {
+	switch field {
+	case "path":
+		dep.Path = value
+	case "git":
+		dep.Git = value
+	case "tag":
+		dep.Tag = value
+	case "branch":
+		dep.Branch = value
+	case "version":
+		dep.Version = value
+	}
+}
+
+// parseTomlInlineTable 解析 { key = "value", ... } 形式的内联表
+func parseTomlInlineTable(value string) map[string]string {
+	table := make(map[string]string)
+	body := strings.TrimSpace(value)
+	body = strings.TrimSuffix(strings.TrimPrefix(body, "{"), "}")
+
+	for _, field := range splitTomlList(body) {
+		key, fieldValue, ok := cutTomlKeyValue(field)
+		if !ok {
+			continue
+		}
+		table[unquoteToml(strings.TrimSpace(key))] = unquoteToml(strings.TrimSpace(fieldValue))
+	}
+	return table
+}
+
+// parseTomlArray 解析 ["a", "b"] 形式的字符串数组
+func parseTomlArray(value string) []string {
+	body := strings.TrimSpace(value)
+	body = strings.TrimSuffix(strings.TrimPrefix(body, "["), "]")
+
+	var items []string
+	for _, item := range splitTomlList(body) {
+		if item = unquoteToml(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitTomlList 按逗号切分列表，忽略引号内的逗号
+func splitTomlList(body string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(body); i++ {
+		switch {
+		case quote != 0 && body[i] == quote:
+			quote = 0
+		case quote == 0 && (body[i] == '"' || body[i] == '\''):
+			quote = body[i]
+		case quote == 0 && body[i] == ',':
+			items = append(items, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(body[start:]); rest != "" {
+		items = append(items, rest)
+	}
+	return items
+}
+
+// cutTomlKeyValue 在第一个不在引号内的 = 处切分键和值，键可以是带引号的字符串
+func cutTomlKeyValue(line string) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != 0 && line[i] == quote:
+			quote = 0
+		case quote == 0 && (line[i] == '"' || line[i] == '\''):
+			quote = line[i]
+		case quote == 0 && line[i] == '=':
+			return line[:i], line[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// tomlValueComplete 判断值的括号是否已闭合
+func tomlValueComplete(value string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quote != 0 && value[i] == quote:
+			quote = 0
+		case quote == 0 && (value[i] == '"' || value[i] == '\''):
+			quote = value[i]
+		case quote == 0 && (value[i] == '[' || value[i] == '{'):
+			depth++
+		case quote == 0 && (value[i] == ']' || value[i] == '}'):
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripTomlComment 去掉行尾注释（忽略引号内的 #）
+func stripTomlComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != 0 && line[i] == quote:
+			quote = 0
+		case quote == 0 && (line[i] == '"' || line[i] == '\''):
+			quote = line[i]
+		case quote == 0 && line[i] == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteToml 去掉字符串两端的引号
+func unquoteToml(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
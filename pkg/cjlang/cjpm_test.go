@@ -0,0 +1,106 @@
+package cjlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCjpmConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    CjpmConfig
+	}{
+		{
+			name: "package and comments",
+			content: `# 项目配置
+[package] # 包信息
+  name = "demo" # 包名
+  version = '1.2.0'
+  cjc-version = "0.53.4"
+  output-type = "executable"
+  description = "use # and = in strings"
+`,
+			want: CjpmConfig{
+				Name:       "demo",
+				Version:    "1.2.0",
+				CjcVersion: "0.53.4",
+				OutputType: "executable",
+				Sections:   []string{"package"},
+			},
+		},
+		{
+			name: "inline tables",
+			content: `[dependencies]
+  zlib = { path = "./libs/zlib" }
+  http = {git = "https://example.com/http.git", tag = "v1.0, final" , branch = 'main'}
+  json = "0.2.0"
+[test-dependencies]
+  mock = { path = "../mock", version = "1.0" } # 测试用
+`,
+			want: CjpmConfig{
+				Dependencies: []CjpmDependency{
+					{Name: "http", Git: "https://example.com/http.git", Tag: "v1.0, final", Branch: "main"},
+					{Name: "json", Version: "0.2.0"},
+					{Name: "zlib", Path: "./libs/zlib"},
+				},
+				TestDependencies: []CjpmDependency{{Name: "mock", Path: "../mock", Version: "1.0"}},
+				Sections:         []string{"dependencies", "test-dependencies"},
+			},
+		},
+		{
+			name: "quoted keys",
+			content: `[dependencies]
+  "my-lib" = { "path" = "../my-lib" }
+  'a=b' = { path = "./eq" }
+[dependencies."dotted.lib"]
+  git = "https://example.com/dotted.git"
+  "branch" = "dev"
+`,
+			want: CjpmConfig{
+				Dependencies: []CjpmDependency{
+					{Name: "a=b", Path: "./eq"},
+					{Name: "dotted.lib", Git: "https://example.com/dotted.git", Branch: "dev"},
+					{Name: "my-lib", Path: "../my-lib"},
+				},
+				Sections: []string{"dependencies", `dependencies."dotted.lib"`},
+			},
+		},
+		{
+			name: "arrays",
+			content: `[workspace]
+  members = [
+    "core", # 核心
+    'net',
+    "tools/cli",
+  ]
+[target.x86_64-unknown-linux-gnu.bin-dependencies]
+  path-option = ["./stdx/linux_x86_64_llvm/dynamic/stdx", "./third,party"]
+`,
+			want: CjpmConfig{
+				WorkspaceMembers: []string{"core", "net", "tools/cli"},
+				BinDependencies:  []string{"./stdx/linux_x86_64_llvm/dynamic/stdx", "./third,party"},
+				Sections:         []string{"workspace", "target.x86_64-unknown-linux-gnu.bin-dependencies"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCjpmConfig(tt.content)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseCjpmConfig:\n got %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCjpmConfigUsesStdx(t *testing.T) {
+	config := ParseCjpmConfig("[target.x86_64-unknown-linux-gnu.bin-dependencies]\npath-option = [\"./libs/STDX/dynamic\"]\n")
+	if !config.UsesStdx() {
+		t.Error("UsesStdx() = false, want true")
+	}
+	if ParseCjpmConfig("[package]\nname = \"stdx\"\n").UsesStdx() {
+		t.Error("UsesStdx() = true for a package named stdx without bin-dependencies")
+	}
+}
@@ -0,0 +1,170 @@
+package cjlang
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic cjc/cjpm 输出中的一条错误或警告
+type Diagnostic struct {
+	Severity    string   `json:"severity"`              // error/warning/note
+	Code        string   `json:"code,omitempty"`        // 错误码（如果输出中有）
+	Message     string   `json:"message"`               // 错误信息
+	File        string   `json:"file,omitempty"`        // 出错文件
+	Line        int      `json:"line,omitempty"`        // 出错行号
+	Column      int      `json:"column,omitempty"`      // 出错列号
+	Identifiers []string `json:"identifiers,omitempty"` // 信息中引用的标识符，如 'foo'
+	Topics      []string `json:"topics,omitempty"`      // 信息涉及的语言概念，用于检索文档
+}
+
+var (
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// 诊断行，如 "error: undeclared identifier 'foo'"、"warning[W0001]: unused variable"
+	diagnosticLine = regexp.MustCompile(`^\s*(?:\[\s*)?(error|warning|note|fatal error|ERROR|WARNING|Error|Warning)\s*(?:\[([A-Za-z0-9_-]+)\])?\s*\]?\s*:\s*(.+)$`)
+	// 位置行，如 " ==> src/main.cj:3:5:" 或 "src/main.cj:3:5: error: ..."
+	locationLine   = regexp.MustCompile(`^\s*(?:==>|-->)\s*(.+?):(\d+):(\d+)`)
+	locationPrefix = regexp.MustCompile(`^(.+?\.cj):(\d+):(\d+):\s*`)
+	// 信息中的错误码，如 E1001
+	codePattern = regexp.MustCompile(`\b[A-Z]{1,3}\d{3,5}\b`)
+	// 信息中引用的标识符
+	quotedIdentifier = regexp.MustCompile("['`\"‘“]([^'`\"’”]+)['`\"’”]")
+	// 汇总行，如 "1 error generated, 1 error printed."
+	summaryLine  = regexp.MustCompile(`^\d+ (?:errors?|warnings?) generated`)
+	nonWordChars = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// diagnosticTopics 诊断信息中的关键短语到文档概念词的映射
+// 仓颉文档以中文为主，编译器信息是英文，需要转换成文档中使用的术语
+var diagnosticTopics = []struct {
+	patterns []string
+	topics   []string
+}{
+	{[]string{"undeclared", "cannot find", "not found", "unresolved", "undefined"}, []string{"import", "包", "作用域"}},
+	{[]string{"import", "package"}, []string{"包", "import"}},
+	{[]string{"mismatched type", "type mismatch", "incompatible type", "cannot convert", "expected type"}, []string{"类型转换", "类型"}},
+	{[]string{"immutable", "cannot assign", "reassign", "is not mutable"}, []string{"变量", "let", "var"}},
+	{[]string{"mut "}, []string{"mut", "结构体"}},
+	{[]string{"variable"}, []string{"变量"}},
+	{[]string{"generic", "constraint", "does not satisfy", "type argument"}, []string{"泛型", "约束"}},
+	{[]string{"interface", "implement", "abstract"}, []string{"接口"}},
+	// open 只在 non-open、not open 中表示修饰符，避免 cannot open file 之类的文件错误
+	{[]string{"override", "redef", "non open", "not open", "inherit"}, []string{"继承", "override"}},
+	{[]string{"overload", "ambiguous"}, []string{"重载"}},
+	{[]string{"unused", "never used"}, []string{"警告"}},
+	{[]string{"unsafe", "foreign", "cfunc", "cpointer"}, []string{"C 互操作", "unsafe"}},
+	{[]string{"macro", "quote", "tokens"}, []string{"宏"}},
+	{[]string{"match", "exhaustive", "pattern"}, []string{"模式匹配"}},
+	{[]string{"return"}, []string{"函数", "返回值"}},
+	{[]string{"constructor", "init", "initialized", "initialize"}, []string{"构造函数", "初始化"}},
+	{[]string{"exception", "throw", "try"}, []string{"异常"}},
+	{[]string{"spawn", "thread", "mutex", "atomic"}, []string{"并发", "线程"}},
+	{[]string{"cjpm", "dependency", "dependencies", "module"}, []string{"cjpm", "依赖"}},
+	{[]string{"option", "flag", "argument"}, []string{"编译选项", "cjc"}},
+	{[]string{"main"}, []string{"main", "程序入口"}},
+	{[]string{"visib", "private", "protected", "internal", "access"}, []string{"访问修饰符", "可见性"}},
+	{[]string{"integer overflow", "overflow"}, []string{"溢出", "整数类型"}},
+	{[]string{"enum", "constructor of enum"}, []string{"枚举"}},
+	{[]string{"struct"}, []string{"结构体"}},
+	{[]string{"class"}, []string{"类"}},
+	{[]string{"lambda", "closure", "capture"}, []string{"闭包", "lambda"}},
+}
+
+// ParseDiagnostics 从 cjc/cjpm 的原始输出中提取诊断信息，相同的信息只保留一条
+func ParseDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	seen := make(map[string]bool)
+	current := -1
+
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(output, ""), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// 位置行属于上一条诊断
+		if m := locationLine.FindStringSubmatch(line); m != nil {
+			if current >= 0 && diagnostics[current].File == "" {
+				diagnostics[current].File = m[1]
+				diagnostics[current].Line, _ = strconv.Atoi(m[2])
+				diagnostics[current].Column, _ = strconv.Atoi(m[3])
+			}
+			continue
+		}
+
+		// 位置前缀形式：src/main.cj:3:5: error: ...
+		var file string
+		var lineNo, column int
+		if m := locationPrefix.FindStringSubmatch(line); m != nil {
+			file = m[1]
+			lineNo, _ = strconv.Atoi(m[2])
+			column, _ = strconv.Atoi(m[3])
+			line = line[len(m[0]):]
+		}
+
+		m := diagnosticLine.FindStringSubmatch(line)
+		if m == nil || summaryLine.MatchString(strings.TrimSpace(m[3])) {
+			continue
+		}
+
+		message := strings.TrimSpace(m[3])
+		key := strings.ToLower(m[1]) + ":" + message
+		if seen[key] {
+			current = -1
+			continue
+		}
+		seen[key] = true
+
+		diagnostic := Diagnostic{
+			Severity: strings.TrimPrefix(strings.ToLower(m[1]), "fatal "),
+			Code:     m[2],
+			Message:  message,
+			File:     file,
+			Line:     lineNo,
+			Column:   column,
+		}
+		if diagnostic.Code == "" {
+			diagnostic.Code = codePattern.FindString(message)
+		}
+		for _, q := range quotedIdentifier.FindAllStringSubmatch(message, -1) {
+			if identifier := strings.TrimSpace(q[1]); identifier != "" && !contains(diagnostic.Identifiers, identifier) {
+				diagnostic.Identifiers = append(diagnostic.Identifiers, identifier)
+			}
+		}
+		diagnostic.Topics = diagnosticTopicsFor(message)
+
+		diagnostics = append(diagnostics, diagnostic)
+		current = len(diagnostics) - 1
+	}
+
+	return diagnostics
+}
+
+// diagnosticTopicsFor 返回诊断信息涉及的文档概念词
+func diagnosticTopicsFor(message string) []string {
+	// 引号中的标识符不参与概念匹配，避免变量名被误认为关键字
+	// 短语只在词首匹配，避免 remain 匹配到 main
+	lower := " " + nonWordChars.ReplaceAllString(strings.ToLower(quotedIdentifier.ReplaceAllString(message, " ")), " ") + " "
+
+	var topics []string
+	for _, entry := range diagnosticTopics {
+		for _, pattern := range entry.patterns {
+			if strings.Contains(lower, " "+pattern) {
+				for _, topic := range entry.topics {
+					if !contains(topics, topic) {
+						topics = append(topics, topic)
+					}
+				}
+				break
+			}
+		}
+	}
+	return topics
+}
+
+// contains 判断字符串切片是否包含指定元素
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package cjlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	output := "\x1b[31merror\x1b[0m: undeclared identifier 'foo'\n" +
+		" ==> src/main.cj:3:5:\n" +
+		"  |\n" +
+		"3 |     foo()\n" +
+		"  |     ^^^ \n" +
+		"\n" +
+		"warning: unused variable: 'x'\r\n" +
+		" --> src/util.cj:10:9\r\n" +
+		"src/lib.cj:7:1: error[E1001]: mismatched types, expected 'Int64', found 'String'\n" +
+		"error: undeclared identifier 'foo'\n" +
+		" ==> src/other.cj:8:2:\n" +
+		"fatal error: cannot open file `a.cj`\n" +
+		"2 errors generated, 2 errors printed.\n"
+
+	want := []Diagnostic{
+		{
+			Severity:    "error",
+			Message:     "undeclared identifier 'foo'",
+			File:        "src/main.cj",
+			Line:        3,
+			Column:      5,
+			Identifiers: []string{"foo"},
+			Topics:      []string{"import", "包", "作用域"},
+		},
+		{
+			Severity:    "warning",
+			Message:     "unused variable: 'x'",
+			File:        "src/util.cj",
+			Line:        10,
+			Column:      9,
+			Identifiers: []string{"x"},
+			Topics:      []string{"变量", "警告"},
+		},
+		{
+			Severity:    "error",
+			Code:        "E1001",
+			Message:     "mismatched types, expected 'Int64', found 'String'",
+			File:        "src/lib.cj",
+			Line:        7,
+			Column:      1,
+			Identifiers: []string{"Int64", "String"},
+			Topics:      []string{"类型转换", "类型"},
+		},
+		{
+			Severity:    "error",
+			Message:     "cannot open file `a.cj`",
+			Identifiers: []string{"a.cj"},
+		},
+	}
+
+	got := ParseDiagnostics(output)
+	if len(got) != len(want) {
+		t.Fatalf("ParseDiagnostics returned %d diagnostics, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("diagnostic %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseDiagnosticsCode(t *testing.T) {
+	tests := []struct {
+		line string
+		code string
+	}{
+		{"warning[W0001]: unused import", "W0001"},
+		{"error: E0042 expression has no effect", "E0042"},
+		{"[ERROR]: module 'net' not found", ""},
+	}
+	for _, tt := range tests {
+		diagnostics := ParseDiagnostics(tt.line)
+		if len(diagnostics) != 1 {
+			t.Errorf("ParseDiagnostics(%q) returned %d diagnostics, want 1", tt.line, len(diagnostics))
+			continue
+		}
+		if diagnostics[0].Code != tt.code {
+			t.Errorf("ParseDiagnostics(%q) code = %q, want %q", tt.line, diagnostics[0].Code, tt.code)
+		}
+	}
+}
+
+func TestDiagnosticTopicsFor(t *testing.T) {
+	tests := []struct {
+		message string
+		topics  []string
+	}{
+		// remain 不应匹配 main，引号中的标识符不参与匹配
+		{"function 'main' remains unused", []string{"警告"}},
+		{"missing return in function main", []string{"函数", "返回值", "main", "程序入口"}},
+		{"cannot assign to immutable value 'count'", []string{"变量", "let", "var"}},
+		{"type 'Foo' does not satisfy constraint 'ToString'", []string{"泛型", "约束"}},
+		{"cannot open file", nil},
+		{"cannot inherit from non-open class 'Base'", []string{"继承", "override", "类"}},
+	}
+	for _, tt := range tests {
+		if got := diagnosticTopicsFor(tt.message); !reflect.DeepEqual(got, tt.topics) {
+			t.Errorf("diagnosticTopicsFor(%q) = %q, want %q", tt.message, got, tt.topics)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package cjlang
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Project 仓颉项目的分析结果
+type Project struct {
+	Root        string         // 项目根目录
+	Config      *CjpmConfig    // 根目录的 cjpm.toml，不存在时为 nil
+	Members     []*CjpmConfig  // 工作区成员的 cjpm.toml
+	Packages    []string       // 项目源码自身声明的包
+	Imports     map[string]int // 导入的包 -> 导入该包的文件数
+	SourceFiles int            // 源文件数
+	TestFiles   int            // 测试文件数（*_test.cj）
+}
+
+// 分析项目时跳过的目录
+var skippedProjectDirs = map[string]bool{
+	"target": true,
+	"build":  true,
+	"output": true,
+}
+
+// AnalyzeProject 分析仓颉项目：读取 cjpm.toml（含工作区成员）并收集 .cj 源文件中的导入
+func AnalyzeProject(root string) (*Project, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access project: %w", err)
+	}
+	if !info.IsDir() {
+		// 允许直接传入 cjpm.toml 路径
+		root = filepath.Dir(root)
+	}
+
+	project := &Project{
+		Root:    root,
+		Imports: make(map[string]int),
+	}
+
+	if config, err := LoadCjpmConfig(filepath.Join(root, "cjpm.toml")); err == nil {
+		project.Config = config
+		for _, member := range config.WorkspaceMembers {
+			if memberConfig, err := LoadCjpmConfig(filepath.Join(root, member, "cjpm.toml")); err == nil {
+				project.Members = append(project.Members, memberConfig)
+			}
+		}
+	}
+
+	packages := make(map[string]bool)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skippedProjectDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".cj") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		if strings.HasSuffix(d.Name(), "_test.cj") {
+			project.TestFiles++
+		} else {
+			project.SourceFiles++
+		}
+
+		file := ParseSource(string(content))
+		if file.Package != "" {
+			packages[file.Package] = true
+		}
+
+		// 同一文件多次导入同一个包只计一次
+		seen := make(map[string]bool)
+		for _, imp := range file.Imports {
+			pkg := imp.Package()
+			if !seen[pkg] {
+				seen[pkg] = true
+				project.Imports[pkg]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for pkg := range packages {
+		project.Packages = append(project.Packages, pkg)
+	}
+	sort.Strings(project.Packages)
+
+	return project, nil
+}
+
+// Dependencies 返回根项目和工作区成员的全部依赖（含测试依赖）
+func (p *Project) Dependencies() []CjpmDependency {
+	var deps []CjpmDependency
+	for _, config := range p.configs() {
+		deps = append(deps, config.Dependencies...)
+		deps = append(deps, config.TestDependencies...)
+	}
+	return deps
+}
+
+// UsesStdx 判断项目是否配置了 stdx 二进制依赖或导入了 stdx 包
+func (p *Project) UsesStdx() bool {
+	for _, config := range p.configs() {
+		if config.UsesStdx() {
+			return true
+		}
+	}
+	for pkg := range p.Imports {
+		if IsStdxPackage(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// LibraryImports 返回导入的 std/stdx 包，按导入次数降序排列
+func (p *Project) LibraryImports() []string {
+	var packages []string
+	for pkg := range p.Imports {
+		if IsStdPackage(pkg) || IsStdxPackage(pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if p.Imports[packages[i]] != p.Imports[packages[j]] {
+			return p.Imports[packages[i]] > p.Imports[packages[j]]
+		}
+		return packages[i] < packages[j]
+	})
+	return packages
+}
+
+// ExternalImports 返回既不是 std/stdx 也不是项目自身的导入包（通常来自 cjpm 依赖）
+func (p *Project) ExternalImports() []string {
+	own := make(map[string]bool)
+	for _, pkg := range p.Packages {
+		own[pkg] = true
+	}
+
+	var packages []string
+	for pkg := range p.Imports {
+		if !IsStdPackage(pkg) && !IsStdxPackage(pkg) && !own[pkg] {
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// configs 返回根项目和工作区成员的配置
+func (p *Project) configs() []*CjpmConfig {
+	var configs []*CjpmConfig
+	if p.Config != nil {
+		configs = append(configs, p.Config)
+	}
+	return append(configs, p.Members...)
+}
+
+// IsStdPackage 判断是否为标准库包（std.xxx）
+func IsStdPackage(pkg string) bool {
+	return pkg == "std" || strings.HasPrefix(pkg, "std.")
+}
+
+// IsStdxPackage 判断是否为扩展标准库包（stdx.xxx）
+func IsStdxPackage(pkg string) bool {
+	return pkg == "stdx" || strings.HasPrefix(pkg, "stdx.")
+}
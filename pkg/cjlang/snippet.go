@@ -0,0 +1,194 @@
+package cjlang
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Snippet 代码片段中引用的符号
+type Snippet struct {
+	Imports     []Import    // 导入声明
+	TypeRefs    []SymbolRef // 引用的类型（大写开头的标识符）
+	FuncCalls   []SymbolRef // 调用的顶层函数，如 println(...)
+	MemberCalls []MemberRef // 成员访问，如 list.add(...)、ArrayList.of(...)
+	Locals      []string    // 片段自身声明的名称（类型、函数、变量、参数、泛型参数）
+}
+
+// SymbolRef 符号引用
+type SymbolRef struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+// MemberRef 成员访问
+type MemberRef struct {
+	Receiver     string `json:"receiver"`                // 接收者表达式，如 list
+	ReceiverType string `json:"receiver_type,omitempty"` // 推断出的接收者类型，如 ArrayList
+	Member       string `json:"member"`                  // 成员名，如 add
+	Line         int    `json:"line"`
+}
+
+// 内置类型和不需要在标准库文档中查找的类型名
+var builtinTypes = map[string]bool{
+	"Int8": true, "Int16": true, "Int32": true, "Int64": true, "IntNative": true,
+	"UInt8": true, "UInt16": true, "UInt32": true, "UInt64": true, "UIntNative": true,
+	"Float16": true, "Float32": true, "Float64": true,
+	"Bool": true, "Rune": true, "Unit": true, "Nothing": true, "This": true, "VArray": true,
+}
+
+// 看起来像函数调用但不是函数的关键字
+var callKeywords = map[string]bool{
+	"if": true, "while": true, "for": true, "match": true, "func": true, "init": true,
+	"super": true, "this": true, "return": true, "spawn": true, "synchronized": true,
+	"catch": true, "main": true, "case": true, "throw": true, "try": true, "finally": true,
+	"do": true, "in": true, "is": true, "as": true, "where": true, "quote": true,
+	"unsafe": true, "prop": true, "let": true, "var": true, "const": true,
+}
+
+var (
+	localDeclPattern    = regexp.MustCompile(`\b(?:class|struct|interface|enum|func|type|macro|let|var|const|prop)\s+([A-Za-z_]\w*)`)
+	genericParamPattern = regexp.MustCompile(`\b(?:class|struct|interface|enum|func|type|extend)\s*(?:[A-Za-z_]\w*)?\s*<([^<>]*)>`)
+	paramPattern        = regexp.MustCompile(`[(,]\s*(?:(?:let|var)\s+)?([a-z_]\w*)\s*!?\s*:`)
+	lambdaParamPattern  = regexp.MustCompile(`\{\s*([a-z_]\w*(?:\s*,\s*[a-z_]\w*)*)\s*=>`)
+	forParamPattern     = regexp.MustCompile(`\bfor\s*\(\s*\(?\s*([a-z_]\w*(?:\s*,\s*[a-z_]\w*)*)`)
+	casePattern         = regexp.MustCompile(`\bcase\s+(?:[A-Z]\w*\s*\()?\s*([a-z_]\w*)`)
+	typeRefPattern      = regexp.MustCompile(`(^|[^.\w@])([A-Z][A-Za-z0-9_]*)\b`)
+	funcCallPattern     = regexp.MustCompile(`(^|[^.\w@])([a-z_]\w*)\s*(?:<[^<>()]*>)?\s*\(`)
+	memberPattern       = regexp.MustCompile(`([A-Za-z_]\w*)\s*(?:<[^<>()]*>)?\s*(?:\(\))?\s*\.\s*([A-Za-z_]\w*)`)
+	varTypePattern      = regexp.MustCompile(`\b(?:let|var)\s+([a-z_]\w*)\s*(?::\s*([A-Z]\w*)|=\s*([A-Z]\w*)\s*(?:<[^=;]*?>)?\s*(?:\(|\.))`)
+)
+
+// ParseSnippet 轻量解析代码片段，提取导入、类型引用、顶层函数调用和成员访问
+// 片段可以是完整文件，也可以是函数体中的几行代码；注释和字符串中的内容会被忽略
+func ParseSnippet(src string) *Snippet {
+	file := ParseSource(src)
+	masked, _ := maskSource(src)
+	text := string(masked)
+
+	snippet := &Snippet{Imports: file.Imports}
+
+	// 去掉 import 和 package 行，避免把包名当作成员访问
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if importPattern.MatchString(line) || fromPattern.MatchString(line) || packagePattern.MatchString(line) ||
+			strings.HasPrefix(trimmed, "@") && !strings.Contains(trimmed, "(") {
+			lines[i] = ""
+		}
+	}
+	text = strings.Join(lines, "\n")
+
+	locals := make(map[string]bool)
+	for _, m := range localDeclPattern.FindAllStringSubmatch(text, -1) {
+		locals[m[1]] = true
+	}
+	for _, m := range genericParamPattern.FindAllStringSubmatch(text, -1) {
+		for _, param := range strings.Split(m[1], ",") {
+			if name := strings.TrimSpace(strings.Split(param, "<:")[0]); name != "" {
+				locals[name] = true
+			}
+		}
+	}
+	for _, pattern := range []*regexp.Regexp{paramPattern, lambdaParamPattern, forParamPattern, casePattern} {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			for _, name := range strings.Split(m[1], ",") {
+				locals[strings.TrimSpace(name)] = true
+			}
+		}
+	}
+	// 包名的首段（如 std.collection.ArrayList 中的 std）不是成员访问的接收者
+	packageRoots := map[string]bool{"std": true, "stdx": true}
+	for _, imp := range file.Imports {
+		if imp.Alias != "" {
+			locals[imp.Alias] = true
+		}
+		packageRoots[strings.Split(imp.Path, ".")[0]] = true
+	}
+
+	// 变量类型：let list = ArrayList<Int64>() 或 let list: ArrayList<Int64>
+	varTypes := make(map[string]string)
+	for _, m := range varTypePattern.FindAllStringSubmatch(text, -1) {
+		if m[2] != "" {
+			varTypes[m[1]] = m[2]
+		} else {
+			varTypes[m[1]] = m[3]
+		}
+	}
+
+	seenTypes := make(map[string]bool)
+	seenFuncs := make(map[string]bool)
+	seenMembers := make(map[string]bool)
+	for i, line := range strings.Split(text, "\n") {
+		lineNo := i + 1
+
+		for _, m := range typeRefPattern.FindAllStringSubmatch(line, -1) {
+			name := m[2]
+			if locals[name] || builtinTypes[name] || seenTypes[name] {
+				continue
+			}
+			seenTypes[name] = true
+			snippet.TypeRefs = append(snippet.TypeRefs, SymbolRef{Name: name, Line: lineNo})
+		}
+
+		for _, m := range funcCallPattern.FindAllStringSubmatch(line, -1) {
+			name := m[2]
+			if locals[name] || callKeywords[name] || seenFuncs[name] {
+				continue
+			}
+			seenFuncs[name] = true
+			snippet.FuncCalls = append(snippet.FuncCalls, SymbolRef{Name: name, Line: lineNo})
+		}
+
+		for _, idx := range memberPattern.FindAllStringSubmatchIndex(line, -1) {
+			// 跳过链式调用中间的部分，如 a.b.c 中的 b.c，以及数字字面量
+			if idx[0] > 0 && (line[idx[0]-1] == '.' || isDigit(line[idx[0]])) {
+				continue
+			}
+			receiver := line[idx[2]:idx[3]]
+			member := line[idx[4]:idx[5]]
+			if receiver == "this" || receiver == "super" || packageRoots[receiver] {
+				continue
+			}
+
+			ref := MemberRef{Receiver: receiver, Member: member, Line: lineNo}
+			if varTypes[receiver] != "" {
+				ref.ReceiverType = varTypes[receiver]
+			} else if receiver[0] >= 'A' && receiver[0] <= 'Z' {
+				ref.ReceiverType = receiver
+			}
+			if locals[ref.ReceiverType] {
+				// 片段自身声明的类型，无法检查
+				continue
+			}
+
+			key := ref.ReceiverType + "." + member
+			if ref.ReceiverType == "" {
+				key = receiver + "." + member
+			}
+			if seenMembers[key] {
+				continue
+			}
+			seenMembers[key] = true
+			snippet.MemberCalls = append(snippet.MemberCalls, ref)
+		}
+	}
+
+	for name := range locals {
+		snippet.Locals = append(snippet.Locals, name)
+	}
+	sort.Strings(snippet.Locals)
+
+	return snippet
+}
+
+// IsLocal 判断名称是否为片段自身声明的
+func (s *Snippet) IsLocal(name string) bool {
+	idx := sort.SearchStrings(s.Locals, name)
+	return idx < len(s.Locals) && s.Locals[idx] == name
+}
+
+// isDigit 判断是否为数字字符
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
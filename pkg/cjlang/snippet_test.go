@@ -0,0 +1,109 @@
+package cjlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSnippet(t *testing.T) {
+	src := `import std.collection.*
+import std.io.{InputStream as In}
+
+/** 计数器 */
+class Counter<T> where T <: ToString {
+    var count: Int64 = 0
+    func add(item: T): Unit {
+        this.count += 1
+    }
+}
+
+main() {
+    let list = ArrayList<Int64>()
+    let map: HashMap<String, Int64> = HashMap()
+    list.add(1)
+    list.add(2)
+    map.put("k", 1) // println(Fake.call())
+    let s = "String.fake(x)"
+    println(list.size)
+    let r = 3.14.toString()
+    for ((k, v) in map) { println(k) }
+    list.forEach({ x => print(x) })
+    std.collection.ArrayList.of(1)
+    let e = In.read()
+    match (opt) {
+        case Some(value) => value.size
+        case None => 0
+    }
+    Counter<Int64>().add(1)
+}`
+
+	snippet := ParseSnippet(src)
+
+	wantImports := []Import{
+		{Path: "std.collection.*", Line: 1},
+		{Path: "std.io.InputStream", Alias: "In", Line: 2},
+	}
+	if !reflect.DeepEqual(snippet.Imports, wantImports) {
+		t.Errorf("Imports = %+v, want %+v", snippet.Imports, wantImports)
+	}
+
+	// 内置类型、片段自身声明的类型和注释、字符串中的名称不计入
+	wantTypes := []SymbolRef{
+		{Name: "ToString", Line: 5},
+		{Name: "ArrayList", Line: 13},
+		{Name: "HashMap", Line: 14},
+		{Name: "String", Line: 14},
+		{Name: "Some", Line: 26},
+		{Name: "None", Line: 27},
+	}
+	if !reflect.DeepEqual(snippet.TypeRefs, wantTypes) {
+		t.Errorf("TypeRefs = %+v, want %+v", snippet.TypeRefs, wantTypes)
+	}
+
+	// 关键字和成员调用不是顶层函数调用，同名调用只记录第一次
+	wantFuncs := []SymbolRef{
+		{Name: "println", Line: 19},
+		{Name: "print", Line: 22},
+	}
+	if !reflect.DeepEqual(snippet.FuncCalls, wantFuncs) {
+		t.Errorf("FuncCalls = %+v, want %+v", snippet.FuncCalls, wantFuncs)
+	}
+
+	// 接收者类型由变量声明推断；this、包名、别名、数字字面量和片段自身的类型不检查
+	wantMembers := []MemberRef{
+		{Receiver: "list", ReceiverType: "ArrayList", Member: "add", Line: 15},
+		{Receiver: "map", ReceiverType: "HashMap", Member: "put", Line: 17},
+		{Receiver: "list", ReceiverType: "ArrayList", Member: "size", Line: 19},
+		{Receiver: "list", ReceiverType: "ArrayList", Member: "forEach", Line: 22},
+		{Receiver: "value", Member: "size", Line: 26},
+	}
+	if !reflect.DeepEqual(snippet.MemberCalls, wantMembers) {
+		t.Errorf("MemberCalls = %+v, want %+v", snippet.MemberCalls, wantMembers)
+	}
+
+	wantLocals := []string{"Counter", "In", "T", "add", "count", "e", "item", "k", "list", "map", "r", "s", "v", "value", "x"}
+	if !reflect.DeepEqual(snippet.Locals, wantLocals) {
+		t.Errorf("Locals = %q, want %q", snippet.Locals, wantLocals)
+	}
+}
+
+func TestSnippetIsLocal(t *testing.T) {
+	snippet := ParseSnippet("func greet<U>(name: String, times!: Int64) {\n    let msg = name\n}\n")
+	tests := []struct {
+		name  string
+		local bool
+	}{
+		{"greet", true},
+		{"U", true},
+		{"name", true},
+		{"times", true},
+		{"msg", true},
+		{"String", false},
+		{"println", false},
+	}
+	for _, tt := range tests {
+		if got := snippet.IsLocal(tt.name); got != tt.local {
+			t.Errorf("IsLocal(%q) = %v, want %v", tt.name, got, tt.local)
+		}
+	}
+}
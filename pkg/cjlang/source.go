@@ -0,0 +1,505 @@
+package cjlang
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SourceFile 仓颉源文件的解析结果
+type SourceFile struct {
+	Package string   // 包名，如 mycorp.net.http；源文件未声明时为空
+	Imports []Import // 导入列表
+	Decls   []Decl   // 顶层声明
+}
+
+// Import 导入声明
+type Import struct {
+	Path  string // 导入路径，如 std.collection.* 或 std.collection.ArrayList
+	Alias string // 别名（import a.B as C）
+	Line  int    // 行号（从1开始）
+}
+
+// Package 返回导入所在的包名
+// std.collection.* 和 std.collection.ArrayList 均返回 std.collection
+func (i Import) Package() string {
+	if strings.HasSuffix(i.Path, ".*") {
+		return strings.TrimSuffix(i.Path, ".*")
+	}
+	if idx := strings.LastIndex(i.Path, "."); idx > 0 {
+		return i.Path[:idx]
+	}
+	return i.Path
+}
+
+// Decl 声明（类型、函数、属性等）
+type Decl struct {
+	Kind      string   // class/struct/interface/enum/extend/func/prop/init，枚举构造器为 case
+	Name      string   // 名称，如 ArrayList、add、init
+	Generics  string   // 泛型参数，如 <T>
+	Modifiers []string // 修饰符，如 public、open、static
+	Signature string   // 完整声明签名（不含函数体）
+	Doc       string   // 文档注释（/** ... */）内容
+	Line      int      // 行号（从1开始）
+	Members   []Decl   // 成员声明（仅类型和扩展），枚举的构造器在前
+}
+
+// IsType 判断声明是否为类型或扩展（可以包含成员）
+func (d Decl) IsType() bool {
+	switch d.Kind {
+	case "class", "struct", "interface", "enum", "extend":
+		return true
+	}
+	return false
+}
+
+// IsPrivate 判断声明是否为 private
+func (d Decl) IsPrivate() bool {
+	for _, modifier := range d.Modifiers {
+		if modifier == "private" {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	packagePattern    = regexp.MustCompile(`^\s*(?:macro\s+)?package\s+([\w.]+)`)
+	importPattern     = regexp.MustCompile(`^\s*(?:(?:public|protected|internal)\s+)?import\s+(.+)$`)
+	fromPattern       = regexp.MustCompile(`^\s*from\s+(\w+)\s+import\s+(.+)$`)
+	declPattern       = regexp.MustCompile(`^\s*((?:@\w+(?:\[[^\]]*\])?\s+)*)((?:(?:public|private|protected|internal|open|abstract|sealed|static|override|redef|mut|unsafe|const|operator|foreign)\s+)*)(class|struct|interface|enum|extend|func|prop|init)\b(.*)$`)
+	typeNamePattern   = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*(<[^>{]*>)?`)
+	funcNamePattern   = regexp.MustCompile(`^\s*([^\s(<]+)\s*(<[^>(]*>)?`)
+	extendNamePattern = regexp.MustCompile(`^\s*(?:<[^>]*>\s*)?([A-Za-z_][\w.]*)\s*(<[^>{]*>)?`)
+	caseNamePattern   = regexp.MustCompile(`^[A-Za-z_]\w*`)
+)
+
+// docComment 文档注释及其在源码中的结束位置
+type docComment struct {
+	text string
+	end  int
+}
+
+// typeContext 正在解析的类型声明上下文
+type typeContext struct {
+	depth    int   // 类型体内部的花括号深度
+	openLine int   // 类型体的 { 所在行（签名可能跨行）
+	decl     *Decl // 类型声明
+}
+
+// ParseSource 解析仓颉源码，提取包名、导入和声明（含文档注释）
+// 这是基于行和花括号深度的轻量解析，不做完整的语法分析
+func ParseSource(src string) *SourceFile {
+	file := &SourceFile{}
+	masked, docs := maskSource(src)
+	maskedText := string(masked)
+	lines := strings.Split(maskedText, "\n")
+
+	offset := 0
+	depth := 0
+	casesEnd := 0 // 已解析的枚举构造器的结束位置（构造器参数可以跨行）
+	var stack []typeContext
+
+	for i, line := range lines {
+		lineStart := offset
+		offset += len(line) + 1
+
+		if depth == 0 {
+			if m := packagePattern.FindStringSubmatch(line); m != nil && file.Package == "" {
+				file.Package = m[1]
+			} else if m := fromPattern.FindStringSubmatch(line); m != nil {
+				for _, imp := range parseImportItems(strings.TrimSpace(m[2]), i+1) {
+					imp.Path = m[1] + "." + imp.Path
+					file.Imports = append(file.Imports, imp)
+				}
+			} else if m := importPattern.FindStringSubmatch(line); m != nil {
+				file.Imports = append(file.Imports, parseImportItems(strings.TrimSpace(m[1]), i+1)...)
+			}
+		}
+
+		// 只识别顶层声明和类型体内的直接成员
+		var parent *typeContext
+		if len(stack) > 0 {
+			parent = &stack[len(stack)-1]
+		}
+		acceptDecl := depth == 0 || (parent != nil && depth == parent.depth)
+
+		if acceptDecl && lineStart >= casesEnd {
+			if decl, end, ok := parseDecl(src, maskedText, lineStart, i+1); ok {
+				decl.Doc = findDocComment(src, docs, lineStart)
+
+				var target *Decl
+				if parent != nil && depth == parent.depth {
+					parent.decl.Members = append(parent.decl.Members, decl)
+					target = &parent.decl.Members[len(parent.decl.Members)-1]
+				} else if depth == 0 {
+					file.Decls = append(file.Decls, decl)
+					target = &file.Decls[len(file.Decls)-1]
+				}
+
+				// 类型声明的花括号开启新的成员上下文
+				if target != nil && target.IsType() && strings.HasSuffix(target.Signature, "{") {
+					target.Signature = strings.TrimSpace(strings.TrimSuffix(target.Signature, "{"))
+					openLine := i + strings.Count(maskedText[lineStart:end], "\n")
+					stack = append(stack, typeContext{depth: depth + 1, openLine: openLine, decl: target})
+					if target.Kind == "enum" {
+						// 构造器可以与 { 写在同一行，如 enum Color { Red | Green }
+						var cases []Decl
+						cases, casesEnd = parseEnumCases(src, maskedText, docs, end, openLine+1)
+						target.Members = append(target.Members, cases...)
+					}
+				} else if target != nil {
+					target.Signature = strings.TrimSpace(strings.TrimSuffix(target.Signature, "{"))
+				}
+			} else if parent != nil && depth == parent.depth && parent.decl.Kind == "enum" {
+				var cases []Decl
+				cases, casesEnd = parseEnumCases(src, maskedText, docs, lineStart, i+1)
+				parent.decl.Members = append(parent.decl.Members, cases...)
+			}
+		}
+
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth < 0 {
+			depth = 0
+		}
+		for len(stack) > 0 && i >= stack[len(stack)-1].openLine && depth < stack[len(stack)-1].depth {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return file
+}
+
+// parseImportItems 解析 import 后的内容，展开 {A, B} 形式的多项导入
+func parseImportItems(spec string, line int) []Import {
+	spec = strings.TrimSuffix(strings.TrimSpace(spec), ";")
+
+	if open := strings.Index(spec, "{"); open >= 0 {
+		prefix := strings.TrimSpace(spec[:open])
+		body := strings.TrimSuffix(strings.TrimSpace(spec[open+1:]), "}")
+		var imports []Import
+		for _, item := range strings.Split(body, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			for _, imp := range parseImportItems(item, line) {
+				imp.Path = prefix + imp.Path
+				imports = append(imports, imp)
+			}
+		}
+		return imports
+	}
+
+	imp := Import{Path: spec, Line: line}
+	if path, alias, ok := strings.Cut(spec, " as "); ok {
+		imp.Path = strings.TrimSpace(path)
+		imp.Alias = strings.TrimSpace(alias)
+	}
+	imp.Path = strings.ReplaceAll(imp.Path, " ", "")
+	return []Import{imp}
+}
+
+// parseDecl 尝试从指定行开始解析一个声明，同时返回签名的结束位置
+func parseDecl(src, masked string, lineStart, lineNumber int) (Decl, int, bool) {
+	lineEnd := strings.IndexByte(masked[lineStart:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(masked) - lineStart
+	}
+	m := declPattern.FindStringSubmatch(masked[lineStart : lineStart+lineEnd])
+	if m == nil {
+		return Decl{}, 0, false
+	}
+
+	decl := Decl{
+		Kind:      m[3],
+		Modifiers: strings.Fields(m[2]),
+		Line:      lineNumber,
+	}
+
+	rest := m[4]
+	switch decl.Kind {
+	case "init":
+		decl.Name = "init"
+	case "func":
+		nm := funcNamePattern.FindStringSubmatch(rest)
+		if nm == nil {
+			return Decl{}, 0, false
+		}
+		decl.Name, decl.Generics = nm[1], nm[2]
+	case "extend":
+		nm := extendNamePattern.FindStringSubmatch(rest)
+		if nm == nil {
+			return Decl{}, 0, false
+		}
+		decl.Name, decl.Generics = nm[1], nm[2]
+	default:
+		nm := typeNamePattern.FindStringSubmatch(rest)
+		if nm == nil {
+			return Decl{}, 0, false
+		}
+		decl.Name, decl.Generics = nm[1], nm[2]
+	}
+
+	// 跳过声明前的注解，签名从修饰符或关键字开始
+	start := lineStart + len(m[0]) - len(strings.TrimLeft(m[0], " \t"))
+	start += len(m[1])
+	var end int
+	decl.Signature, end = extractSignature(src, masked, start)
+
+	return decl, end, true
+}
+
+// extractSignature 提取声明签名：到同级的 { 为止（保留 {），或在括号平衡的行尾结束，同时返回签名的结束位置
+func extractSignature(src, masked string, start int) (string, int) {
+	parens := 0
+
+	for i := start; i < len(masked); i++ {
+		switch masked[i] {
+		case '(', '[':
+			parens++
+		case ')', ']':
+			parens--
+		case '{':
+			if parens <= 0 {
+				return collapseSpaces(src[start : i+1]), i + 1
+			}
+		case '\n':
+			if parens <= 0 && !continuesOnNextLine(masked, i+1) {
+				return collapseSpaces(src[start:i]), i
+			}
+		}
+	}
+
+	return collapseSpaces(src[start:]), len(masked)
+}
+
+// parseEnumCases 从 start 开始解析枚举构造器，如 | Red | Green(Int64)
+// 构造器的参数可以跨行；在括号平衡的行尾、同级的 { 或 } 以及不是构造器的内容处结束，返回构造器和结束位置
+func parseEnumCases(src, masked string, docs []docComment, start, lineNumber int) ([]Decl, int) {
+	var cases []Decl
+	i := start
+	for i < len(masked) {
+		if c := masked[i]; c == ' ' || c == '\t' || c == '\r' || c == '|' {
+			i++
+			continue
+		}
+		name := caseNamePattern.FindString(masked[i:])
+		if name == "" {
+			break
+		}
+
+		end, parens := i+len(name), 0
+	scan:
+		for ; end < len(masked); end++ {
+			switch masked[end] {
+			case '(':
+				parens++
+			case ')':
+				parens--
+			case '|', '\n', '{', '}':
+				if parens <= 0 {
+					break scan
+				}
+			}
+		}
+		if rest := strings.TrimSpace(masked[i+len(name) : end]); rest != "" && !strings.HasPrefix(rest, "(") {
+			break
+		}
+
+		// 文档注释写在构造器前的 | 之前
+		declStart := i
+		for declStart > start && (masked[declStart-1] == ' ' || masked[declStart-1] == '\t') {
+			declStart--
+		}
+		if declStart > start && masked[declStart-1] == '|' {
+			declStart--
+		} else {
+			declStart = i
+		}
+
+		cases = append(cases, Decl{
+			Kind:      "case",
+			Name:      name,
+			Signature: collapseSpaces(src[i : i+len(strings.TrimRight(masked[i:end], " \t\r"))]),
+			Doc:       findDocComment(src, docs, declStart),
+			Line:      lineNumber + strings.Count(masked[start:i], "\n"),
+		})
+		i = end
+	}
+	return cases, i
+}
+
+// continuesOnNextLine 判断声明签名是否在下一行继续（如 where 子句、<: 父类型）
+func continuesOnNextLine(masked string, next int) bool {
+	rest := strings.TrimLeft(masked[next:], " \t\r\n")
+	for _, prefix := range []string{"{", "where", "<:", "&", ":"} {
+		if strings.HasPrefix(rest, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseSpaces 将空白字符序列折叠为单个空格
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// findDocComment 查找紧邻声明之前的文档注释（中间只允许空白和注解）
+func findDocComment(src string, docs []docComment, declStart int) string {
+	for i := len(docs) - 1; i >= 0; i-- {
+		if docs[i].end > declStart {
+			continue
+		}
+		between := src[docs[i].end:declStart]
+		for _, line := range strings.Split(between, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "@") {
+				return ""
+			}
+		}
+		return docs[i].text
+	}
+	return ""
+}
+
+// maskSource 将注释和字符串字面量替换为空格（保留换行），并收集文档注释
+// 掩码后的文本与原文长度一致，便于按偏移量回取原文
+func maskSource(src string) ([]byte, []docComment) {
+	masked := []byte(src)
+	var docs []docComment
+
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(masked); i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+
+	i := 0
+	for i < len(src) {
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			blank(i, i+end)
+			i += end
+
+		case strings.HasPrefix(src[i:], "/*"):
+			isDoc := strings.HasPrefix(src[i:], "/**") && !strings.HasPrefix(src[i:], "/**/")
+			end := blockCommentEnd(src, i)
+			if isDoc {
+				docs = append(docs, docComment{text: cleanDocComment(src[i:end]), end: end})
+			}
+			blank(i, end)
+			i = end
+
+		case strings.HasPrefix(src[i:], `"""`) || strings.HasPrefix(src[i:], `'''`):
+			quote := src[i : i+3]
+			end := strings.Index(src[i+3:], quote)
+			if end < 0 {
+				end = len(src) - i - 3
+			} else {
+				end += 3
+			}
+			blank(i, i+3+end)
+			i += 3 + end
+
+		case src[i] == '#' && rawStringStart(src, i) > 0:
+			hashes := rawStringStart(src, i)
+			closing := string(src[i+hashes]) + strings.Repeat("#", hashes)
+			end := strings.Index(src[i+hashes+1:], closing)
+			if end < 0 {
+				end = len(src) - i - hashes - 1
+			} else {
+				end += len(closing)
+			}
+			blank(i, i+hashes+1+end)
+			i += hashes + 1 + end
+
+		case src[i] == '"' || src[i] == '\'':
+			end := quotedEnd(src, i)
+			blank(i, end)
+			i = end
+
+		default:
+			i++
+		}
+	}
+
+	return masked, docs
+}
+
+// blockCommentEnd 返回块注释结束后的位置，支持嵌套块注释
+func blockCommentEnd(src string, start int) int {
+	level := 0
+	for i := start; i < len(src)-1; i++ {
+		if src[i] == '/' && src[i+1] == '*' {
+			level++
+			i++
+		} else if src[i] == '*' && src[i+1] == '/' {
+			level--
+			i++
+			if level == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(src)
+}
+
+// rawStringStart 判断是否为原始字符串 #"..."#，返回 # 的个数，不是则返回0
+func rawStringStart(src string, i int) int {
+	hashes := 0
+	for i+hashes < len(src) && src[i+hashes] == '#' {
+		hashes++
+	}
+	if i+hashes < len(src) && (src[i+hashes] == '"' || src[i+hashes] == '\'') {
+		return hashes
+	}
+	return 0
+}
+
+// quotedEnd 返回单行字符串字面量结束后的位置，处理转义和 ${} 插值
+func quotedEnd(src string, start int) int {
+	quote := src[start]
+	interpolation := 0
+	for i := start + 1; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			interpolation++
+			i++
+		case src[i] == '}' && interpolation > 0:
+			interpolation--
+		case src[i] == quote && interpolation == 0:
+			return i + 1
+		case src[i] == '\n':
+			// 未闭合的字符串，到行尾结束
+			return i
+		}
+	}
+	return len(src)
+}
+
+// cleanDocComment 去掉文档注释的 /** */ 和行首的 *
+func cleanDocComment(comment string) string {
+	comment = strings.TrimPrefix(comment, "/**")
+	comment = strings.TrimSuffix(comment, "*/")
+
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		if strings.HasPrefix(line, " ") {
+			line = line[1:]
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,209 @@
+package cjlang
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// declLines 把声明列表展开为 "行号 kind name generics: signature" 形式，成员缩进两个空格
+func declLines(decls []Decl, indent string) []string {
+	var lines []string
+	for _, decl := range decls {
+		lines = append(lines, fmt.Sprintf("%s%d %s %s%s: %s", indent, decl.Line, decl.Kind, decl.Name, decl.Generics, decl.Signature))
+		lines = append(lines, declLines(decl.Members, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestParseSourceDecls(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "nested generics",
+			src: `public class Cache<K, V> <: Container<HashMap<K, ArrayList<V>>> where K <: Hashable & Equatable<K> {
+    public func get(key: K): Option<ArrayList<V>> {
+        return None
+    }
+    public func merge<T>(other: HashMap<K, ArrayList<T>>): Unit where T <: ToString {}
+}
+public func zip<A, B>(a: Array<A>, b: Array<B>): Array<(A, B)> {
+    return []
+}`,
+			want: []string{
+				"1 class Cache<K, V>: public class Cache<K, V> <: Container<HashMap<K, ArrayList<V>>> where K <: Hashable & Equatable<K>",
+				"  2 func get: public func get(key: K): Option<ArrayList<V>>",
+				"  5 func merge<T>: public func merge<T>(other: HashMap<K, ArrayList<T>>): Unit where T <: ToString",
+				"7 func zip<A, B>: public func zip<A, B>(a: Array<A>, b: Array<B>): Array<(A, B)>",
+			},
+		},
+		{
+			name: "strings containing comment markers",
+			src: `let url = "http://example.com/*"
+let pattern = "/* not a comment { */"
+let raw = #"// still a string }"#
+let multi = """
+func hidden() {
+"""
+public struct Config {
+    let prefix = "//"
+    public func path(): String { "/*}" }
+}
+public func after(): Unit {}`,
+			want: []string{
+				"7 struct Config: public struct Config",
+				"  9 func path: public func path(): String",
+				"11 func after: public func after(): Unit",
+			},
+		},
+		{
+			name: "multi-line signatures",
+			src: `public class Pair<T>
+    <: ToString
+    where T <: ToString {
+    public func format(
+        left: T,
+        right: T
+    ): String {
+        return ""
+    }
+    public prop size: Int64 {
+        get() { 2 }
+    }
+}
+func top(): Unit {
+    func nested(): Unit {}
+}`,
+			want: []string{
+				"1 class Pair<T>: public class Pair<T> <: ToString where T <: ToString",
+				"  4 func format: public func format( left: T, right: T ): String",
+				"  10 prop size: public prop size: Int64",
+				"14 func top: func top(): Unit",
+			},
+		},
+		{
+			name: "enum cases",
+			src: `public enum Shape {
+    | Circle(Float64)
+    | Rect(
+        Float64,
+        Float64
+    ) | Empty // no area
+    | Point
+
+    public func area(): Float64 {
+        match (this) {
+            case Circle(r) => r * r
+            case _ => 0.0
+        }
+    }
+}
+enum Color { Red | Green | Blue(UInt8) }
+enum Option2<T> where T <: ToString {
+    Some2(T) | None2
+    | ...
+}`,
+			want: []string{
+				"1 enum Shape: public enum Shape",
+				"  2 case Circle: Circle(Float64)",
+				"  3 case Rect: Rect( Float64, Float64 )",
+				"  6 case Empty: Empty",
+				"  7 case Point: Point",
+				"  9 func area: public func area(): Float64",
+				"16 enum Color: enum Color",
+				"  16 case Red: Red",
+				"  16 case Green: Green",
+				"  16 case Blue: Blue(UInt8)",
+				"17 enum Option2<T>: enum Option2<T> where T <: ToString",
+				"  18 case Some2: Some2(T)",
+				"  18 case None2: None2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := declLines(ParseSource(tt.src).Decls, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSource decls:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseSourceDocComments(t *testing.T) {
+	src := `package demo.shapes
+
+/**
+ * 图形
+ *
+ * 支持圆和矩形
+ */
+@Deprecated
+public enum Shape {
+    /** 圆，参数为半径 */
+    | Circle(Float64)
+    // 普通注释不是文档
+    | Rect(Float64, Float64)
+    /** 空图形 */ | Empty
+}
+
+/** 不相邻的注释 */
+let x = 1
+func noDoc(): Unit {}`
+
+	file := ParseSource(src)
+	if file.Package != "demo.shapes" {
+		t.Errorf("Package = %q, want demo.shapes", file.Package)
+	}
+	docs := make(map[string]string)
+	var walk func(decls []Decl)
+	walk = func(decls []Decl) {
+		for _, decl := range decls {
+			docs[decl.Name] = decl.Doc
+			walk(decl.Members)
+		}
+	}
+	walk(file.Decls)
+
+	want := map[string]string{
+		"Shape":  "图形\n\n支持圆和矩形",
+		"Circle": "圆，参数为半径",
+		"Rect":   "",
+		"Empty":  "空图形",
+		"noDoc":  "",
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("doc comments = %q, want %q", docs, want)
+	}
+}
+
+func TestParseSourceImports(t *testing.T) {
+	src := `package app
+import std.collection.*
+import std.io.{InputStream, OutputStream as Out}
+public import mylib.util.Helper
+from net import http.Client
+func main() {
+    let s = "import fake.pkg"
+}`
+
+	var got []string
+	for _, imp := range ParseSource(src).Imports {
+		got = append(got, fmt.Sprintf("%d %s %s %s", imp.Line, imp.Path, imp.Alias, imp.Package()))
+	}
+	want := []string{
+		"2 std.collection.*  std.collection",
+		"3 std.io.InputStream  std.io",
+		"3 std.io.OutputStream Out std.io",
+		"4 mylib.util.Helper  mylib.util",
+		"5 net.http.Client  net.http",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imports:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,387 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// textPart 从某个位置开始截取的一段文本
+type textPart struct {
+	text      string
+	next      int      // 剩余内容的起始位置，0 表示已到末尾
+	remaining int      // 剩余内容的估算 token 数
+	outline   []string // 剩余内容中的标题（附带紧随其后的签名），在预算内尽量列出
+}
+
+// addTool 注册工具：每个工具都支持 max_tokens 参数，响应超出预算时截断并返回续读游标
+func (s *CangJieDocServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	mcp.WithNumber("max_tokens",
+		mcp.Description(fmt.Sprintf("响应的最大 token 数（估算，最小%d），超出时优先保留标题、签名和开头的段落，并返回 nextCursor 续读剩余内容 (默认不限制)", types.MinMaxTokens)),
+	)(&tool)
+	if _, ok := tool.InputSchema.Properties["cursor"]; !ok {
+		mcp.WithString("cursor",
+			mcp.Description("续读游标：响应因 max_tokens 截断时，传入返回的 nextCursor 获取剩余内容，其他参数需与上一次相同"),
+		)(&tool)
+	}
+	s.server.AddTool(tool, s.withTokenBudget(tool.Name, handler))
+}
+
+// withTokenBudget 按 max_tokens 截断处理函数返回的文本；游标中记录剩余内容在响应中的位置
+func (s *CangJieDocServer) withTokenBudget(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		maxTokens := 0
+		if m, ok := request.GetArguments()["max_tokens"].(float64); ok && m > 0 {
+			maxTokens = max(int(m), types.MinMaxTokens)
+		}
+		if maxTokens == 0 {
+			return handler(ctx, request)
+		}
+
+		query := cursorQuery(name, request)
+		cursor, err := s.decodeCursor(request, query)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || result.IsError || len(result.Content) != 1 {
+			return result, err
+		}
+		content, ok := result.Content[0].(mcp.TextContent)
+		if !ok {
+			return result, nil
+		}
+		text, err := s.applyTokenBudget(content.Text, query, cursor, maxTokens)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	}
+}
+
+// applyTokenBudget 从 cursor.Part 开始截取不超过 maxTokens 的响应
+// JSON 响应截取其中最大的数组或字符串字段，其他响应按 Markdown 块截取
+// 续读时截取的内容与上一部分不同（如文档已更新）则返回错误，避免从错位的位置继续
+func (s *CangJieDocServer) applyTokenBudget(text, query string, cursor pageCursor, maxTokens int) (string, error) {
+	if cursor.Part == 0 && utils.EstimateTokens(text) <= maxTokens {
+		return text, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var response map[string]interface{}
+	if strings.HasPrefix(text, "{") && decoder.Decode(&response) == nil {
+		trimmed, ok, err := s.trimJSON(response, query, cursor, maxTokens)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return trimmed, nil
+		}
+	}
+
+	digest := contentDigest(text)
+	if err := checkDigest(cursor, digest); err != nil {
+		return "", err
+	}
+	part := fitText(text, cursor.Part, maxTokens-types.ContinuationReserve)
+	if part.next == 0 {
+		return part.text, nil
+	}
+	var builder strings.Builder
+	builder.WriteString(part.text)
+	fmt.Fprintf(&builder, "\n\n✂️ 已按 max_tokens=%d 截断，剩余约 %d tokens\n", maxTokens, part.remaining)
+	if len(part.outline) > 0 {
+		builder.WriteString("后续章节：\n")
+		for _, entry := range part.outline {
+			builder.WriteString("- " + entry + "\n")
+		}
+	}
+	fmt.Fprintf(&builder, "➡️ nextCursor: %s （作为 cursor 参数传入获取剩余内容）\n", s.encodeCursor(query, cursor.Offset, part.next, digest))
+	return builder.String(), nil
+}
+
+// checkDigest 续读时校验截断的内容没有变化，变化后游标中的位置不再对应原来的内容
+func checkDigest(cursor pageCursor, digest string) error {
+	if cursor.Part > 0 && cursor.Digest != digest {
+		return fmt.Errorf("cursor expired: the response changed since the previous part, restart the request without cursor")
+	}
+	return nil
+}
+
+// trimJSON 截取 JSON 响应中最大的数组（按元素）或字符串（按块），并在 truncated 中说明截取的字段
+// 还有剩余内容时 nextCursor 指向剩余部分，最后一部分保留原响应的 nextCursor（下一页）
+func (s *CangJieDocServer) trimJSON(response map[string]interface{}, query string, cursor pageCursor, maxTokens int) (string, bool, error) {
+	parent, key, path := largestField(response)
+	if parent == nil {
+		return "", false, nil
+	}
+	// 只对截取的字段计算摘要，响应中的生成时间等字段每次不同
+	field, _ := json.Marshal(parent[key])
+	digest := contentDigest(path, string(field))
+	if err := checkDigest(cursor, digest); err != nil {
+		return "", false, err
+	}
+
+	budget := maxTokens - types.ContinuationReserve
+	info := map[string]interface{}{"field": path}
+	if cursor.Part > 0 {
+		info["offset"] = cursor.Part
+	}
+	next := 0
+	switch value := parent[key].(type) {
+	case []interface{}:
+		start := min(cursor.Part, len(value))
+		parent[key] = []interface{}{}
+		used := estimateJSON(response)
+		end := start
+		for end < len(value) {
+			tokens := estimateJSON(value[end])
+			if used+tokens > budget && end > start {
+				break
+			}
+			used += tokens
+			end++
+		}
+		parent[key] = value[start:end]
+		info["returned"] = end - start
+		if end < len(value) {
+			next = end
+			info["remaining"] = len(value) - end
+		}
+	case string:
+		parent[key] = ""
+		part := fitText(value, cursor.Part, budget-estimateJSON(response))
+		parent[key] = part.text
+		if part.next > 0 {
+			next = part.next
+			info["remaining_tokens"] = part.remaining
+			if len(part.outline) > 0 {
+				info["outline"] = part.outline
+			}
+		}
+	}
+
+	if next > 0 {
+		response["nextCursor"] = s.encodeCursor(query, cursor.Offset, next, digest)
+	}
+	if next > 0 || cursor.Part > 0 {
+		response["truncated"] = info
+	}
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return "", false, nil
+	}
+	return string(data), true, nil
+}
+
+// largestField 找到响应中估算 token 数最多的数组或字符串字段，沿对象和单元素数组向下查找
+// 返回字段所在的对象、字段名和路径（如 categories[0].subcategories）
+func largestField(response map[string]interface{}) (map[string]interface{}, string, string) {
+	node, path := response, ""
+	for {
+		key, best := "", -1
+		for k, v := range node {
+			switch v.(type) {
+			case []interface{}, map[string]interface{}, string:
+				if tokens := estimateJSON(v); tokens > best || (tokens == best && k < key) {
+					key, best = k, tokens
+				}
+			}
+		}
+		if key == "" {
+			return nil, "", ""
+		}
+		if path != "" {
+			path += "."
+		}
+		path += key
+
+		switch value := node[key].(type) {
+		case map[string]interface{}:
+			node = value
+			continue
+		case []interface{}:
+			if len(value) == 1 {
+				if child, ok := value[0].(map[string]interface{}); ok {
+					node, path = child, path+"[0]"
+					continue
+				}
+			}
+		}
+		return node, key, path
+	}
+}
+
+// estimateJSON 估算值序列化为 JSON 后的 token 数
+func estimateJSON(value interface{}) int {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+	return utils.EstimateTokens(buffer.String())
+}
+
+// fitText 从 start 开始按块（段落、标题、代码块、表格）截取不超过 budget 的文本，至少保留一行
+// 从代码块或表格中间开始时补上代码块的开始标记或表头，在代码块中间截断时补上结束标记
+func fitText(text string, start, budget int) textPart {
+	start = min(start, len(text))
+	// 截取位置总在行首，不在行首时移到下一行，保证从完整的字符和行开始
+	if start > 0 && text[start-1] != '\n' {
+		start = nextLine(text, start)
+	}
+	prefix := resumePrefix(text, start)
+	used := utils.EstimateTokens(prefix)
+
+	end := start
+	for _, blockEnd := range blockEnds(text, start) {
+		tokens := utils.EstimateTokens(text[end:blockEnd])
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		end = blockEnd
+	}
+	// 第一个块就放不下，或者剩余预算还多时，按行截取下一个块
+	if end == start || budget-used >= budget/2 {
+		for end < len(text) {
+			lineEnd := nextLine(text, end)
+			tokens := utils.EstimateTokens(text[end:lineEnd])
+			if used+tokens > budget && end > start {
+				break
+			}
+			used += tokens
+			end = lineEnd
+		}
+	}
+
+	part := textPart{text: prefix + strings.TrimRight(text[start:end], "\n")}
+	if end >= len(text) {
+		return part
+	}
+	fence := openFence(text[:end])
+	if fence != "" {
+		part.text += "\n" + fenceMarker(fence)
+	}
+	part.next = end
+	part.remaining = utils.EstimateTokens(text[end:])
+	part.outline = outline(text[end:], fence != "", budget-used)
+	return part
+}
+
+// blockEnds 返回从 start 开始每个块的结束位置：空行、标题、代码块结束之后，以及标题和代码块开始之前
+func blockEnds(text string, start int) []int {
+	var ends []int
+	inFence := openFence(text[:start]) != ""
+	for pos := start; pos < len(text); {
+		lineEnd := nextLine(text, pos)
+		line := strings.TrimSpace(text[pos:lineEnd])
+		closing := false
+		if isFence(line) {
+			closing = inFence
+			inFence = !inFence
+		}
+		if !inFence {
+			next := strings.TrimSpace(text[lineEnd:nextLine(text, lineEnd)])
+			if line == "" || closing || strings.HasPrefix(line, "#") || strings.HasPrefix(next, "#") || isFence(next) || lineEnd == len(text) {
+				ends = append(ends, lineEnd)
+			}
+		}
+		pos = lineEnd
+	}
+	return ends
+}
+
+// resumePrefix 从代码块中间续读时返回代码块的开始标记，从表格中间续读时返回表头和分隔行
+func resumePrefix(text string, start int) string {
+	if start == 0 || start >= len(text) {
+		return ""
+	}
+	if fence := openFence(text[:start]); fence != "" {
+		return fence + "\n"
+	}
+	if !strings.HasPrefix(strings.TrimSpace(text[start:nextLine(text, start)]), "|") {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSuffix(text[:start], "\n"), "\n")
+	first := len(lines)
+	for first > 0 && strings.HasPrefix(strings.TrimSpace(lines[first-1]), "|") {
+		first--
+	}
+	if len(lines)-first < 2 {
+		return ""
+	}
+	return lines[first] + "\n" + lines[first+1] + "\n"
+}
+
+// outline 列出剩余内容中的标题，标题后紧跟代码块时附上代码块的第一行（通常是签名），不超过 budget
+func outline(rest string, inFence bool, budget int) []string {
+	var entries []string
+	used, last := 0, -1 // last 为等待签名的标题下标
+	for _, line := range strings.Split(rest, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case isFence(trimmed):
+			inFence = !inFence
+		case inFence:
+			if last >= 0 && trimmed != "" {
+				signature := " — `" + trimmed + "`"
+				if tokens := utils.EstimateTokens(signature); used+tokens <= budget {
+					entries[last] += signature
+					used += tokens
+				}
+				last = -1
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			tokens := utils.EstimateTokens(trimmed) + 2
+			if used+tokens > budget {
+				return entries
+			}
+			entries = append(entries, trimmed)
+			used += tokens
+			last = len(entries) - 1
+		case trimmed != "":
+			last = -1
+		}
+	}
+	return entries
+}
+
+// openFence 返回文本末尾未闭合的代码块开始行，没有时返回空字符串
+func openFence(text string) string {
+	open := ""
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); isFence(trimmed) {
+			if open == "" {
+				open = trimmed
+			} else {
+				open = ""
+			}
+		}
+	}
+	return open
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
+
+// fenceMarker 返回代码块开始行中的标记（如 ```cangjie 中的 ```）
+func fenceMarker(fence string) string {
+	return fence[:len(fence)-len(strings.TrimLeft(fence, fence[:1]))]
+}
+
+// nextLine 返回 pos 所在行的下一行的起始位置
+func nextLine(text string, pos int) int {
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(text)
+}
@@ -0,0 +1,247 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// textCursorPattern 匹配 Markdown 和纯文本响应截断后附带的续读游标
+var textCursorPattern = regexp.MustCompile(`➡️ nextCursor: (\S+)`)
+
+// newLongDocServer 在临时目录中生成一篇包含代码块和表格的长文档，返回服务器和文档ID
+func newLongDocServer(t *testing.T) (*CangJieDocServer, string) {
+	t.Helper()
+	var builder strings.Builder
+	builder.WriteString("# 字符串操作\n\n本章介绍仓颉中字符串的常用操作。\n\n")
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(&builder, "## func op%d(String)\n\n```cangjie\npublic func op%d(s: String): String\n```\n\n", i, i)
+		builder.WriteString(strings.Repeat("功能：对字符串执行一种操作，用于测试按 token 截断时的段落边界。", 3) + "\n\n")
+		builder.WriteString("| 参数 | 说明 |\n|---|---|\n")
+		for j := 0; j < 6; j++ {
+			fmt.Fprintf(&builder, "| p%d | 第%d个参数的说明 |\n", j, j)
+		}
+		builder.WriteString("\n示例：\n\n```cangjie\nmain() {\n")
+		for j := 0; j < 10; j++ {
+			fmt.Fprintf(&builder, "    let s%d = op%d(\"abc%d\")\n    println(s%d)\n", j, i, j, j)
+		}
+		builder.WriteString("}\n```\n\n")
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "manual", "source_zh_cn", "basic")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "long_page.md"), []byte(builder.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewCangJieDocServer(root, ServerOptions{})
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return s, "manual_source_zh_cn_long_page"
+}
+
+// withArgs 复制参数并加上额外的参数
+func withArgs(args map[string]interface{}, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(args)+len(extra))
+	for key, value := range args {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
+
+// contentLines 返回非空行，用于比较截断续读的内容
+func contentLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// readTextParts 按 max_tokens 逐部分读取文本响应直到没有续读游标，返回去掉截断说明后的各部分
+func readTextParts(t *testing.T, s *CangJieDocServer, name string, args map[string]interface{}, maxTokens int) []string {
+	t.Helper()
+	var parts []string
+	cursor := ""
+	for i := 0; i < 200; i++ {
+		extra := map[string]interface{}{"max_tokens": float64(maxTokens)}
+		if cursor != "" {
+			extra["cursor"] = cursor
+		}
+		text, isError := callTool(t, s, name, withArgs(args, extra))
+		if isError {
+			t.Fatalf("%s part %d: %s", name, i+1, text)
+		}
+		if !utf8.ValidString(text) || strings.ContainsRune(text, utf8.RuneError) {
+			t.Fatalf("%s part %d is not valid UTF-8: %q", name, i+1, text)
+		}
+		body, _, _ := strings.Cut(text, "\n\n✂️ 已按 max_tokens")
+		parts = append(parts, body)
+
+		match := textCursorPattern.FindStringSubmatch(text)
+		if match == nil {
+			return parts
+		}
+		cursor = match[1]
+	}
+	t.Fatalf("%s did not finish within 200 parts", name)
+	return nil
+}
+
+// checkTextParts 检查各部分按顺序拼接后包含完整响应的每一行，多出的只有补上的代码块标记和表头
+func checkTextParts(t *testing.T, full string, parts []string) {
+	t.Helper()
+	want := contentLines(full)
+	got := contentLines(strings.Join(parts, "\n"))
+	i := 0
+	for _, line := range got {
+		if i < len(want) && line == want[i] {
+			i++
+		}
+	}
+	if i < len(want) {
+		t.Fatalf("budgeted parts are missing line %d %q of the full response", i+1, want[i])
+	}
+	if extra := len(got) - len(want); extra > 3*len(parts) {
+		t.Fatalf("budgeted parts repeat %d lines across %d parts", extra, len(parts))
+	}
+}
+
+func TestTokenBudgetTextRoundTrip(t *testing.T) {
+	s := newTestServer(t)
+	for _, category := range []string{"manual", "libs"} {
+		args := map[string]interface{}{"view_type": "tree", "category": category, "level": float64(0)}
+		full, isError := callTool(t, s, "cangjie_docs_overview", args)
+		if isError {
+			t.Fatal(full)
+		}
+		// 树形视图由文档集合生成，重复读取以发现不稳定的输出顺序
+		for i := 0; i < 10; i++ {
+			parts := readTextParts(t, s, "cangjie_docs_overview", args, 200)
+			if len(parts) < 2 {
+				t.Fatalf("%s tree fits into one part, the test needs a longer response", category)
+			}
+			checkTextParts(t, full, parts)
+		}
+	}
+
+	s, docID := newLongDocServer(t)
+	args := map[string]interface{}{"doc_id": docID}
+	full, _ := callTool(t, s, "cangjie_get_doc", args)
+	parts := readTextParts(t, s, "cangjie_get_doc", args, 300)
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts, want at least 3", len(parts))
+	}
+	checkTextParts(t, full, parts)
+}
+
+// jsonField 按 truncated.field 中的路径（如 categories[0].subcategories）取出字段
+func jsonField(response map[string]interface{}, path string) interface{} {
+	var value interface{} = response
+	for _, key := range strings.Split(path, ".") {
+		key, index := strings.CutSuffix(key, "[0]")
+		value = value.(map[string]interface{})[key]
+		if index {
+			value = value.([]interface{})[0]
+		}
+	}
+	return value
+}
+
+func TestTokenBudgetJSONRoundTrip(t *testing.T) {
+	longDoc, docID := newLongDocServer(t)
+	corpus := newTestServer(t)
+	tests := []struct {
+		server *CangJieDocServer
+		name   string
+		args   map[string]interface{}
+	}{
+		{longDoc, "cangjie_get_doc", map[string]interface{}{"doc_id": docID, "format": "json", "include_metadata": false}},
+		{corpus, "cangjie_search", map[string]interface{}{"query": "仓颉", "max_results": float64(20)}},
+	}
+	for _, tt := range tests {
+		s := tt.server
+		full, isError := callTool(t, s, tt.name, tt.args)
+		if isError {
+			t.Fatal(full)
+		}
+		var fullResponse map[string]interface{}
+		if err := json.Unmarshal([]byte(full), &fullResponse); err != nil {
+			t.Fatal(err)
+		}
+
+		var collected []interface{}
+		var texts []string
+		field, cursor := "", ""
+		for part := 1; ; part++ {
+			extra := map[string]interface{}{"max_tokens": float64(300)}
+			if cursor != "" {
+				extra["cursor"] = cursor
+			}
+			text, isError := callTool(t, s, tt.name, withArgs(tt.args, extra))
+			if isError {
+				t.Fatalf("%s part %d: %s", tt.name, part, text)
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal([]byte(text), &response); err != nil {
+				t.Fatalf("%s part %d: %v", tt.name, part, err)
+			}
+			truncated, ok := response["truncated"].(map[string]interface{})
+			if !ok {
+				if part == 1 {
+					t.Fatalf("%s fits into one part, the test needs a longer response", tt.name)
+				}
+				t.Fatalf("%s part %d has no truncated info", tt.name, part)
+			}
+			field = truncated["field"].(string)
+			switch value := jsonField(response, field).(type) {
+			case string:
+				texts = append(texts, value)
+			case []interface{}:
+				collected = append(collected, value...)
+			}
+			if _, more := truncated["remaining"]; !more {
+				if _, more = truncated["remaining_tokens"]; !more {
+					break
+				}
+			}
+			cursor = response["nextCursor"].(string)
+		}
+
+		want := jsonField(fullResponse, field)
+		if text, ok := want.(string); ok {
+			checkTextParts(t, text, texts)
+		} else if !reflect.DeepEqual(collected, want) {
+			t.Fatalf("%s: collected %s does not match the full response", tt.name, field)
+		}
+	}
+}
+
+func TestTokenBudgetRejectsChangedResponse(t *testing.T) {
+	s, docID := newLongDocServer(t)
+	args := map[string]interface{}{"doc_id": docID, "max_tokens": float64(300)}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	cursor := s.encodeCursor(cursorQuery("cangjie_get_doc", request), 0, 10, contentDigest("another response"))
+
+	text, isError := callTool(t, s, "cangjie_get_doc", withArgs(args, map[string]interface{}{"cursor": cursor}))
+	if !isError || !strings.Contains(text, "cursor expired") {
+		t.Fatalf("stale cursor accepted: %s", text)
+	}
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
+)
+
+// readingSection 按顺序通读文档时的一节
+type readingSection struct {
+	id      string
+	title   string
+	line    int // 起始行号（从1开始）
+	content string
+}
+
+// contentChunk 按行或 token 截取的一段内容
+type contentChunk struct {
+	text       string
+	offset     int  // 本段的起始位置
+	nextOffset int  // 下一段的起始位置
+	more       bool // 是否还有剩余内容
+	total      int  // 总行数或总 token 数
+	firstLine  int  // 本段的起止行号（从1开始）
+	lastLine   int
+}
+
+// readingSections 把文档划分为互不重叠的节：只有一个一级标题时以其下最高一级的章节为节，否则以最高一级的章节为节
+// 第一节之前的内容（标题、简介）并入第一节，没有标题时整个文档为一节
+func readingSections(toc *types.DocumentTOC, content, title string) []readingSection {
+	level, titles := 0, 0
+	for _, section := range toc.Sections {
+		if section.Level == 1 {
+			titles++
+		}
+	}
+	for _, section := range toc.Sections {
+		if (titles != 1 || section.Level > 1) && (level == 0 || section.Level < level) {
+			level = section.Level
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	var sections []readingSection
+	for _, section := range toc.Sections {
+		if section.Level != level || (len(sections) > 0 && section.LineNumber <= sections[len(sections)-1].line) {
+			continue
+		}
+		if len(sections) == 0 {
+			sections = append(sections, readingSection{id: section.ID, title: section.Title, line: 1})
+		} else {
+			sections = append(sections, readingSection{id: section.ID, title: section.Title, line: section.LineNumber})
+		}
+	}
+	if len(sections) == 0 {
+		sections = append(sections, readingSection{title: title, line: 1})
+	}
+	for i := range sections {
+		end := len(lines)
+		if i+1 < len(sections) {
+			end = sections[i+1].line - 1
+		}
+		sections[i].content = strings.Join(lines[sections[i].line-1:end], "\n")
+	}
+	return sections
+}
+
+// chunkContent 从 offset 开始截取不超过 limit 行（或 token）的内容，按 token 截取时以整行为单位且至少保留一行
+// 从代码块或表格中间开始时补上代码块的开始标记或表头，在代码块中间结束时补上结束标记
+// offset 超出内容末尾时返回错误（空内容允许从0开始读取）
+func chunkContent(content string, offset, limit int, unit string) (contentChunk, error) {
+	lines := strings.Split(content, "\n")
+	start, end := 0, 0
+	chunk := contentChunk{}
+	if unit == types.ChunkUnitToken {
+		// positions[i] 为第 i 行之前的 token 数
+		positions := make([]int, len(lines)+1)
+		for i, line := range lines {
+			positions[i+1] = positions[i] + utils.EstimateTokens(line+"\n")
+		}
+		if offset > 0 && offset >= positions[len(lines)] {
+			return contentChunk{}, fmt.Errorf("offset out of range: %d (content has %d tokens)", offset, positions[len(lines)])
+		}
+		for start < len(lines) && positions[start+1] <= offset {
+			start++
+		}
+		end = start
+		for end < len(lines) && (end == start || positions[end+1]-positions[start] <= limit) {
+			end++
+		}
+		chunk.offset, chunk.nextOffset, chunk.total = positions[start], positions[end], positions[len(lines)]
+	} else {
+		if offset > 0 && offset >= len(lines) {
+			return contentChunk{}, fmt.Errorf("offset out of range: %d (content has %d lines)", offset, len(lines))
+		}
+		start = offset
+		end = min(start+limit, len(lines))
+		chunk.offset, chunk.nextOffset, chunk.total = start, end, len(lines)
+	}
+	chunk.more = end < len(lines)
+	chunk.firstLine, chunk.lastLine = start+1, end
+
+	position := len(strings.Join(lines[:start], "\n"))
+	if start > 0 {
+		position++
+	}
+	text := resumePrefix(content, position) + strings.Join(lines[start:end], "\n")
+	if fence := openFence(strings.Join(lines[:end], "\n")); fence != "" && chunk.more {
+		text += "\n" + fenceMarker(fence)
+	}
+	chunk.text = text
+	return chunk, nil
+}
+
+// readingFooter 生成分节和分块读取的位置说明
+func readingFooter(sectionInfo, chunkInfo map[string]interface{}) string {
+	var builder strings.Builder
+	builder.WriteString("\n\n---\n")
+	if sectionInfo != nil {
+		fmt.Fprintf(&builder, "📑 第 %d/%d 节：%s", sectionInfo["section_index"], sectionInfo["section_count"], sectionInfo["section_title"])
+		if next, ok := sectionInfo["next_section_index"]; ok {
+			fmt.Fprintf(&builder, " | 下一节: section_index=%d", next)
+		}
+		builder.WriteString("\n")
+	}
+	if chunkInfo != nil {
+		unit := " 行"
+		if chunkInfo["unit"] == types.ChunkUnitToken {
+			unit = " tokens"
+		}
+		fmt.Fprintf(&builder, "📄 第 %s 行（共 %d%s）", chunkInfo["lines"], chunkInfo["total"], unit)
+		if next, ok := chunkInfo["next_offset"]; ok {
+			fmt.Fprintf(&builder, " | next_offset: %d", next)
+		} else {
+			builder.WriteString(" | 已读完")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
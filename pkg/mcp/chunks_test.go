@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestChunkContentOffsetOutOfRange(t *testing.T) {
+	content := "第一行\n第二行\n第三行"
+	for _, unit := range []string{types.ChunkUnitLine, types.ChunkUnitToken} {
+		last, err := chunkContent(content, 0, 100, unit)
+		if err != nil {
+			t.Fatalf("%s: offset 0: %v", unit, err)
+		}
+		if last.total == 0 || last.more {
+			t.Fatalf("%s: offset 0 = %+v, want the whole content", unit, last)
+		}
+
+		chunk, err := chunkContent(content, last.total-1, 100, unit)
+		if err != nil {
+			t.Errorf("%s: last offset %d: %v", unit, last.total-1, err)
+		} else if chunk.text != "第三行" || chunk.firstLine != 3 || chunk.lastLine != 3 {
+			t.Errorf("%s: last offset %d = %+v, want the last line", unit, last.total-1, chunk)
+		}
+
+		for _, offset := range []int{last.total, last.total + 5} {
+			if chunk, err := chunkContent(content, offset, 100, unit); err == nil {
+				t.Errorf("%s: offset %d = %+v, want an error", unit, offset, chunk)
+			} else if !strings.Contains(err.Error(), "offset out of range") {
+				t.Errorf("%s: offset %d error = %v", unit, offset, err)
+			}
+		}
+	}
+
+	if _, err := chunkContent("", 0, 10, types.ChunkUnitToken); err != nil {
+		t.Errorf("empty content at offset 0: %v", err)
+	}
+}
+
+func TestGetDocOffsetOutOfRange(t *testing.T) {
+	s, docID := newLongDocServer(t)
+	text, isError := callTool(t, s, "cangjie_get_doc", map[string]interface{}{"doc_id": docID, "offset": float64(100000), "limit": float64(10)})
+	if !isError || !strings.Contains(text, "offset out of range: 100000") {
+		t.Errorf("cangjie_get_doc with offset past the end = %q (error %v), want an out of range error", text, isError)
+	}
+
+	text, isError = callTool(t, s, "cangjie_get_doc", map[string]interface{}{"doc_id": docID, "offset": float64(0), "limit": float64(10)})
+	if isError {
+		t.Errorf("cangjie_get_doc with offset 0 failed: %s", text)
+	}
+}
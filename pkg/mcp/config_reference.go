@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 配置项匹配分数
+const (
+	configExactScore       = 10.0 // 名称完全相同
+	configAliasScore       = 9.0  // 别名完全相同
+	configPrefixScore      = 5.0  // 名称前缀
+	configContainsScore    = 3.0  // 名称包含查询词
+	configDescriptionScore = 1.0  // 说明或章节包含查询词
+)
+
+// handleConfigReference 处理配置参考查询
+func (s *CangJieDocServer) handleConfigReference(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	tool := ""
+	if t, ok := request.GetArguments()["tool"].(string); ok {
+		tool = t
+	}
+
+	maxResults := types.DefaultMaxResults
+	if mr, ok := request.GetArguments()["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+	}
+
+	type scoredEntry struct {
+		entry types.ConfigEntry
+		score float64
+	}
+
+	var matches []scoredEntry
+	for _, entry := range s.configEntries {
+		if tool != "" && entry.Tool != tool {
+			continue
+		}
+		if score := configMatchScore(entry, query); score > 0 {
+			matches = append(matches, scoredEntry{entry, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.Key < matches[j].entry.Key
+	})
+
+	total := len(matches)
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	var results []types.ConfigEntry
+	for _, match := range matches {
+		results = append(results, match.entry)
+	}
+
+	response := map[string]interface{}{
+		"query":   query,
+		"count":   len(results),
+		"total":   total,
+		"results": results,
+	}
+	if total == 0 {
+		response["hint"] = "未找到匹配的配置项，可以用 cangjie_search 在 tools 分类中搜索"
+	}
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// configMatchScore 计算配置项与查询的匹配分数，选项前的 - 和取值部分不参与比较
+func configMatchScore(entry types.ConfigEntry, query string) float64 {
+	q := normalizeConfigName(query)
+	if q == "" {
+		return 0
+	}
+
+	key := normalizeConfigName(entry.Key)
+	if key == q {
+		return configExactScore
+	}
+	for _, alias := range entry.Aliases {
+		if normalizeConfigName(alias) == q {
+			return configAliasScore
+		}
+	}
+	// 过短的查询只做精确匹配，避免 -i 匹配到所有含 i 的配置项
+	if len(q) < 3 {
+		return 0
+	}
+	switch {
+	case strings.HasPrefix(key, q):
+		return configPrefixScore
+	case strings.Contains(key, q):
+		return configContainsScore
+	}
+
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	if strings.Contains(strings.ToLower(entry.Description), lowerQuery) ||
+		strings.Contains(strings.ToLower(entry.Section), lowerQuery) {
+		return configDescriptionScore
+	}
+	return 0
+}
+
+// normalizeConfigName 规范化配置名：去掉前导 -、取值部分和引号，统一小写
+// 如 "--output-type=exe" -> "output-type"
+func normalizeConfigName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if idx := strings.IndexAny(name, "=[<"); idx > 0 {
+		name = name[:idx]
+	}
+	name = strings.Trim(name, "`\"' ")
+	return strings.TrimLeft(name, "-")
+}
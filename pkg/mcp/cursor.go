@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// pageCursor 分页游标的内容，编码后对客户端不透明
+type pageCursor struct {
+	Snapshot string `json:"s"`           // 索引快照，文档重新索引后游标失效
+	Query    string `json:"q"`           // 查询标识，游标只能用于参数相同的查询
+	Offset   int    `json:"o"`           // 下一页的起始位置
+	Part     int    `json:"p,omitempty"` // 响应按 max_tokens 截断时，剩余内容在响应中的位置
+	Digest   string `json:"d,omitempty"` // 截断内容的摘要，内容变化后 Part 不再有效
+}
+
+// cursorQuery 根据工具名和除 cursor 外的参数生成查询标识
+func cursorQuery(tool string, request mcp.CallToolRequest) string {
+	args := make(map[string]interface{})
+	for key, value := range request.GetArguments() {
+		if key != "cursor" {
+			args[key] = value
+		}
+	}
+	// encoding/json 按键名排序输出 map，相同参数得到相同的标识
+	data, _ := json.Marshal(args)
+	hash := fnv.New64a()
+	hash.Write([]byte(tool))
+	hash.Write(data)
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// pageOffset 解析请求中的 cursor 参数，返回当前页的起始位置；没有 cursor 时从 0 开始
+func (s *CangJieDocServer) pageOffset(request mcp.CallToolRequest, query string) (int, error) {
+	cursor, err := s.decodeCursor(request, query)
+	return cursor.Offset, err
+}
+
+// decodeCursor 解析并校验请求中的 cursor 参数；没有 cursor 时返回零值
+func (s *CangJieDocServer) decodeCursor(request mcp.CallToolRequest, query string) (pageCursor, error) {
+	encoded, _ := request.GetArguments()["cursor"].(string)
+	if encoded == "" {
+		return pageCursor{}, nil
+	}
+
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.Offset < 0 || cursor.Part < 0 {
+		return pageCursor{}, fmt.Errorf("invalid cursor: %s", encoded)
+	}
+	if cursor.Query != query {
+		return pageCursor{}, fmt.Errorf("cursor does not match the query parameters, use the same parameters as the previous page")
+	}
+	if cursor.Snapshot != s.searchEngine.Snapshot() {
+		return pageCursor{}, fmt.Errorf("cursor expired: documents were re-indexed, restart the query without cursor")
+	}
+	return cursor, nil
+}
+
+// nextCursor 返回下一页的游标，已到最后一页时返回空字符串
+func (s *CangJieDocServer) nextCursor(query string, offset, pageSize, total int) string {
+	if pageSize <= 0 || offset+pageSize >= total {
+		return ""
+	}
+	return s.encodeCursor(query, offset+pageSize, 0, "")
+}
+
+// encodeCursor 生成游标：offset 为页的起始位置，part 为截断响应中剩余内容的位置，digest 为截断内容的摘要
+func (s *CangJieDocServer) encodeCursor(query string, offset, part int, digest string) string {
+	data, _ := json.Marshal(pageCursor{
+		Snapshot: s.searchEngine.Snapshot(),
+		Query:    query,
+		Offset:   offset,
+		Part:     part,
+		Digest:   digest,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// contentDigest 计算截断内容的摘要
+func contentDigest(parts ...string) string {
+	hash := fnv.New64a()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listDocIDPattern 匹配文档列表表格行中的文档ID
+var listDocIDPattern = regexp.MustCompile(`(?m)^\| (\S+) \| `)
+
+// cursorRequest 生成带参数的工具请求
+func cursorRequest(args map[string]interface{}) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	return request
+}
+
+func TestDecodeCursor(t *testing.T) {
+	s := newTestServer(t)
+	args := map[string]interface{}{"query": "hashmap"}
+	query := cursorQuery("cangjie_search", cursorRequest(args))
+
+	cursor, err := s.decodeCursor(cursorRequest(withArgs(args, map[string]interface{}{"cursor": s.encodeCursor(query, 7, 2, "abc")})), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.Offset != 7 || cursor.Part != 2 || cursor.Digest != "abc" || cursor.Snapshot != s.searchEngine.Snapshot() {
+		t.Errorf("decodeCursor = %+v", cursor)
+	}
+	if cursor, err := s.decodeCursor(cursorRequest(args), query); err != nil || cursor != (pageCursor{}) {
+		t.Errorf("decodeCursor without cursor = %+v, %v; want zero value", cursor, err)
+	}
+
+	negative, _ := json.Marshal(pageCursor{Snapshot: s.searchEngine.Snapshot(), Query: query, Offset: -1})
+	tests := []struct {
+		name   string
+		cursor string
+		query  string
+		want   string
+	}{
+		{"not base64", "!!!", query, "invalid cursor"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("offset=10")), query, "invalid cursor"},
+		{"negative offset", base64.RawURLEncoding.EncodeToString(negative), query, "invalid cursor"},
+		{"other query", s.encodeCursor(cursorQuery("cangjie_search", cursorRequest(map[string]interface{}{"query": "string"})), 5, 0, ""), query, "does not match"},
+		{"other tool", s.encodeCursor(cursorQuery("cangjie_list_docs", cursorRequest(args)), 5, 0, ""), query, "does not match"},
+	}
+	for _, tt := range tests {
+		_, err := s.decodeCursor(cursorRequest(withArgs(args, map[string]interface{}{"cursor": tt.cursor})), tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCursorExpiresAfterReindex(t *testing.T) {
+	s := newTestServer(t)
+	args := map[string]interface{}{"query": "hashmap", "max_results": float64(1)}
+	text, isError := callTool(t, s, "cangjie_search", args)
+	if isError {
+		t.Fatal(text)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatal(err)
+	}
+	cursor, _ := response["nextCursor"].(string)
+	if cursor == "" {
+		t.Fatal("search returned no nextCursor")
+	}
+
+	// 文档没有变化，重新扫描建立索引后旧游标也失效
+	snapshot := s.searchEngine.Snapshot()
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if s.searchEngine.Snapshot() == snapshot {
+		t.Fatal("snapshot did not change after re-indexing")
+	}
+	text, isError = callTool(t, s, "cangjie_search", withArgs(args, map[string]interface{}{"cursor": cursor}))
+	if !isError || !strings.Contains(text, "cursor expired") {
+		t.Errorf("stale cursor = %q (error %v), want cursor expired", text, isError)
+	}
+}
+
+// searchPageIDs 按游标逐页搜索，返回各页结果的文档ID
+func searchPageIDs(t *testing.T, s *CangJieDocServer, args map[string]interface{}) ([]string, int) {
+	t.Helper()
+	var ids []string
+	pages := 0
+	cursor := ""
+	for {
+		pageArgs := args
+		if cursor != "" {
+			pageArgs = withArgs(args, map[string]interface{}{"cursor": cursor})
+		}
+		text, isError := callTool(t, s, "cangjie_search", pageArgs)
+		if isError {
+			t.Fatalf("page %d: %s", pages+1, text)
+		}
+		var response struct {
+			Total      int    `json:"total"`
+			NextCursor string `json:"nextCursor"`
+			Results    []struct {
+				Document struct {
+					ID string `json:"id"`
+				} `json:"document"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range response.Results {
+			ids = append(ids, result.Document.ID)
+		}
+		pages++
+		if response.NextCursor == "" {
+			if len(ids) != response.Total {
+				t.Errorf("collected %d results, total is %d", len(ids), response.Total)
+			}
+			return ids, pages
+		}
+		cursor = response.NextCursor
+	}
+}
+
+func TestSearchCursorPaging(t *testing.T) {
+	s := newTestServer(t)
+	for _, query := range []string{"hashmap", "文件 读取"} {
+		full, _ := searchPageIDs(t, s, map[string]interface{}{"query": query, "max_results": float64(100)})
+		paged, pages := searchPageIDs(t, s, map[string]interface{}{"query": query, "max_results": float64(2)})
+		if pages < 2 {
+			t.Fatalf("%q: got %d pages, the test needs more results", query, pages)
+		}
+		if !reflect.DeepEqual(paged, full) {
+			t.Errorf("%q: paged results %v, want %v", query, paged, full)
+		}
+	}
+
+	// 游标不能换用不同的分页大小
+	args := map[string]interface{}{"query": "hashmap", "max_results": float64(2)}
+	text, _ := callTool(t, s, "cangjie_search", args)
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatal(err)
+	}
+	text, isError := callTool(t, s, "cangjie_search", map[string]interface{}{"query": "hashmap", "max_results": float64(5), "cursor": response["nextCursor"]})
+	if !isError || !strings.Contains(text, "does not match") {
+		t.Errorf("cursor reused with another max_results = %q (error %v), want a mismatch error", text, isError)
+	}
+}
+
+// listPageIDs 按游标逐页列出文档，返回各页的文档ID和页数
+func listPageIDs(t *testing.T, s *CangJieDocServer, args map[string]interface{}) ([]string, int) {
+	t.Helper()
+	var ids []string
+	cursor := ""
+	page := 1
+	for ; ; page++ {
+		pageArgs := args
+		if cursor != "" {
+			pageArgs = withArgs(args, map[string]interface{}{"cursor": cursor})
+		}
+		text, isError := callTool(t, s, "cangjie_list_docs", pageArgs)
+		if isError {
+			t.Fatalf("page %d: %s", page, text)
+		}
+		for _, m := range listDocIDPattern.FindAllStringSubmatch(text, -1) {
+			if m[1] != "ID" {
+				ids = append(ids, m[1])
+			}
+		}
+		m := textCursorPattern.FindStringSubmatch(text)
+		if m == nil {
+			break
+		}
+		cursor = m[1]
+	}
+	return ids, page
+}
+
+func TestListDocsCursorPaging(t *testing.T) {
+	s := newTestServer(t)
+	args := map[string]interface{}{"category": "libs", "subcategory": "std/collection", "sort_by": "title"}
+	full, _ := listPageIDs(t, s, args)
+	if len(full) != 2 {
+		t.Fatalf("listed %v, want the 2 std/collection documents", full)
+	}
+	paged, pages := listPageIDs(t, s, withArgs(args, map[string]interface{}{"max_items": float64(1)}))
+	if pages != 2 || !reflect.DeepEqual(paged, full) {
+		t.Errorf("paged documents = %v in %d pages, want %v in 2 pages", paged, pages, full)
+	}
+}
+
+func TestOverviewCursorPaging(t *testing.T) {
+	s := newTestServer(t)
+
+	// map 视图按文档分页
+	var docs []string
+	cursor := ""
+	for page := 1; ; page++ {
+		args := map[string]interface{}{"category": "libs", "view_type": "map", "max_items": float64(3)}
+		if cursor != "" {
+			args["cursor"] = cursor
+		}
+		text, isError := callTool(t, s, "cangjie_docs_overview", args)
+		if isError {
+			t.Fatalf("map page %d: %s", page, text)
+		}
+		var response struct {
+			Categories map[string]map[string][]struct {
+				ID string `json:"id"`
+			} `json:"categories"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for _, subcategories := range response.Categories {
+			for _, entries := range subcategories {
+				for _, entry := range entries {
+					docs = append(docs, entry.ID)
+					count++
+				}
+			}
+		}
+		if count > 3 {
+			t.Errorf("map page %d has %d documents, want at most 3", page, count)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+	seen := make(map[string]bool)
+	for _, id := range docs {
+		if seen[id] {
+			t.Errorf("document %s listed on more than one page", id)
+		}
+		seen[id] = true
+	}
+	if len(docs) != 10 {
+		t.Errorf("map pages listed %d documents, want all 10 libs documents", len(docs))
+	}
+
+	// overview 视图按子分类分页
+	var subcategories []string
+	cursor = ""
+	for page := 1; ; page++ {
+		args := map[string]interface{}{"category": "libs", "max_items": float64(4)}
+		if cursor != "" {
+			args["cursor"] = cursor
+		}
+		text, isError := callTool(t, s, "cangjie_docs_overview", args)
+		if isError {
+			t.Fatalf("overview page %d: %s", page, text)
+		}
+		var response struct {
+			Categories []struct {
+				Subcategories []struct {
+					Name string `json:"name"`
+				} `json:"subcategories"`
+			} `json:"categories"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal([]byte(text), &response); err != nil {
+			t.Fatal(err)
+		}
+		for _, category := range response.Categories {
+			for _, subcategory := range category.Subcategories {
+				subcategories = append(subcategories, subcategory.Name)
+			}
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+	want := []string{"std/collection", "std/convert", "std/core", "std/fs", "std/math", "std/random", "std/regex", "std/sync", "std/time"}
+	if !reflect.DeepEqual(subcategories, want) {
+		t.Errorf("overview pages = %v, want %v", subcategories, want)
+	}
+}
@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cangje-docs-mcp/pkg/cjlang"
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 诊断信息检索时各类查询的权重
+const (
+	diagnosticCodeWeight       = 2.0 // 错误码
+	diagnosticIdentifierWeight = 0.5 // 信息中的标识符
+	diagnosticTopicWeight      = 1.0 // 语言概念词
+	maxDiagnostics             = 10  // 最多解释的诊断条数
+)
+
+// diagnosticDoc 诊断信息对应的文档及其综合分数
+type diagnosticDoc struct {
+	doc       *types.Document
+	score     float64
+	matchText string
+	matchedBy []string
+}
+
+// handleExplainDiagnostic 处理编译诊断解释请求
+func (s *CangJieDocServer) handleExplainDiagnostic(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	output, err := request.RequireString("output")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	maxResults := 5
+	if mr, ok := request.GetArguments()["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+	}
+
+	diagnostics := cjlang.ParseDiagnostics(output)
+	if len(diagnostics) == 0 {
+		return mcp.NewToolResultError("no cjc/cjpm error or warning found in output"), nil
+	}
+
+	// 错误优先于警告
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Severity == "error" && diagnostics[j].Severity != "error"
+	})
+	truncated := len(diagnostics) > maxDiagnostics
+	if truncated {
+		diagnostics = diagnostics[:maxDiagnostics]
+	}
+
+	var explained []map[string]interface{}
+	for _, diagnostic := range diagnostics {
+		var docs []map[string]interface{}
+		for _, match := range s.diagnosticDocs(diagnostic, maxResults) {
+			docs = append(docs, map[string]interface{}{
+				"id":         match.doc.ID,
+				"title":      match.doc.Title,
+				"category":   match.doc.Category,
+				"score":      match.score,
+				"matched_by": match.matchedBy,
+				"match_text": match.matchText,
+			})
+		}
+
+		entry := map[string]interface{}{
+			"diagnostic": diagnostic,
+			"docs":       docs,
+		}
+		// 未声明的标识符可能只是缺少 import
+		var imports []map[string]interface{}
+		for _, identifier := range diagnostic.Identifiers {
+			for _, symbol := range s.searchEngine.LookupSymbol(normalizeIdentifier(identifier)) {
+				imports = append(imports, symbolDefinition(symbol))
+			}
+		}
+		if len(imports) > 0 {
+			entry["possible_imports"] = imports
+		}
+		explained = append(explained, entry)
+	}
+
+	response := map[string]interface{}{
+		"count":       len(explained),
+		"diagnostics": explained,
+		"hint":        "使用 cangjie_get_doc 阅读相关文档；possible_imports 列出了信息中引用的标准库符号及其导入语句",
+	}
+	if truncated {
+		response["truncated"] = true
+	}
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// diagnosticDocs 检索解释诊断信息的手册和工具文档
+// 分别用错误码、概念词和标识符查询，按各查询内的相对分数加权累加后排序
+func (s *CangJieDocServer) diagnosticDocs(diagnostic cjlang.Diagnostic, maxResults int) []*diagnosticDoc {
+	type weightedQuery struct {
+		query  string
+		weight float64
+	}
+
+	var queries []weightedQuery
+	if diagnostic.Code != "" {
+		queries = append(queries, weightedQuery{diagnostic.Code, diagnosticCodeWeight})
+	}
+	for _, topic := range diagnostic.Topics {
+		queries = append(queries, weightedQuery{topic, diagnosticTopicWeight})
+	}
+	for _, identifier := range diagnostic.Identifiers {
+		// 单字符的变量名等检索不到有意义的文档
+		if len([]rune(identifier)) > 1 {
+			queries = append(queries, weightedQuery{identifier, diagnosticIdentifierWeight})
+		}
+	}
+
+	matches := make(map[string]*diagnosticDoc)
+	for _, q := range queries {
+		for _, category := range []types.DocumentCategory{types.CategoryManual, types.CategoryTools} {
+			results := s.searchEngine.Search(types.SearchRequest{
+				Query:      q.query,
+				Category:   category,
+				MaxResults: maxResults * 4,
+			})
+			if len(results) == 0 {
+				continue
+			}
+
+			topScore := results[0].Score
+			for _, result := range results {
+				docID := result.Document.ID
+				match, exists := matches[docID]
+				if !exists {
+					match = &diagnosticDoc{doc: s.documents[docID], matchText: result.MatchText}
+					if match.doc == nil {
+						continue
+					}
+					matches[docID] = match
+				}
+				match.score += q.weight * result.Score / topScore
+				match.matchedBy = append(match.matchedBy, q.query)
+			}
+		}
+	}
+
+	var ranked []*diagnosticDoc
+	for _, match := range matches {
+		ranked = append(ranked, match)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].doc.ID < ranked[j].doc.ID
+	})
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+	return ranked
+}
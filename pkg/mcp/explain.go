@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ExplainSearch 执行搜索并把每个结果的分数组成输出为文本，用于离线调整权重
+// args 与 cangjie_search 的参数相同（数值参数为 float64），未给出的参数使用与工具相同的默认值和默认搜索范围
+func (s *CangJieDocServer) ExplainSearch(w io.Writer, args map[string]interface{}) error {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "cangjie_search"
+	request.Params.Arguments = args
+	req, err := s.searchRequest(request)
+	if err != nil {
+		return err
+	}
+	req.Explain = true
+	page := s.searchEngine.SearchPage(req)
+
+	fmt.Fprintf(w, "查询: %s | 模式: %s | 结果: %d/%d\n", req.Query, req.Mode, len(page.Results), page.Total)
+	if expanded := s.searchEngine.ExpandQuery(req.Query); len(expanded) > 0 {
+		fmt.Fprintf(w, "扩展词: %s\n", strings.Join(expanded, ", "))
+	}
+	if len(page.Results) > 0 && page.Results[0].Explanation != nil {
+		fmt.Fprintf(w, "计算方式: %s\n", page.Results[0].Explanation.Normalization)
+	}
+
+	for i, result := range page.Results {
+		fmt.Fprintf(w, "\n%d. %s (%s) 分数 %.4f [%s]\n", i+1, result.Document.Title, result.Document.ID, result.Score, result.MatchType)
+		explanation := result.Explanation
+		if explanation == nil {
+			continue
+		}
+		for _, c := range explanation.Components {
+			term := c.Term
+			if term != "" {
+				term = fmt.Sprintf("%q", term)
+			}
+			if c.Stage == "rrf" {
+				// 倒数排名融合的一项：该排名来源中的排名及 1/(60+排名)
+				fmt.Fprintf(w, "   %-8s %-12s rank %-15d %8.4f\n", c.Stage, c.Field, c.Count, c.Score)
+				continue
+			}
+			fmt.Fprintf(w, "   %-8s %-12s %-20s %8.4f × %-3d = %8.4f\n", c.Stage, c.Field, term, c.Weight, c.Count, c.Score)
+		}
+		line := fmt.Sprintf("   基础分 %.4f", explanation.Base)
+		for _, boost := range explanation.Boosts {
+			line += fmt.Sprintf(" × %s %.2f", boost.Name, boost.Factor)
+		}
+		fmt.Fprintf(w, "%s = %.4f\n", line, explanation.Score)
+	}
+	return nil
+}
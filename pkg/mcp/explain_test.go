@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// explainResultLine 匹配 ExplainSearch 输出中每个结果的首行，如 "1. 标题 (doc_id) 分数 ..."
+var explainResultLine = regexp.MustCompile(`(?m)^\d+\. .* \(([^()\s]+)\) 分数 `)
+
+// explainDocIDs 返回 ExplainSearch 输出的结果文档ID
+func explainDocIDs(t *testing.T, s *CangJieDocServer, args map[string]interface{}) []string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.ExplainSearch(&out, args); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range explainResultLine.FindAllStringSubmatch(out.String(), -1) {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
+
+// searchDocIDs 返回 cangjie_search 结果的文档ID
+func searchDocIDs(t *testing.T, s *CangJieDocServer, args map[string]interface{}) []string {
+	t.Helper()
+	text, isError := callTool(t, s, "cangjie_search", args)
+	if isError {
+		t.Fatalf("cangjie_search failed: %s", text)
+	}
+	var response struct {
+		Results []struct {
+			Document struct {
+				ID string `json:"id"`
+			} `json:"document"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, result := range response.Results {
+		ids = append(ids, result.Document.ID)
+	}
+	return ids
+}
+
+func TestExplainSearchMatchesTool(t *testing.T) {
+	s := newTestServer(t)
+	for _, mode := range []string{types.SearchModeKeyword, types.SearchModeHybrid} {
+		args := map[string]interface{}{"query": "仓颉 集合", "mode": mode, "max_results": float64(20)}
+		want := searchDocIDs(t, s, args)
+		if len(want) == 0 {
+			t.Fatalf("%s: cangjie_search returned no results", mode)
+		}
+		if got := explainDocIDs(t, s, args); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ExplainSearch results = %v, want the cangjie_search results %v", mode, got, want)
+		}
+	}
+
+	// 设置了默认搜索范围时两者都在范围内搜索
+	if text, isError := callTool(t, s, "cangjie_project_context", map[string]interface{}{
+		"project_path": newTestProject(t), "set_default": true, "scope_mode": types.ScopeModeFilter,
+	}); isError {
+		t.Fatalf("cangjie_project_context failed: %s", text)
+	}
+	args := map[string]interface{}{"query": "仓颉 集合", "max_results": float64(20)}
+	want := searchDocIDs(t, s, args)
+	got := explainDocIDs(t, s, args)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scoped ExplainSearch results = %v, want the cangjie_search results %v", got, want)
+	}
+	if len(got) == 0 {
+		t.Fatal("scoped ExplainSearch returned no results")
+	}
+	for _, id := range got {
+		if !s.defaultScope.DocIDs[id] {
+			t.Errorf("scoped ExplainSearch returned %s outside the default scope", id)
+		}
+	}
+}
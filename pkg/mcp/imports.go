@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var (
+	// identifierSeparator 标识符列表的分隔符
+	identifierSeparator = regexp.MustCompile(`[\s,，;；]+`)
+	// genericArguments 泛型参数，如 <K, V>，切分前先去掉以免被逗号拆开
+	genericArguments = regexp.MustCompile(`<[^<>]*>`)
+)
+
+// handleWhichImport 处理导入路径查询
+func (s *CangJieDocServer) handleWhichImport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	identifiers, err := request.RequireString("identifiers")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	for genericArguments.MatchString(identifiers) {
+		identifiers = genericArguments.ReplaceAllString(identifiers, "")
+	}
+
+	var results []map[string]interface{}
+	seen := make(map[string]bool)
+	for _, identifier := range identifierSeparator.Split(identifiers, -1) {
+		name := normalizeIdentifier(identifier)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		results = append(results, s.resolveImport(identifier, name))
+	}
+
+	if len(results) == 0 {
+		return mcp.NewToolResultError("no identifier given"), nil
+	}
+
+	response := map[string]interface{}{
+		"count":   len(results),
+		"results": results,
+	}
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// resolveImport 查找单个标识符的导入路径；成员（如 ArrayList.add、Option.Some）按所属类型查找
+func (s *CangJieDocServer) resolveImport(identifier, name string) map[string]interface{} {
+	if owner := ownerIdentifier(identifier); owner != "" {
+		if members := s.searchEngine.LookupMember(owner, name); len(members) > 0 {
+			result := s.resolveImport(identifier, owner)
+			result["member"] = map[string]interface{}{
+				"name":    name,
+				"kind":    members[0].Kind,
+				"doc_id":  members[0].DocID,
+				"section": members[0].Section,
+			}
+			return result
+		}
+	}
+
+	result := map[string]interface{}{
+		"identifier": identifier,
+	}
+
+	symbols := s.searchEngine.LookupSymbol(name)
+	if len(symbols) == 0 {
+		result["found"] = false
+		if similar := s.searchEngine.SimilarSymbols(name, 5); len(similar) > 0 {
+			result["suggestions"] = similar
+		}
+		return result
+	}
+
+	var definitions []map[string]interface{}
+	packages := make(map[string]bool)
+	for _, symbol := range symbols {
+		packages[symbol.Package] = true
+		definitions = append(definitions, symbolDefinition(symbol))
+	}
+
+	result["found"] = true
+	result["ambiguous"] = len(packages) > 1
+	if len(packages) > 1 {
+		result["definitions"] = definitions
+		result["hint"] = "多个包中定义了同名符号，请根据用途选择，并使用完整导入路径（import 包名.符号名）避免冲突"
+	} else {
+		for key, value := range definitions[0] {
+			result[key] = value
+		}
+		if len(definitions) > 1 {
+			// 同一个包中同名的不同声明，如函数重载之外的 class 与 func
+			result["definitions"] = definitions
+		}
+	}
+	return result
+}
+
+// symbolDefinition 生成符号定义的描述
+func symbolDefinition(symbol types.APISymbol) map[string]interface{} {
+	definition := map[string]interface{}{
+		"name":          symbol.Name,
+		"kind":          symbol.Kind,
+		"package":       symbol.Package,
+		"import":        fmt.Sprintf("import %s.*", symbol.Package),
+		"import_symbol": fmt.Sprintf("import %s.%s", symbol.Package, symbol.Name),
+		"doc_id":        symbol.DocID,
+		"section":       symbol.Section,
+	}
+	if symbol.Package == "std.core" {
+		definition["note"] = "std.core 包默认导入，无需 import"
+	}
+	return definition
+}
+
+// ownerIdentifier 返回限定名中最后一段之前的部分的名称，如 ArrayList.add -> ArrayList、std.collection.ArrayList -> collection，没有限定时返回空字符串
+func ownerIdentifier(identifier string) string {
+	name := strings.TrimSpace(identifier)
+	if idx := strings.IndexAny(name, "<(["); idx >= 0 {
+		name = name[:idx]
+	}
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		return ""
+	}
+	return normalizeIdentifier(name[:idx])
+}
+
+// normalizeIdentifier 去掉标识符中的包名限定和泛型参数，如 std.collection.ArrayList<T> -> ArrayList
+func normalizeIdentifier(identifier string) string {
+	name := strings.TrimSpace(identifier)
+	if idx := strings.IndexAny(name, "<(["); idx >= 0 {
+		name = name[:idx]
+	}
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return strings.Trim(name, "`'\"")
+}
@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// importResult cangjie_which_import 返回的单个标识符结果
+type importResult struct {
+	Identifier  string   `json:"identifier"`
+	Found       bool     `json:"found"`
+	Ambiguous   bool     `json:"ambiguous"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Package     string   `json:"package"`
+	Import      string   `json:"import"`
+	DocID       string   `json:"doc_id"`
+	Section     string   `json:"section"`
+	Note        string   `json:"note"`
+	Suggestions []string `json:"suggestions"`
+	Definitions []struct {
+		Package string `json:"package"`
+		Import  string `json:"import"`
+	} `json:"definitions"`
+	Member *struct {
+		Name    string `json:"name"`
+		Kind    string `json:"kind"`
+		Section string `json:"section"`
+	} `json:"member"`
+}
+
+// whichImport 调用 cangjie_which_import 并解析结果
+func whichImport(t *testing.T, s *CangJieDocServer, identifiers string) []importResult {
+	t.Helper()
+	text, isError := callTool(t, s, "cangjie_which_import", map[string]interface{}{"identifiers": identifiers})
+	if isError {
+		t.Fatalf("cangjie_which_import %q: %s", identifiers, text)
+	}
+	var response struct {
+		Count   int            `json:"count"`
+		Results []importResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Count != len(response.Results) {
+		t.Errorf("count %d, %d results", response.Count, len(response.Results))
+	}
+	return response.Results
+}
+
+func TestWhichImport(t *testing.T) {
+	s := newTestServer(t)
+	results := whichImport(t, s, "ArrayList, HashMap<K, V>；Random std.fs.File println")
+
+	want := []struct {
+		identifier, name, kind, pkg, section string
+	}{
+		{"ArrayList", "ArrayList", "class", "std.collection", "class ArrayList<T>"},
+		{"HashMap", "HashMap", "class", "std.collection", "class HashMap<K, V>"},
+		{"Random", "Random", "class", "std.random", "class Random"},
+		{"std.fs.File", "File", "class", "std.fs", "class File"},
+		{"println", "println", "func", "std.core", "func println(String)"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Identifier != w.identifier || !got.Found || got.Ambiguous || got.Name != w.name || got.Kind != w.kind || got.Package != w.pkg || got.Section != w.section {
+			t.Errorf("result %d = %+v, want %+v", i, got, w)
+		}
+		if got.Import != "import "+w.pkg+".*" || got.DocID == "" {
+			t.Errorf("%s: import %q doc %q", w.identifier, got.Import, got.DocID)
+		}
+	}
+	if results[4].Note == "" {
+		t.Error("std.core symbol has no note about the default import")
+	}
+
+	// 重复的标识符只返回一次，没有标识符时报错
+	if results := whichImport(t, s, "ArrayList arraylist ArrayList<T>"); len(results) != 2 || results[1].Name != "ArrayList" {
+		t.Errorf("duplicates = %+v, want ArrayList and the case-insensitive arraylist", results)
+	}
+	if text, isError := callTool(t, s, "cangjie_which_import", map[string]interface{}{"identifiers": " , ；"}); !isError {
+		t.Errorf("empty identifiers = %q, want an error", text)
+	}
+}
+
+func TestWhichImportMember(t *testing.T) {
+	s := newTestServer(t)
+	results := whichImport(t, s, "ArrayList.add, std.collection.HashMap<K, V>.put, String.split, Random.missing")
+	want := []struct {
+		pkg, name, member, section string
+	}{
+		{"std.collection", "ArrayList", "add", "func add(T)"},
+		{"std.collection", "HashMap", "put", "func put(K, V)"},
+		{"std.core", "String", "split", "func split(String)"},
+	}
+	for i, w := range want {
+		got := results[i]
+		if !got.Found || got.Package != w.pkg || got.Name != w.name || got.Member == nil || got.Member.Name != w.member || got.Member.Section != w.section || got.Member.Kind != "func" {
+			t.Errorf("result %d = %+v (member %+v), want %s.%s in %s", i, got, got.Member, w.name, w.member, w.pkg)
+		}
+	}
+	// 类型中没有的成员按成员名查找
+	if missing := results[3]; missing.Found || missing.Member != nil {
+		t.Errorf("Random.missing = %+v, want not found", missing)
+	}
+}
+
+func TestWhichImportUnknown(t *testing.T) {
+	s := newTestServer(t)
+	results := whichImport(t, s, "Hash, NoSuchThing")
+	if got := results[0]; got.Found || !reflect.DeepEqual(got.Suggestions, []string{"HashMap", "HashSet"}) {
+		t.Errorf("Hash = %+v, want suggestions HashMap and HashSet", got)
+	}
+	if got := results[1]; got.Found || len(got.Suggestions) != 0 {
+		t.Errorf("NoSuchThing = %+v, want not found without suggestions", got)
+	}
+}
+
+func TestWhichImportAmbiguous(t *testing.T) {
+	dir := t.TempDir()
+	source := "package mylib.collection\n\n/** 自定义的哈希表 */\npublic class HashMap<K, V> {\n    public func put(key: K, value: V): Unit {}\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "map.cj"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, types.DocRoot{Name: "mylib", Path: dir, Category: "mylib", Format: types.RootFormatCangjie})
+
+	for _, identifier := range []string{"HashMap", "HashMap.put"} {
+		got := whichImport(t, s, identifier)[0]
+		if !got.Found || !got.Ambiguous || got.Package != "" {
+			t.Errorf("%s = %+v, want ambiguous without a single package", identifier, got)
+			continue
+		}
+		var imports []string
+		for _, definition := range got.Definitions {
+			imports = append(imports, definition.Import)
+		}
+		if len(imports) != 2 || !slices.Contains(imports, "import std.collection.*") || !slices.Contains(imports, "import mylib.collection.*") {
+			t.Errorf("%s definitions = %q", identifier, imports)
+		}
+	}
+}
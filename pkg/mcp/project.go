@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cangje-docs-mcp/pkg/cjlang"
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// projectContext 项目分析结果及其对应的文档范围
+type projectContext struct {
+	project   *cjlang.Project
+	packages  map[string][]*types.Document // 项目用到的包 -> 包文档
+	toolDocs  []*types.Document            // cjpm 等工具文档
+	missing   []string                     // 文档集合中找不到的 std/stdx 包
+	scopeDocs map[string]bool
+}
+
+// projectCacheEntry 缓存的项目分析结果及分析时 cjpm.toml 的修改时间
+type projectCacheEntry struct {
+	modTime time.Time
+	context *projectContext
+}
+
+// handleProjectContext 处理项目上下文请求
+func (s *CangJieDocServer) handleProjectContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projectPath, err := request.RequireString("project_path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	setDefault := false
+	if sd, ok := request.GetArguments()["set_default"].(bool); ok {
+		setDefault = sd
+	}
+
+	scopeMode := types.ScopeModeBoost
+	if sm, ok := request.GetArguments()["scope_mode"].(string); ok && sm != "" {
+		scopeMode = sm
+	}
+
+	maxDocs := 10
+	if md, ok := request.GetArguments()["max_docs"].(float64); ok && md > 0 {
+		maxDocs = int(md)
+	}
+
+	// 显式请求项目上下文时总是重新分析并刷新缓存（源文件的导入变化不会改变 cjpm.toml）
+	pc, err := s.cachedProjectContext(projectPath, true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	project := pc.project
+
+	projectInfo := map[string]interface{}{
+		"root":         project.Root,
+		"source_files": project.SourceFiles,
+		"test_files":   project.TestFiles,
+		"packages":     project.Packages,
+		"uses_stdx":    project.UsesStdx(),
+	}
+	if project.Config != nil {
+		projectInfo["name"] = project.Config.Name
+		projectInfo["version"] = project.Config.Version
+		projectInfo["cjc_version"] = project.Config.CjcVersion
+		projectInfo["output_type"] = project.Config.OutputType
+	} else {
+		projectInfo["warning"] = "未找到 cjpm.toml，仅根据源文件分析"
+	}
+	if deps := project.Dependencies(); len(deps) > 0 {
+		projectInfo["dependencies"] = deps
+	}
+	if external := project.ExternalImports(); len(external) > 0 {
+		projectInfo["external_imports"] = external
+	}
+
+	// 按导入次数列出包文档，包概述文档排在最前
+	var libraryPackages []map[string]interface{}
+	for _, pkg := range sortedContextPackages(pc) {
+		docs := pc.packages[pkg]
+		var docList []map[string]interface{}
+		for i, doc := range docs {
+			if i >= maxDocs {
+				break
+			}
+			docList = append(docList, map[string]interface{}{
+				"id":    doc.ID,
+				"title": doc.Title,
+			})
+		}
+		libraryPackages = append(libraryPackages, map[string]interface{}{
+			"package":    pkg,
+			"import":     fmt.Sprintf("import %s.*", pkg),
+			"imported":   project.Imports[pkg],
+			"total_docs": len(docs),
+			"docs":       docList,
+		})
+	}
+
+	var toolDocs []map[string]interface{}
+	for i, doc := range pc.toolDocs {
+		if i >= maxDocs {
+			break
+		}
+		toolDocs = append(toolDocs, map[string]interface{}{
+			"id":    doc.ID,
+			"title": doc.Title,
+		})
+	}
+
+	scopeInfo := map[string]interface{}{
+		"name": project.Root,
+		"docs": len(pc.scopeDocs),
+	}
+	if setDefault {
+		s.scopeMu.Lock()
+		s.defaultScope = &types.SearchScope{
+			Name:   project.Root,
+			Mode:   scopeMode,
+			DocIDs: pc.scopeDocs,
+		}
+		s.scopeMu.Unlock()
+		scopeInfo["default"] = true
+		scopeInfo["mode"] = scopeMode
+	}
+
+	response := map[string]interface{}{
+		"project":          projectInfo,
+		"library_packages": libraryPackages,
+		"tool_docs":        toolDocs,
+		"scope":            scopeInfo,
+		"hint":             "使用 cangjie_get_doc 获取包文档；cangjie_search 传入 project_path 或设置 set_default=true 可按项目范围搜索",
+	}
+	if len(pc.missing) > 0 {
+		response["unresolved_packages"] = pc.missing
+	}
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// analyzeProjectContext 分析项目并收集其用到的包文档和工具文档
+func (s *CangJieDocServer) analyzeProjectContext(projectPath string) (*projectContext, error) {
+	project, err := cjlang.AnalyzeProject(filepath.Clean(projectPath))
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &projectContext{
+		project:   project,
+		packages:  make(map[string][]*types.Document),
+		scopeDocs: make(map[string]bool),
+	}
+
+	// std/stdx 包文档，以及额外文档根中记录的第三方或项目自身的包
+	for pkg := range project.Imports {
+		docs := s.searchEngine.PackageDocuments(pkg)
+		if len(docs) == 0 {
+			if cjlang.IsStdPackage(pkg) || cjlang.IsStdxPackage(pkg) {
+				pc.missing = append(pc.missing, pkg)
+			}
+			continue
+		}
+		sortPackageDocs(pkg, docs)
+		pc.packages[pkg] = docs
+		for _, doc := range docs {
+			pc.scopeDocs[doc.ID] = true
+		}
+	}
+	sort.Strings(pc.missing)
+
+	// 项目使用 cjpm 构建时附带 cjpm 相关的工具文档
+	if project.Config != nil {
+		for _, doc := range s.documents {
+			if doc.Category != types.CategoryTools {
+				continue
+			}
+			if strings.Contains(strings.ToLower(doc.RelativePath), "cjpm") || strings.Contains(strings.ToLower(doc.Title), "cjpm") {
+				pc.toolDocs = append(pc.toolDocs, doc)
+				pc.scopeDocs[doc.ID] = true
+			}
+		}
+		sort.Slice(pc.toolDocs, func(i, j int) bool {
+			return pc.toolDocs[i].RelativePath < pc.toolDocs[j].RelativePath
+		})
+	}
+
+	return pc, nil
+}
+
+// cachedProjectContext 返回项目分析结果，按项目根目录和 cjpm.toml 的修改时间缓存，避免每次按项目范围搜索都重新扫描源码
+// refresh 为 true 时忽略缓存重新分析
+func (s *CangJieDocServer) cachedProjectContext(projectPath string, refresh bool) (*projectContext, error) {
+	root := filepath.Clean(projectPath)
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		// 与 cjlang.AnalyzeProject 一致，允许直接传入 cjpm.toml 路径
+		root = filepath.Dir(root)
+	}
+	modTime := cjpmModTime(root)
+
+	s.projectMu.Lock()
+	entry, ok := s.projectCache[root]
+	s.projectMu.Unlock()
+	if ok && !refresh && entry.modTime.Equal(modTime) {
+		return entry.context, nil
+	}
+
+	pc, err := s.analyzeProjectContext(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	if s.projectCache == nil {
+		s.projectCache = make(map[string]projectCacheEntry)
+	}
+	s.projectCache[root] = projectCacheEntry{modTime: modTime, context: pc}
+	return pc, nil
+}
+
+// cjpmModTime 返回项目根目录下 cjpm.toml 的修改时间，文件不存在时返回零值
+func cjpmModTime(root string) time.Time {
+	info, err := os.Stat(filepath.Join(root, "cjpm.toml"))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// searchScope 根据搜索参数确定搜索范围：显式的 project_path 优先，其次是默认范围
+func (s *CangJieDocServer) searchScope(request mcp.CallToolRequest) (*types.SearchScope, error) {
+	mode := ""
+	if sm, ok := request.GetArguments()["scope_mode"].(string); ok {
+		mode = sm
+	}
+	if mode == "none" {
+		return nil, nil
+	}
+
+	if projectPath, ok := request.GetArguments()["project_path"].(string); ok && projectPath != "" {
+		pc, err := s.cachedProjectContext(projectPath, false)
+		if err != nil {
+			return nil, err
+		}
+		if mode == "" {
+			mode = types.ScopeModeBoost
+		}
+		return &types.SearchScope{Name: pc.project.Root, Mode: mode, DocIDs: pc.scopeDocs}, nil
+	}
+
+	s.scopeMu.RLock()
+	defer s.scopeMu.RUnlock()
+	if s.defaultScope == nil {
+		return nil, nil
+	}
+	scope := *s.defaultScope
+	if mode != "" {
+		scope.Mode = mode
+	}
+	return &scope, nil
+}
+
+// sortedContextPackages 按导入次数降序返回有文档的包
+func sortedContextPackages(pc *projectContext) []string {
+	var packages []string
+	for pkg := range pc.packages {
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		ci, cj := pc.project.Imports[packages[i]], pc.project.Imports[packages[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return packages[i] < packages[j]
+	})
+	return packages
+}
+
+// sortPackageDocs 包概述文档（标题以包名开头）排在最前，其余按标题排序
+func sortPackageDocs(pkg string, docs []*types.Document) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		oi, oj := strings.HasPrefix(docs[i].Title, pkg), strings.HasPrefix(docs[j].Title, pkg)
+		if oi != oj {
+			return oi
+		}
+		return docs[i].Title < docs[j].Title
+	})
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestProject 创建一个带 cjpm.toml 和一个导入 std.collection 的源文件的项目
+func newTestProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"cjpm.toml":   "[package]\nname = \"demo\"\n",
+		"src/main.cj": "package demo\nimport std.collection.*\n\nmain() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestProjectContextCache(t *testing.T) {
+	s := newTestServer(t)
+	dir := newTestProject(t)
+
+	if text, isError := callTool(t, s, "cangjie_search", map[string]interface{}{"query": "ArrayList", "project_path": dir}); isError {
+		t.Fatalf("cangjie_search failed: %s", text)
+	}
+	first, err := s.cachedProjectContext(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.project.Config == nil || first.project.Config.Name != "demo" {
+		t.Fatalf("unexpected project config: %+v", first.project.Config)
+	}
+
+	// 同一项目再次搜索（包括传入 cjpm.toml 路径）使用缓存
+	for _, path := range []string{dir, dir + string(filepath.Separator), filepath.Join(dir, "cjpm.toml")} {
+		pc, err := s.cachedProjectContext(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pc != first {
+			t.Errorf("cachedProjectContext(%q) re-analyzed the project", path)
+		}
+	}
+
+	// cjpm.toml 修改后重新分析
+	config := filepath.Join(dir, "cjpm.toml")
+	if err := os.WriteFile(config, []byte("[package]\nname = \"renamed\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(config, later, later); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.cachedProjectContext(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || second.project.Config.Name != "renamed" {
+		t.Errorf("project was not re-analyzed after cjpm.toml changed: %+v", second.project.Config)
+	}
+
+	// cangjie_project_context 总是重新分析并刷新缓存
+	if text, isError := callTool(t, s, "cangjie_project_context", map[string]interface{}{"project_path": dir}); isError {
+		t.Fatalf("cangjie_project_context failed: %s", text)
+	}
+	third, err := s.cachedProjectContext(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == second {
+		t.Error("cangjie_project_context did not refresh the cached analysis")
+	}
+}
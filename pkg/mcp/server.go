@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"cangje-docs-mcp/pkg/scanner"
 	"cangje-docs-mcp/pkg/search"
@@ -18,10 +20,28 @@ type CangJieDocServer struct {
 	documents   map[string]*types.Document
 	searchEngine *search.SearchEngine
 	scanner     *scanner.Scanner
+
+	synonymsFile string // 同义词文件路径，为空表示只使用内置词表
+
+	configEntries []types.ConfigEntry // cjpm/cjc 配置项和命令行选项
+
+	scopeMu      sync.RWMutex
+	defaultScope *types.SearchScope // 由 cangjie_project_context 设置的默认搜索范围
+
+	projectMu    sync.Mutex
+	projectCache map[string]projectCacheEntry // 项目根目录 -> 项目分析结果，cjpm.toml 修改后失效
+}
+
+// ServerOptions 服务器可选配置
+type ServerOptions struct {
+	OverlayDir   string          // 本地补充文档目录，与官方文档合并索引
+	Roots        []types.DocRoot // 额外的文档根目录
+	SynonymsFile string          // 同义词文件，覆盖或补充内置的同义词和中英文对照表
+	IndexFile    string          // 持久化搜索索引文件，为空时语义索引只保存在内存中
 }
 
 // NewCangJieDocServer 创建新的仓颉文档服务器
-func NewCangJieDocServer(docRoot string) *CangJieDocServer {
+func NewCangJieDocServer(docRoot string, opts ServerOptions) *CangJieDocServer {
 	// 配置slog，不输出到stdio避免干扰MCP通信
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelError, // 只输出错误日志到stderr
@@ -32,6 +52,8 @@ func NewCangJieDocServer(docRoot string) *CangJieDocServer {
 		"仓颉语言文档检索系统",
 		"1.0.0",
 		server.WithToolCapabilities(true),
+		// 工具处理函数 panic 时返回错误而不是结束进程
+		server.WithRecovery(),
 	)
 
 	s := &CangJieDocServer{
@@ -41,6 +63,28 @@ func NewCangJieDocServer(docRoot string) *CangJieDocServer {
 		scanner:      scanner.NewScanner(docRoot),
 	}
 
+	if opts.OverlayDir != "" {
+		s.scanner.SetOverlayDir(opts.OverlayDir)
+	}
+
+	// 未指定同义词文件时使用补充目录中的 synonyms.txt（如果存在）
+	s.synonymsFile = opts.SynonymsFile
+	if s.synonymsFile == "" && opts.OverlayDir != "" {
+		path := filepath.Join(opts.OverlayDir, types.SynonymsFileName)
+		if _, err := os.Stat(path); err == nil {
+			s.synonymsFile = path
+		}
+	}
+
+	// 额外文档根需要在注册工具前添加，以便自定义分类出现在工具参数中
+	priorities := make(map[string]int)
+	for _, root := range opts.Roots {
+		s.scanner.AddRoot(root)
+		priorities[root.Name] = root.Priority
+	}
+	s.searchEngine.SetRootPriorities(priorities)
+	s.searchEngine.SetIndexFile(opts.IndexFile)
+
 	// 注册工具
 	s.registerTools()
 
@@ -49,6 +93,18 @@ func NewCangJieDocServer(docRoot string) *CangJieDocServer {
 
 // Serve 启动服务器
 func (s *CangJieDocServer) Serve(ctx context.Context) error {
+	if err := s.Load(); err != nil {
+		return err
+	}
+
+	slog.Info("服务器已启动", "文档数量", len(s.documents))
+
+	// 启动MCP服务器（stdio协议）
+	return server.ServeStdio(s.server)
+}
+
+// Load 扫描文档并构建搜索索引，Serve 会自动调用，命令行模式下单独使用
+func (s *CangJieDocServer) Load() error {
 	// 初始化文档
 	if err := s.initializeDocuments(); err != nil {
 		return fmt.Errorf("failed to initialize documents: %w", err)
@@ -57,16 +113,17 @@ func (s *CangJieDocServer) Serve(ctx context.Context) error {
 	// 构建搜索索引
 	s.searchEngine.BuildIndex(s.documents)
 
-	slog.Info("服务器已启动", "文档数量", len(s.documents))
-
-	// 启动MCP服务器（stdio协议）
-	return server.ServeStdio(s.server)
+	// 缓存的项目分析结果引用了旧的文档
+	s.projectMu.Lock()
+	s.projectCache = nil
+	s.projectMu.Unlock()
+	return nil
 }
 
 // initializeDocuments 初始化文档
 func (s *CangJieDocServer) initializeDocuments() error {
 	docRoot := s.scanner.GetDocRoot()
-	slog.Info("开始扫描文档目录", "路径", docRoot)
+	slog.Info("开始扫描文档目录", "路径", docRoot, "补充目录", s.scanner.GetOverlayDir())
 
 	// 检查文档目录是否存在
 	if _, err := os.Stat(docRoot); os.IsNotExist(err) {
@@ -80,17 +137,29 @@ func (s *CangJieDocServer) initializeDocuments() error {
 	}
 
 	s.documents = documents
+	s.configEntries = s.scanner.BuildConfigReference(documents)
 
-	slog.Info("文档扫描完成", "文档数量", len(documents))
+	if s.synonymsFile != "" {
+		if err := s.searchEngine.LoadSynonyms(s.synonymsFile); err != nil {
+			return fmt.Errorf("failed to load synonyms: %w", err)
+		}
+	}
+
+	slog.Info("文档扫描完成", "文档数量", len(documents), "配置项数量", len(s.configEntries))
 
 	// 打印分类统计
 	categoryStats := make(map[types.DocumentCategory]int)
+	sourceStats := make(map[string]int)
 	for _, doc := range documents {
 		categoryStats[doc.Category]++
+		sourceStats[doc.Source]++
 	}
 
 	for category, count := range categoryStats {
-		slog.Info("分类统计", "分类", types.CategoryNames[category], "数量", count)
+		slog.Info("分类统计", "分类", s.scanner.CategoryName(category), "数量", count)
+	}
+	for source, count := range sourceStats {
+		slog.Info("来源统计", "来源", source, "数量", count)
 	}
 
 	return nil
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testCorpus 搜索相关性评估使用的测试语料
+var testCorpus = filepath.Join("..", "search", "testdata", "corpus")
+
+// newTestServer 扫描测试语料（和额外文档根）并建立索引，语义索引只保存在内存中
+func newTestServer(t *testing.T, roots ...types.DocRoot) *CangJieDocServer {
+	t.Helper()
+	s := NewCangJieDocServer(testCorpus, ServerOptions{Roots: roots})
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// callTool 通过注册的处理函数调用工具，返回文本结果和是否为错误结果
+func callTool(t *testing.T, s *CangJieDocServer, name string, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	tool := s.server.GetTool(name)
+	if tool == nil {
+		t.Fatalf("tool %s not registered", name)
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := tool.Handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	text := ""
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			text += c.Text
+		}
+	}
+	return text, result.IsError
+}
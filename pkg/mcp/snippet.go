@@ -0,0 +1,273 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cangje-docs-mcp/pkg/cjlang"
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 片段检查结果状态
+const (
+	snippetStatusOK         = "ok"
+	snippetStatusUnknown    = "unknown"    // 文档中找不到，可能拼写错误或记错了API
+	snippetStatusUnverified = "unverified" // 成员不在该类型的文档中，但其他类型有同名成员（可能来自父类型、接口或扩展）
+	snippetStatusUnchecked  = "unchecked"  // 没有文档的第三方包，无法检查
+)
+
+// 未在标准库文档中单独声明的 Option 构造器
+var optionConstructors = map[string]bool{"Some": true, "None": true}
+
+// snippetIssue 片段检查发现的问题或解析结果
+type snippetIssue struct {
+	Kind         string                   `json:"kind"` // import、type、function、member
+	Name         string                   `json:"name"`
+	ReceiverType string                   `json:"receiver_type,omitempty"`
+	Line         int                      `json:"line"`
+	Status       string                   `json:"status"`
+	Message      string                   `json:"message,omitempty"`
+	Definition   map[string]interface{}   `json:"definition,omitempty"`
+	Suggestions  []map[string]interface{} `json:"suggestions,omitempty"`
+}
+
+// handleCheckSnippet 处理代码片段检查请求：对照标准库符号索引检查片段中用到的导入、类型、函数和成员
+func (s *CangJieDocServer) handleCheckSnippet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	code, err := request.RequireString("code")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if strings.TrimSpace(code) == "" {
+		return mcp.NewToolResultError("code is empty"), nil
+	}
+
+	snippet := cjlang.ParseSnippet(code)
+
+	var results []snippetIssue
+	var unchecked []string
+	imported := map[string]bool{"std.core": true}
+	for _, imp := range snippet.Imports {
+		imported[imp.Package()] = true
+		issue := s.checkSnippetImport(imp)
+		if issue.Status == snippetStatusUnchecked {
+			unchecked = append(unchecked, imp.Package())
+		}
+		results = append(results, issue)
+	}
+	// notFound 标记找不到的符号，片段导入了没有文档的包时符号可能来自这些包
+	notFound := func(issue *snippetIssue, message string) {
+		issue.Status = snippetStatusUnknown
+		issue.Message = message
+		if len(unchecked) > 0 {
+			issue.Status = snippetStatusUnchecked
+			issue.Message = fmt.Sprintf("可能来自没有文档的包 %s", strings.Join(unchecked, ", "))
+		}
+		issue.Suggestions = s.symbolSuggestions(issue.Name)
+	}
+
+	missingImports := make(map[string]map[string]interface{})
+	resolve := func(issue *snippetIssue, symbols []types.APISymbol) {
+		issue.Status = snippetStatusOK
+		issue.Definition = symbolDefinition(symbols[0])
+		for _, symbol := range symbols {
+			if imported[symbol.Package] {
+				issue.Definition = symbolDefinition(symbol)
+				return
+			}
+		}
+		// 符号所在的包没有导入
+		issue.Message = fmt.Sprintf("需要导入 %s", symbols[0].Package)
+		if _, ok := missingImports[issue.Name]; !ok {
+			missingImports[issue.Name] = symbolDefinition(symbols[0])
+		}
+	}
+
+	for _, ref := range snippet.TypeRefs {
+		issue := snippetIssue{Kind: "type", Name: ref.Name, Line: ref.Line}
+		switch symbols := s.searchEngine.LookupSymbol(ref.Name); {
+		case optionConstructors[ref.Name]:
+			issue.Status = snippetStatusOK
+		case len(symbols) > 0 && symbols[0].Name == ref.Name:
+			resolve(&issue, symbols)
+		case s.searchEngine.HasType(ref.Name):
+			// 只有扩展的类型，如内置类型
+			issue.Status = snippetStatusOK
+		default:
+			notFound(&issue, "标准库文档中没有该类型或符号")
+		}
+		results = append(results, issue)
+	}
+
+	for _, ref := range snippet.FuncCalls {
+		issue := snippetIssue{Kind: "function", Name: ref.Name, Line: ref.Line}
+		var funcs []types.APISymbol
+		for _, symbol := range s.searchEngine.LookupSymbol(ref.Name) {
+			if symbol.Name == ref.Name && (symbol.Kind == "func" || symbol.Kind == "macro") {
+				funcs = append(funcs, symbol)
+			}
+		}
+		if len(funcs) > 0 {
+			resolve(&issue, funcs)
+		} else {
+			notFound(&issue, "标准库文档中没有该函数，如果是片段外定义的函数可以忽略")
+		}
+		results = append(results, issue)
+	}
+
+	for _, ref := range snippet.MemberCalls {
+		if issue, ok := s.checkSnippetMember(ref); ok {
+			results = append(results, issue)
+		}
+	}
+
+	summary := make(map[string]int)
+	var problems, resolved []snippetIssue
+	for _, issue := range results {
+		summary[issue.Status]++
+		if issue.Status == snippetStatusOK && issue.Message == "" {
+			resolved = append(resolved, issue)
+		} else {
+			problems = append(problems, issue)
+		}
+	}
+
+	var missing []map[string]interface{}
+	for _, issue := range results {
+		if definition, ok := missingImports[issue.Name]; ok {
+			missing = append(missing, definition)
+			delete(missingImports, issue.Name)
+		}
+	}
+
+	response := map[string]interface{}{
+		"summary":  summary,
+		"problems": problems,
+		"resolved": resolved,
+		"locals":   snippet.Locals,
+	}
+	if len(missing) > 0 {
+		response["missing_imports"] = missing
+	}
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// checkSnippetImport 检查导入的包和符号是否存在
+func (s *CangJieDocServer) checkSnippetImport(imp cjlang.Import) snippetIssue {
+	pkg := imp.Package()
+	issue := snippetIssue{Kind: "import", Name: imp.Path, Line: imp.Line, Status: snippetStatusOK}
+
+	if len(s.searchEngine.PackageDocuments(pkg)) == 0 {
+		if !cjlang.IsStdPackage(pkg) && !cjlang.IsStdxPackage(pkg) {
+			issue.Status = snippetStatusUnchecked
+			issue.Message = "没有该包的文档"
+			return issue
+		}
+		issue.Status = snippetStatusUnknown
+		issue.Message = fmt.Sprintf("标准库中没有包 %s", pkg)
+		for _, name := range s.searchEngine.ClosestPackages(pkg, 3) {
+			issue.Suggestions = append(issue.Suggestions, map[string]interface{}{
+				"package": name,
+				"import":  fmt.Sprintf("import %s.*", name),
+			})
+		}
+		return issue
+	}
+
+	// 导入整个包（import mylib）或通配导入时没有要检查的符号
+	if imp.Path == pkg || !strings.HasPrefix(imp.Path, pkg+".") || strings.HasSuffix(imp.Path, ".*") {
+		return issue
+	}
+
+	name := imp.Path[len(pkg)+1:]
+	for _, symbol := range s.searchEngine.LookupSymbol(name) {
+		if symbol.Name == name && symbol.Package == pkg {
+			issue.Definition = symbolDefinition(symbol)
+			return issue
+		}
+	}
+	issue.Status = snippetStatusUnknown
+	issue.Message = fmt.Sprintf("包 %s 中没有 %s", pkg, name)
+	issue.Suggestions = s.symbolSuggestions(name)
+	return issue
+}
+
+// checkSnippetMember 检查成员访问，接收者类型未知且成员名在文档中存在时不报告
+func (s *CangJieDocServer) checkSnippetMember(ref cjlang.MemberRef) (snippetIssue, bool) {
+	issue := snippetIssue{Kind: "member", Name: ref.Member, ReceiverType: ref.ReceiverType, Line: ref.Line}
+
+	if ref.ReceiverType != "" {
+		if !s.searchEngine.HasType(ref.ReceiverType) {
+			// 未知类型已在类型检查中报告
+			return issue, false
+		}
+		if members := s.searchEngine.LookupMember(ref.ReceiverType, ref.Member); len(members) > 0 {
+			issue.Status = snippetStatusOK
+			issue.Definition = memberDefinition(members[0])
+			return issue, true
+		}
+		if s.searchEngine.HasMember(ref.Member) {
+			issue.Status = snippetStatusUnverified
+			issue.Message = fmt.Sprintf("%s 的文档中没有成员 %s，可能来自父类型、接口或扩展", ref.ReceiverType, ref.Member)
+		} else {
+			issue.Status = snippetStatusUnknown
+			issue.Message = fmt.Sprintf("%s 没有成员 %s", ref.ReceiverType, ref.Member)
+		}
+		issue.Suggestions = s.memberSuggestions(ref.ReceiverType, ref.Member)
+		return issue, true
+	}
+
+	if s.searchEngine.HasMember(ref.Member) {
+		return issue, false
+	}
+	issue.Status = snippetStatusUnknown
+	issue.Message = fmt.Sprintf("标准库文档中没有成员 %s（接收者 %s 的类型未知）", ref.Member, ref.Receiver)
+	issue.Suggestions = s.memberSuggestions("", ref.Member)
+	return issue, true
+}
+
+// symbolSuggestions 返回拼写最接近的顶层符号及其文档位置
+func (s *CangJieDocServer) symbolSuggestions(name string) []map[string]interface{} {
+	var suggestions []map[string]interface{}
+	for _, candidate := range s.searchEngine.ClosestSymbols(name, 3) {
+		if symbols := s.searchEngine.LookupSymbol(candidate); len(symbols) > 0 {
+			suggestions = append(suggestions, symbolDefinition(symbols[0]))
+		}
+	}
+	return suggestions
+}
+
+// memberSuggestions 返回拼写最接近的成员及其文档位置，typeName 为空时在所有类型中查找
+func (s *CangJieDocServer) memberSuggestions(typeName, member string) []map[string]interface{} {
+	var suggestions []map[string]interface{}
+	for _, candidate := range s.searchEngine.ClosestMembers(typeName, member, 3) {
+		if typeName != "" {
+			if members := s.searchEngine.LookupMember(typeName, candidate); len(members) > 0 {
+				suggestions = append(suggestions, memberDefinition(members[0]))
+			}
+			continue
+		}
+		suggestions = append(suggestions, map[string]interface{}{"name": candidate})
+	}
+	return suggestions
+}
+
+// memberDefinition 返回类型成员的定义位置
+func memberDefinition(symbol types.APISymbol) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    symbol.Name,
+		"kind":    symbol.Kind,
+		"type":    symbol.Parent,
+		"package": symbol.Package,
+		"doc_id":  symbol.DocID,
+		"section": symbol.Section,
+	}
+}
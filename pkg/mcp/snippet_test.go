@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cangje-docs-mcp/pkg/cjlang"
+	"cangje-docs-mcp/pkg/types"
+)
+
+// newSourceRootServer 加上一个从仓颉源码生成文档的额外文档根，包名为 mylib
+func newSourceRootServer(t *testing.T) *CangJieDocServer {
+	t.Helper()
+	dir := t.TempDir()
+	source := "package mylib\n\n/**\n * 打招呼\n */\npublic func hello(name: String): String {\n    return name\n}\n\n" +
+		"public enum Shape {\n    | Circle(Float64)\n    | Empty\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "lib.cj"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return newTestServer(t, types.DocRoot{Name: "mylib", Path: dir, Category: "mylib", Format: types.RootFormatCangjie})
+}
+
+func TestCheckSnippetImportSingleSegment(t *testing.T) {
+	s := newSourceRootServer(t)
+	if len(s.searchEngine.PackageDocuments("mylib")) == 0 {
+		t.Fatal("fixture package mylib has no documents")
+	}
+
+	tests := []struct {
+		path   string
+		status string
+	}{
+		{"mylib", snippetStatusOK},
+		{"mylib.*", snippetStatusOK},
+		{"mylib.hello", snippetStatusOK},
+		{"mylib.missing", snippetStatusUnknown},
+		{"otherlib", snippetStatusUnchecked},
+	}
+	for _, tt := range tests {
+		issue := s.checkSnippetImport(cjlang.Import{Path: tt.path, Line: 1})
+		if issue.Status != tt.status {
+			t.Errorf("checkSnippetImport(%q) status = %q, want %q (%s)", tt.path, issue.Status, tt.status, issue.Message)
+		}
+	}
+
+	if text, isError := callTool(t, s, "cangjie_check_snippet", map[string]interface{}{"code": "import mylib\n\nmain() {}\n"}); isError {
+		t.Fatalf("cangjie_check_snippet failed: %s", text)
+	}
+}
+
+func TestSourceRootEnumCases(t *testing.T) {
+	s := newSourceRootServer(t)
+	for _, member := range []string{"Circle", "Empty"} {
+		if len(s.searchEngine.LookupMember("Shape", member)) == 0 {
+			t.Errorf("enum constructor Shape.%s is not indexed", member)
+		}
+	}
+	if len(s.searchEngine.LookupMember("Shape", "Square")) != 0 {
+		t.Error("unexpected member Shape.Square")
+	}
+}
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleGetTable 处理表格获取请求
+func (s *CangJieDocServer) handleGetTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	docID, err := request.RequireString("doc_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	doc, exists := s.documents[docID]
+	if !exists {
+		return mcp.NewToolResultError(fmt.Sprintf("document not found: %s", docID)), nil
+	}
+
+	format := "compact"
+	if f, ok := request.GetArguments()["format"].(string); ok && f != "" {
+		format = f
+	}
+
+	filter := ""
+	if f, ok := request.GetArguments()["filter"].(string); ok {
+		filter = strings.ToLower(strings.TrimSpace(f))
+	}
+
+	index := 0
+	if t, ok := request.GetArguments()["table"].(float64); ok {
+		index = int(t)
+	}
+
+	if len(doc.Tables) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("document %s has no tables", docID)), nil
+	}
+
+	// 未指定表格时列出表格概要
+	if index == 0 {
+		var tables []map[string]interface{}
+		for _, table := range doc.Tables {
+			tables = append(tables, map[string]interface{}{
+				"index":   table.Index,
+				"section": table.Section,
+				"headers": table.Headers,
+				"rows":    len(table.Rows),
+			})
+		}
+		response := map[string]interface{}{
+			"doc_id": docID,
+			"title":  doc.Title,
+			"count":  len(tables),
+			"tables": tables,
+		}
+		data, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	if index < 1 || index > len(doc.Tables) {
+		return mcp.NewToolResultError(fmt.Sprintf("table %d not found, document has %d tables", index, len(doc.Tables))), nil
+	}
+	table := filterTableRows(doc.Tables[index-1], filter)
+
+	if format == "json" {
+		data, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	return mcp.NewToolResultText(compactTable(doc, table)), nil
+}
+
+// filterTableRows 返回只包含匹配行的表格副本
+func filterTableRows(table types.Table, filter string) types.Table {
+	if filter == "" {
+		return table
+	}
+
+	filtered := table
+	filtered.Rows = nil
+	for _, row := range table.Rows {
+		for _, cell := range row {
+			if strings.Contains(strings.ToLower(cell), filter) {
+				filtered.Rows = append(filtered.Rows, row)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// compactTable 以紧凑文本输出表格：表头一行，之后每行一条记录，单元格中的竖线会转义
+func compactTable(doc *types.Document, table types.Table) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# %s - 表格 %d", doc.Title, table.Index))
+	if table.Section != "" {
+		builder.WriteString(fmt.Sprintf("（%s）", table.Section))
+	}
+	builder.WriteString(fmt.Sprintf("\n%d 行\n\n", len(table.Rows)))
+
+	builder.WriteString(compactTableRow(table.Headers))
+	builder.WriteString("\n")
+	for _, row := range table.Rows {
+		builder.WriteString(compactTableRow(row))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// compactTableRow 拼接一行单元格
+func compactTableRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	return strings.Join(escaped, " | ")
+}
@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// tocSource 生成目录所用的文档内容
+type tocSource struct {
+	docID    string
+	title    string
+	content  string
+	parentID string // 分割后的章节文档所属的原文档
+	readable bool   // 能否直接用 cangjie_get_doc 读取（已分割的原文档不在索引中）
+}
+
+// tocEntry 目录中的一个章节，大小包含子章节
+type tocEntry struct {
+	ID           string `json:"id"`
+	Anchor       string `json:"anchor"`
+	Level        int    `json:"level"`
+	Title        string `json:"title"`
+	Line         int    `json:"line"`
+	CharCount    int    `json:"char_count"`
+	Tokens       int    `json:"tokens"`
+	ParentID     string `json:"parent_id,omitempty"`
+	SectionIndex int    `json:"section_index,omitempty"` // cangjie_get_doc 的 section_index
+	Split        bool   `json:"split"`
+	DocID        string `json:"doc_id,omitempty"` // 分割出的章节文档ID
+}
+
+// handleGetTOC 处理文档目录请求
+func (s *CangJieDocServer) handleGetTOC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	docID, err := request.RequireString("doc_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	format := "compact"
+	if f, ok := request.GetArguments()["format"].(string); ok && f != "" {
+		format = f
+	}
+
+	source, err := s.findTOCSource(docID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	toc := s.scanner.ParseDocumentTOC(source.content, source.docID)
+	entries := s.tocEntries(source, toc)
+
+	split := false
+	for _, entry := range entries {
+		split = split || entry.Split
+	}
+
+	if format == "json" {
+		response := map[string]interface{}{
+			"doc_id":        source.docID,
+			"title":         source.title,
+			"total_size":    len(source.content),
+			"tokens":        utils.EstimateTokens(source.content),
+			"is_split":      split,
+			"section_count": len(entries),
+			"sections":      entries,
+		}
+		if source.parentID != "" {
+			response["parent_id"] = source.parentID
+		}
+		data, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	return mcp.NewToolResultText(compactTOC(source, entries, split)), nil
+}
+
+// findTOCSource 按ID或完整路径ID查找文档；已分割的原文档不在索引中，通过章节文档找到原文件重新读取
+func (s *CangJieDocServer) findTOCSource(docID string) (*tocSource, error) {
+	doc, exists := s.documents[docID]
+	if !exists {
+		for _, d := range s.documents {
+			if d.FullPathID == docID {
+				doc, exists = d, true
+				break
+			}
+		}
+	}
+	if exists {
+		source := &tocSource{docID: doc.ID, title: doc.Title, content: doc.Content, readable: true}
+		if len(doc.Prerequisites) > 0 {
+			source.parentID = doc.Prerequisites[0]
+		}
+		return source, nil
+	}
+
+	for _, d := range s.documents {
+		if len(d.Prerequisites) > 0 && d.Prerequisites[0] == docID {
+			content, err := os.ReadFile(d.FilePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read document %s: %v", docID, err)
+			}
+			return &tocSource{docID: docID, title: d.ParentTitle, content: string(content)}, nil
+		}
+	}
+	return nil, fmt.Errorf("document not found: %s", docID)
+}
+
+// tocEntries 为每个章节生成目录项，标注按节阅读的序号和分割出的章节文档
+func (s *CangJieDocServer) tocEntries(source *tocSource, toc *types.DocumentTOC) []tocEntry {
+	readingIndex := make(map[string]int)
+	if source.readable {
+		for i, section := range readingSections(toc, source.content, source.title) {
+			readingIndex[section.id] = i + 1
+		}
+	}
+
+	anchors := sectionAnchors(toc)
+	entries := make([]tocEntry, 0, len(toc.Sections))
+	for i, section := range toc.Sections {
+		entry := tocEntry{
+			ID:           section.ID,
+			Anchor:       anchors[i],
+			Level:        section.Level,
+			Title:        section.Title,
+			Line:         section.LineNumber,
+			CharCount:    section.CharCount,
+			Tokens:       utils.EstimateTokens(section.Content),
+			ParentID:     section.ParentID,
+			SectionIndex: readingIndex[section.ID],
+		}
+		if id := s.scanner.SectionDocumentID(source.docID, section, i); s.documents[id] != nil {
+			entry.Split, entry.DocID = true, id
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// compactTOC 以缩进列表输出目录，每个章节一行
+func compactTOC(source *tocSource, entries []tocEntry, split bool) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n", source.title)
+	fmt.Fprintf(&builder, "doc_id: %s | %d 字符 | 约 %d tokens | %d 个章节", source.docID, len(source.content), utils.EstimateTokens(source.content), len(entries))
+	if source.parentID != "" {
+		fmt.Fprintf(&builder, " | 所属文档: %s", source.parentID)
+	}
+	builder.WriteString("\n")
+	if split {
+		builder.WriteString("文档已分割：已分割的章节用 → 后的文档ID读取，其他章节用所在章节文档的ID和 section=<锚点> 读取\n\n")
+	} else {
+		builder.WriteString("读取章节：cangjie_get_doc 传入 section=<锚点或章节ID>，或 section_index=<§后的序号> 按节顺序阅读\n\n")
+	}
+
+	minLevel := 0
+	for _, entry := range entries {
+		if minLevel == 0 || entry.Level < minLevel {
+			minLevel = entry.Level
+		}
+	}
+	for _, entry := range entries {
+		builder.WriteString(strings.Repeat("  ", entry.Level-minLevel))
+		fmt.Fprintf(&builder, "- %s `#%s` | 第%d行 | %d 字符 ~%d tokens", entry.Title, entry.Anchor, entry.Line, entry.CharCount, entry.Tokens)
+		if entry.SectionIndex > 0 {
+			fmt.Fprintf(&builder, " | §%d", entry.SectionIndex)
+		}
+		if entry.Split {
+			fmt.Fprintf(&builder, " | 已分割 → %s", entry.DocID)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// sectionAnchors 按 GitHub 的规则为每个章节生成锚点：小写，空格换成连字符，去掉标点，重复的锚点依次加上 -1、-2
+func sectionAnchors(toc *types.DocumentTOC) []string {
+	anchors := make([]string, len(toc.Sections))
+	seen := make(map[string]int)
+	for i, section := range toc.Sections {
+		anchor := headingAnchor(section.Title)
+		if n := seen[anchor]; n > 0 {
+			anchors[i] = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			anchors[i] = anchor
+		}
+		seen[anchor]++
+	}
+	return anchors
+}
+
+// headingAnchor 生成标题的锚点
+func headingAnchor(title string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+			builder.WriteRune(r)
+		case r == ' ':
+			builder.WriteRune('-')
+		}
+	}
+	return builder.String()
+}
+
+// findTOCSection 按章节ID或锚点（可带 #）查找章节
+func findTOCSection(toc *types.DocumentTOC, key string) (types.DocumentSection, bool) {
+	anchor := strings.TrimPrefix(key, "#")
+	for i, candidate := range sectionAnchors(toc) {
+		if toc.Sections[i].ID == key || candidate == anchor {
+			return toc.Sections[i], true
+		}
+	}
+	return types.DocumentSection{}, false
+}
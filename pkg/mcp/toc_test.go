@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cangje-docs-mcp/pkg/utils"
+)
+
+// tocFixture 目录测试使用的小文档，包含重复标题和带标点的标题
+const tocFixture = "# 字符串\n\n简介。\n\n## 创建 String\n\n用字面量创建。\n\n### 示例\n\n```cangjie\nlet s = \"abc\"\n```\n\n## 创建 String\n\n重复的标题。\n\n## FAQ: 常见问题?\n\n问答。"
+
+// newTOCServer 在临时目录中生成一篇小文档和一篇会被分割的大文档
+func newTOCServer(t *testing.T) *CangJieDocServer {
+	t.Helper()
+	var large strings.Builder
+	large.WriteString("# 集合类型\n\n")
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&large, "## class Type%d\n\n", i)
+		large.WriteString(strings.Repeat("集合类型的说明文字，用于测试文档分割。\n\n", 90))
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "manual", "source_zh_cn", "basic")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"toc_page.md": tocFixture, "large_page.md": large.String()}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewCangJieDocServer(root, ServerOptions{})
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// tocResponse cangjie_get_toc 的 json 格式响应
+type tocResponse struct {
+	DocID        string     `json:"doc_id"`
+	Title        string     `json:"title"`
+	TotalSize    int        `json:"total_size"`
+	IsSplit      bool       `json:"is_split"`
+	ParentID     string     `json:"parent_id"`
+	SectionCount int        `json:"section_count"`
+	Sections     []tocEntry `json:"sections"`
+}
+
+// getTOC 以 json 格式获取文档目录
+func getTOC(t *testing.T, s *CangJieDocServer, docID string) tocResponse {
+	t.Helper()
+	text, isError := callTool(t, s, "cangjie_get_toc", map[string]interface{}{"doc_id": docID, "format": "json"})
+	if isError {
+		t.Fatalf("cangjie_get_toc %s: %s", docID, text)
+	}
+	var response tocResponse
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestGetTOC(t *testing.T) {
+	s := newTOCServer(t)
+	docID := "manual_source_zh_cn_toc_page"
+	toc := getTOC(t, s, docID)
+	if toc.DocID != docID || toc.Title != "字符串" || toc.TotalSize != len(tocFixture) || toc.IsSplit || toc.ParentID != "" {
+		t.Errorf("toc header = %+v", toc)
+	}
+
+	var got []string
+	for _, entry := range toc.Sections {
+		got = append(got, fmt.Sprintf("%d %s #%s line %d §%d split %v", entry.Level, entry.Title, entry.Anchor, entry.Line, entry.SectionIndex, entry.Split))
+	}
+	want := []string{
+		"1 字符串 #字符串 line 1 §0 split false",
+		"2 创建 String #创建-string line 5 §1 split false",
+		"3 示例 #示例 line 9 §0 split false",
+		"2 创建 String #创建-string-1 line 15 §2 split false",
+		"2 FAQ: 常见问题? #faq-常见问题 line 19 §3 split false",
+	}
+	if !reflect.DeepEqual(got, want) || toc.SectionCount != len(want) {
+		t.Errorf("sections:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 章节大小包含子章节
+	lines := strings.Split(tocFixture, "\n")
+	sizes := map[string][2]int{"创建-string": {4, 14}, "示例": {8, 14}, "faq-常见问题": {18, len(lines)}}
+	for _, entry := range toc.Sections {
+		bounds, ok := sizes[entry.Anchor]
+		if !ok {
+			continue
+		}
+		content := strings.Join(lines[bounds[0]:bounds[1]], "\n")
+		if entry.CharCount != len(content) || entry.Tokens != utils.EstimateTokens(content) {
+			t.Errorf("#%s: %d chars ~%d tokens, want %d chars ~%d tokens", entry.Anchor, entry.CharCount, entry.Tokens, len(content), utils.EstimateTokens(content))
+		}
+	}
+
+	// compact 格式每个章节一行，按层级缩进
+	text, _ := callTool(t, s, "cangjie_get_toc", map[string]interface{}{"doc_id": docID})
+	for _, line := range []string{"- 字符串 `#字符串` | 第1行", "  - 创建 String `#创建-string-1` | 第15行", "    - 示例 `#示例` | 第9行"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("compact toc does not contain %q:\n%s", line, text)
+		}
+	}
+}
+
+func TestGetTOCSplitDocument(t *testing.T) {
+	s := newTOCServer(t)
+	parentID := "manual_source_zh_cn_large_page"
+	if s.documents[parentID] != nil {
+		t.Fatal("large document was not split, the test needs a larger fixture")
+	}
+
+	// 已分割的原文档不在索引中，目录标出分割出的章节文档
+	toc := getTOC(t, s, parentID)
+	if !toc.IsSplit || toc.Title != "集合类型" || len(toc.Sections) != 7 {
+		t.Fatalf("split toc = %+v", toc)
+	}
+	for i, entry := range toc.Sections {
+		if entry.SectionIndex != 0 {
+			t.Errorf("%s: section_index %d on a document that cannot be read directly", entry.Title, entry.SectionIndex)
+		}
+		// 一级和二级章节都分割为独立文档
+		if entry.Level <= 2 {
+			if !entry.Split || s.documents[entry.DocID] == nil || s.documents[entry.DocID].Title != entry.Title {
+				t.Errorf("section %d %s: split %v doc %q", i, entry.Title, entry.Split, entry.DocID)
+			}
+		} else if entry.Split {
+			t.Errorf("section %s is marked as split", entry.Title)
+		}
+	}
+
+	// 章节文档的目录指向所属的原文档
+	section := getTOC(t, s, toc.Sections[1].DocID)
+	if section.ParentID != parentID || section.Title != "class Type0" || section.IsSplit {
+		t.Errorf("section toc = %+v", section)
+	}
+}
+
+func TestGetTOCUnknown(t *testing.T) {
+	s := newTOCServer(t)
+	if text, isError := callTool(t, s, "cangjie_get_toc", map[string]interface{}{"doc_id": "no_such_doc"}); !isError || !strings.Contains(text, "document not found") {
+		t.Errorf("unknown document = %q (error %v)", text, isError)
+	}
+
+	toc := s.scanner.ParseDocumentTOC(tocFixture, "doc")
+	tests := []struct {
+		key   string
+		title string
+		line  int
+		ok    bool
+	}{
+		{"创建-string", "创建 String", 5, true},
+		{"#创建-string-1", "创建 String", 15, true},
+		{"#faq-常见问题", "FAQ: 常见问题?", 19, true},
+		{"doc_section_2", "示例", 9, true},
+		{"创建-string-2", "", 0, false},
+		{"#不存在", "", 0, false},
+		{"doc_section_9", "", 0, false},
+	}
+	for _, tt := range tests {
+		section, ok := findTOCSection(toc, tt.key)
+		if ok != tt.ok || section.Title != tt.title || section.LineNumber != tt.line {
+			t.Errorf("findTOCSection(%q) = %q line %d, %v; want %q line %d, %v", tt.key, section.Title, section.LineNumber, ok, tt.title, tt.line, tt.ok)
+		}
+	}
+
+	// cangjie_get_doc 按锚点读取重复标题的第二个章节
+	text, isError := callTool(t, s, "cangjie_get_doc", map[string]interface{}{"doc_id": "manual_source_zh_cn_toc_page", "section": "#创建-string-1", "format": "plain"})
+	if isError || !strings.Contains(text, "## 创建 String\n\n重复的标题。") || strings.Contains(text, "### 示例") {
+		t.Errorf("get_doc by anchor = %q (error %v)", text, isError)
+	}
+}
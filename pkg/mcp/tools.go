@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -25,25 +26,28 @@ func (s *CangJieDocServer) registerTools() {
 		),
 		mcp.WithString("category",
 			mcp.Required(),
-			mcp.Description("指定分类 (manual/libs/tools/extra/ohos)"),
-			mcp.Enum("manual", "libs", "tools", "extra", "ohos"),
+			mcp.Description("指定分类 ("+s.categoryList()+")"),
+			mcp.Enum(s.categoryEnum()...),
 		),
 		mcp.WithNumber("max_items",
-			mcp.Description("最大显示条目数 (默认50)"),
+			mcp.Description("每页最大条目数 (默认50；map视图按文档分页，overview视图按子分类分页)"),
 		),
 		mcp.WithNumber("level",
 			mcp.Description("树形显示深度 (仅navigation/tree视图，默认3，0表示全部)"),
 		),
+		mcp.WithString("cursor",
+			mcp.Description("分页游标，传入上次返回的 nextCursor 获取下一页（仅map/overview视图）或被 max_tokens 截断的剩余内容，其他参数需与上次相同"),
+		),
 	)
-	s.server.AddTool(overviewTool, s.handleGetDocumentOverview)
+	s.addTool(overviewTool, s.handleGetDocumentOverview)
 
 	// 文档列表工具
 	listTool := mcp.NewTool("cangjie_list_docs",
-		mcp.WithDescription("列出仓颉语言文档，支持路径导航（如 std/core）和分类筛选（manual/libs/tools/extra/ohos）"),
+		mcp.WithDescription("列出仓颉语言文档，支持路径导航（如 std/core）和分类筛选（"+s.categoryList()+"）"),
 		mcp.WithString("category",
 			mcp.Required(),
 			mcp.Description("主分类"),
-			mcp.Enum("manual", "libs", "tools", "extra", "ohos"),
+			mcp.Enum(s.categoryEnum()...),
 		),
 		mcp.WithString("subcategory",
 			mcp.Description("子分类路径（支持多级路径，如 'stdx' 或 'stdx/crypto'），留空显示子分类列表"),
@@ -56,10 +60,13 @@ func (s *CangJieDocServer) registerTools() {
 			mcp.Description("是否包含内容预览 (默认false)"),
 		),
 		mcp.WithNumber("max_items",
-			mcp.Description("最大返回数量 (默认100)"),
+			mcp.Description("每页最大返回数量 (默认100)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("分页游标，传入上次返回的 nextCursor 获取下一页（仅文档列表）或被 max_tokens 截断的剩余内容，其他参数需与上次相同"),
 		),
 	)
-	s.server.AddTool(listTool, s.handleListDocuments)
+	s.addTool(listTool, s.handleListDocuments)
 
 	// 搜索文档工具
 	searchTool := mcp.NewTool("cangjie_search",
@@ -69,21 +76,56 @@ func (s *CangJieDocServer) registerTools() {
 			mcp.Description("搜索查询词。单个关键词或多关键词（空格分隔，AND匹配）"),
 		),
 		mcp.WithString("category",
-			mcp.Description("可选的分类过滤 (manual/libs/tools/extra/ohos)"),
-			mcp.Enum("manual", "libs", "tools", "extra", "ohos"),
+			mcp.Description("可选的分类过滤 ("+s.categoryList()+")"),
+			mcp.Enum(s.categoryEnum()...),
+		),
+		mcp.WithString("source",
+			mcp.Description("可选的文档来源过滤 (official/overlay 或额外文档根名称)"),
+		),
+		mcp.WithString("project_path",
+			mcp.Description("可选的仓颉项目路径，按项目用到的包限定搜索范围"),
+		),
+		mcp.WithString("scope_mode",
+			mcp.Description("项目范围的使用方式 (默认boost；none表示忽略已设置的默认范围)"),
+			mcp.Enum(types.ScopeModeBoost, types.ScopeModeFilter, "none"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("搜索模式 (默认keyword)：keyword 关键词匹配；semantic 按语义相似度检索，适合“怎么截取字符串”这类描述性问题；hybrid 按排名融合两者"),
+			mcp.Enum(types.SearchModeKeyword, types.SearchModeSemantic, types.SearchModeHybrid),
 		),
 		mcp.WithNumber("max_results",
-			mcp.Description("最大结果数 (默认10)"),
+			mcp.Description("每页最大结果数 (默认10)"),
 		),
 		mcp.WithNumber("min_confidence",
-			mcp.Description("最小置信度 (默认0.3)"),
+			mcp.Description("最小置信度 (默认0.3，只用于keyword模式)"),
+		),
+		mcp.WithNumber("collapse",
+			mcp.Description("同一源文件最多保留的章节数，其余章节折叠计数 (默认2，0表示不折叠)"),
+		),
+		mcp.WithString("parent_id",
+			mcp.Description("只搜索该父文档的章节，用于展开折叠的分组（取结果中 group.expand 的参数）"),
+		),
+		mcp.WithBoolean("facets",
+			mcp.Description("是否返回全部命中文档按分类、子分类、包、难度、文档类型和语言的统计 (默认false)"),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("是否返回每个结果的分数组成：各词在各字段的命中、权重、加成和计算方式，用于排查排序问题 (默认false)"),
+		),
+		mcp.WithNumber("snippets",
+			mcp.Description(fmt.Sprintf("每个结果返回的最相关片段数，附带章节标题路径，命中词用 ** 标记 (默认0，最多%d)", types.MaxSnippets)),
+		),
+		mcp.WithNumber("snippet_length",
+			mcp.Description(fmt.Sprintf("每个片段的最大字符数 (默认%d)", types.DefaultSnippetLength)),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("分页游标，传入上次返回的 nextCursor 获取下一页（或被 max_tokens 截断的剩余内容），其他参数需与上次相同"),
 		),
 	)
-	s.server.AddTool(searchTool, s.handleSearchDocuments)
+	s.addTool(searchTool, s.handleSearchDocuments)
 
 	// 获取文档内容工具
 	contentTool := mcp.NewTool("cangjie_get_doc",
-		mcp.WithDescription("获取仓颉语言指定文档的完整内容，支持按章节获取和元数据过滤"),
+		mcp.WithDescription("获取仓颉语言指定文档的完整内容，支持按章节获取、按节顺序阅读（section_index）和按行或 token 分块读取（offset/limit）"),
 		mcp.WithString("doc_id",
 			mcp.Required(),
 			mcp.Description("文档ID"),
@@ -96,49 +138,165 @@ func (s *CangJieDocServer) registerTools() {
 			mcp.Enum("markdown", "json", "plain"),
 		),
 		mcp.WithString("section",
-			mcp.Description("获取特定章节 (如 '1.1', '2.3')"),
+			mcp.Description("获取特定章节 (如 '1.1', '2.3'，或 cangjie_get_toc 返回的章节ID和锚点)"),
+		),
+		mcp.WithNumber("section_index",
+			mcp.Description("按顺序读取第 N 节（从1开始），响应中返回总节数和下一节的 section_index"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("分块读取的起始位置（行号或 token 数，从0开始），传入上次返回的 next_offset 继续读取"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("分块读取的最大行数或 token 数 (默认%d行或%d tokens)", types.DefaultChunkLines, types.DefaultChunkTokens)),
+		),
+		mcp.WithString("unit",
+			mcp.Description("offset 和 limit 的单位 (默认line)"),
+			mcp.Enum(types.ChunkUnitLine, types.ChunkUnitToken),
+		),
+	)
+	s.addTool(contentTool, s.handleGetDocumentContent)
+
+	// 文档目录工具
+	tocTool := mcp.NewTool("cangjie_get_toc",
+		mcp.WithDescription("获取文档的目录：每个章节的ID、锚点、层级、行号、大小和估算 token 数，以及是否已分割为独立文档，便于只读取需要的章节"),
+		mcp.WithString("doc_id",
+			mcp.Required(),
+			mcp.Description("文档ID（也可以是已分割文档的原文档ID，即章节文档的 parent）"),
+		),
+		mcp.WithString("format",
+			mcp.Description("输出格式 (默认compact)：compact 为每个章节一行的缩进列表，json 为结构化目录"),
+			mcp.Enum("compact", "json"),
 		),
 	)
-	s.server.AddTool(contentTool, s.handleGetDocumentContent)
+	s.addTool(tocTool, s.handleGetTOC)
+
+	// 项目上下文工具
+	projectTool := mcp.NewTool("cangjie_project_context",
+		mcp.WithDescription("分析仓颉项目（cjpm.toml 和 .cj 源文件中的导入），返回项目用到的 std/stdx 包文档和相关工具文档，可设为 cangjie_search 的默认搜索范围"),
+		mcp.WithString("project_path",
+			mcp.Required(),
+			mcp.Description("项目根目录或 cjpm.toml 路径"),
+		),
+		mcp.WithBoolean("set_default",
+			mcp.Description("是否将项目范围设为后续搜索的默认范围 (默认false)"),
+		),
+		mcp.WithString("scope_mode",
+			mcp.Description("默认范围的使用方式 (默认boost)"),
+			mcp.Enum(types.ScopeModeBoost, types.ScopeModeFilter),
+		),
+		mcp.WithNumber("max_docs",
+			mcp.Description("每个包最多列出的文档数 (默认10)"),
+		),
+	)
+	s.addTool(projectTool, s.handleProjectContext)
+
+	// 导入路径查询工具
+	whichImportTool := mcp.NewTool("cangjie_which_import",
+		mcp.WithDescription("查询标识符（如 ArrayList、HashMap、Random）所在的包和导入语句，返回定义所在的文档章节，并提示多个包同名的歧义"),
+		mcp.WithString("identifiers",
+			mcp.Required(),
+			mcp.Description("一个或多个标识符，用逗号或空格分隔，如 'ArrayList, HashMap'"),
+		),
+	)
+	s.addTool(whichImportTool, s.handleWhichImport)
+
+	// 编译诊断解释工具
+	diagnosticTool := mcp.NewTool("cangjie_explain_diagnostic",
+		mcp.WithDescription("解析 cjc/cjpm 的原始编译输出，提取错误码、错误信息和涉及的关键词，返回按相关性排序的手册和工具文档章节"),
+		mcp.WithString("output",
+			mcp.Required(),
+			mcp.Description("cjc 或 cjpm build 的原始输出（可包含多条错误和警告）"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("每条诊断最多返回的文档数 (默认5)"),
+		),
+	)
+	s.addTool(diagnosticTool, s.handleExplainDiagnostic)
+
+	// 配置参考工具
+	configTool := mcp.NewTool("cangjie_config_reference",
+		mcp.WithDescription("查询 cjpm.toml 配置项、cjpm/cjc 命令行选项和子命令，返回类型、默认值、说明和来源章节"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("配置项、选项或关键词，如 'cross-compile-configuration'、'--output-type'、'增量编译'"),
+		),
+		mcp.WithString("tool",
+			mcp.Description("可选的工具过滤"),
+			mcp.Enum("cjpm", "cjc"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("最大结果数 (默认10)"),
+		),
+	)
+	s.addTool(configTool, s.handleConfigReference)
+
+	// 表格工具
+	tableTool := mcp.NewTool("cangjie_get_table",
+		mcp.WithDescription("获取文档中的 Markdown 表格（参数、异常、选项、操作符列表等），不指定表格时列出文档中的所有表格"),
+		mcp.WithString("doc_id",
+			mcp.Required(),
+			mcp.Description("文档ID"),
+		),
+		mcp.WithNumber("table",
+			mcp.Description("表格序号（从1开始，见搜索结果的 table.index），留空列出所有表格"),
+		),
+		mcp.WithString("format",
+			mcp.Description("输出格式 (默认compact)：json 为行列结构，compact 为每行一条的紧凑文本"),
+			mcp.Enum("compact", "json"),
+		),
+		mcp.WithString("filter",
+			mcp.Description("只返回包含该文本的行（不区分大小写）"),
+		),
+	)
+	s.addTool(tableTool, s.handleGetTable)
+
+	// 代码片段检查工具
+	snippetTool := mcp.NewTool("cangjie_check_snippet",
+		mcp.WithDescription("对照标准库API文档检查代码片段：导入的包和符号、用到的类型、函数和成员是否存在，给出拼写最接近的候选、缺少的导入和文档位置（不是编译器，只做轻量检查）"),
+		mcp.WithString("code",
+			mcp.Required(),
+			mcp.Description("仓颉代码片段，可以是完整文件或几行代码"),
+		),
+	)
+	s.addTool(snippetTool, s.handleCheckSnippet)
+}
+
+// categoryEnum 返回工具参数可选的分类列表（包含额外文档根注册的自定义分类）
+func (s *CangJieDocServer) categoryEnum() []string {
+	var categories []string
+	for _, category := range s.scanner.Categories() {
+		categories = append(categories, string(category))
+	}
+	return categories
+}
+
+// categoryList 返回用于工具描述的分类列表文本
+func (s *CangJieDocServer) categoryList() string {
+	return strings.Join(s.categoryEnum(), "/")
 }
 
 // handleSearchDocuments 处理文档搜索
 func (s *CangJieDocServer) handleSearchDocuments(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// 使用新的API获取参数
-	query, err := request.RequireString("query")
+	searchReq, err := s.searchRequest(request)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	query, maxResults, mode, scope := searchReq.Query, searchReq.MaxResults, searchReq.Mode, searchReq.Scope
 
-	// 可选参数
-	var category types.DocumentCategory
-	if cat, ok := request.GetArguments()["category"].(string); ok && cat != "" {
-		category = types.DocumentCategory(cat)
-	}
-
-	maxResults := types.DefaultMaxResults
-	if mr, ok := request.GetArguments()["max_results"].(float64); ok {
-		maxResults = int(mr)
-	}
-
-	minConfidence := types.DefaultMinConfidence
-	if mc, ok := request.GetArguments()["min_confidence"].(float64); ok {
-		minConfidence = mc
-	}
-
-	// 构建搜索请求
-	searchReq := types.SearchRequest{
-		Query:        query,
-		MaxResults:   maxResults,
-		MinConfidence: minConfidence,
-		Category:     category,
+	cursorKey := cursorQuery("cangjie_search", request)
+	offset, err := s.pageOffset(request, cursorKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	searchReq.Offset = offset
 
 	// 执行搜索
-	results := s.searchEngine.Search(searchReq)
+	page := s.searchEngine.SearchPage(searchReq)
+	results, total := page.Results, page.Total
 
 	// 格式化结果
 	var formattedResults []map[string]interface{}
+	shownGroups := make(map[string]bool)
 	for _, result := range results {
 		formattedResults = append(formattedResults, map[string]interface{}{
 			"document": map[string]interface{}{
@@ -150,18 +308,75 @@ func (s *CangJieDocServer) handleSearchDocuments(ctx context.Context, request mc
 				"difficulty":   result.Document.Difficulty,
 				"keywords":     result.Document.Keywords,
 				"relative_path": result.Document.RelativePath,
+				"source":       result.Document.Source,
 			},
 			"score":     result.Score,
 			"match_type": result.MatchType,
 			"match_text": result.MatchText,
 		})
+		formatted := formattedResults[len(formattedResults)-1]
+		if result.Table != nil {
+			formatted["table"] = result.Table
+		}
+		if len(result.Snippets) > 0 {
+			formatted["snippets"] = result.Snippets
+		}
+		if result.Explanation != nil {
+			formatted["explanation"] = result.Explanation
+		}
+		if result.Document.ParentTitle != "" {
+			formatted["document"].(map[string]interface{})["parent_title"] = result.Document.ParentTitle
+		}
+		// 分组信息只在组内第一个结果上给出
+		if group := result.Group; group != nil && !shownGroups[group.ParentID] {
+			shownGroups[group.ParentID] = true
+			groupInfo := map[string]interface{}{
+				"parent_id":    group.ParentID,
+				"parent_title": group.ParentTitle,
+				"matches":      group.Matches,
+				"shown":        group.Shown,
+			}
+			if group.Collapsed > 0 {
+				groupInfo["collapsed"] = group.Collapsed
+				groupInfo["expand"] = map[string]interface{}{
+					"tool":      "cangjie_search",
+					"query":     query,
+					"parent_id": group.ParentID,
+				}
+			}
+			formatted["group"] = groupInfo
+		}
 	}
 
 	response := map[string]interface{}{
 		"query":   query,
+		"mode":    mode,
 		"count":   len(results),
+		"total":   total,
 		"results": formattedResults,
 	}
+	if offset > 0 {
+		response["offset"] = offset
+	}
+	if page.Facets != nil {
+		response["facets"] = page.Facets
+	}
+	if next := s.nextCursor(cursorKey, offset, maxResults, total); next != "" {
+		response["nextCursor"] = next
+	}
+	if scope != nil {
+		response["scope"] = map[string]interface{}{
+			"name": scope.Name,
+			"mode": scope.Mode,
+			"docs": len(scope.DocIDs),
+		}
+	}
+	if expanded := s.searchEngine.ExpandQuery(query); len(expanded) > 0 {
+		response["expanded_terms"] = expanded
+	}
+	if page.DidYouMean != "" {
+		response["did_you_mean"] = page.DidYouMean
+	}
 
 	data, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
@@ -171,6 +386,92 @@ func (s *CangJieDocServer) handleSearchDocuments(ctx context.Context, request mc
 	return mcp.NewToolResultText(string(data)), nil
 }
 
+// searchRequest 按 cangjie_search 的参数构建搜索请求（不含分页位置），未给出的参数使用默认值
+// 命令行的 -explain 也通过它构建请求，保证与工具的搜索结果一致
+func (s *CangJieDocServer) searchRequest(request mcp.CallToolRequest) (types.SearchRequest, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return types.SearchRequest{}, err
+	}
+
+	// 可选参数
+	var category types.DocumentCategory
+	if cat, ok := request.GetArguments()["category"].(string); ok && cat != "" {
+		category = types.DocumentCategory(cat)
+	}
+
+	source := ""
+	if src, ok := request.GetArguments()["source"].(string); ok {
+		source = src
+	}
+
+	maxResults := types.DefaultMaxResults
+	if mr, ok := request.GetArguments()["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+	}
+
+	scope, err := s.searchScope(request)
+	if err != nil {
+		return types.SearchRequest{}, err
+	}
+
+	minConfidence := types.DefaultMinConfidence
+	if mc, ok := request.GetArguments()["min_confidence"].(float64); ok {
+		minConfidence = mc
+	}
+
+	mode := types.SearchModeKeyword
+	if m, ok := request.GetArguments()["mode"].(string); ok && m != "" {
+		mode = m
+	}
+
+	collapse := types.DefaultCollapseSections
+	if c, ok := request.GetArguments()["collapse"].(float64); ok {
+		collapse = int(c)
+	}
+
+	parentID := ""
+	if p, ok := request.GetArguments()["parent_id"].(string); ok {
+		parentID = p
+	}
+
+	facets := false
+	if f, ok := request.GetArguments()["facets"].(bool); ok {
+		facets = f
+	}
+
+	explain := false
+	if e, ok := request.GetArguments()["explain"].(bool); ok {
+		explain = e
+	}
+
+	snippets := 0
+	if n, ok := request.GetArguments()["snippets"].(float64); ok {
+		snippets = min(max(int(n), 0), types.MaxSnippets)
+	}
+
+	snippetLength := types.DefaultSnippetLength
+	if l, ok := request.GetArguments()["snippet_length"].(float64); ok && l > 0 {
+		snippetLength = int(l)
+	}
+
+	return types.SearchRequest{
+		Query:        query,
+		MaxResults:   maxResults,
+		MinConfidence: minConfidence,
+		Category:     category,
+		Source:       source,
+		Scope:        scope,
+		Mode:         mode,
+		Collapse:     collapse,
+		ParentID:     parentID,
+		Facets:       facets,
+		Explain:      explain,
+		Snippets:     snippets,
+		SnippetLength: snippetLength,
+	}, nil
+}
+
 // handleGetDocumentOverview 处理文档总览请求
 func (s *CangJieDocServer) handleGetDocumentOverview(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// 获取必填参数
@@ -196,11 +497,17 @@ func (s *CangJieDocServer) handleGetDocumentOverview(ctx context.Context, reques
 		level = int(l)
 	}
 
+	cursorKey := cursorQuery("cangjie_docs_overview", request)
+	offset, err := s.pageOffset(request, cursorKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// 根据视图类型生成不同的响应
 	switch viewType {
 	case "map":
 		// 生成文档地图
-		response := s.generateDocumentMap(category, maxItems)
+		response := s.generateDocumentMap(category, maxItems, offset, cursorKey)
 		data, err := json.MarshalIndent(response, "", "  ")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
@@ -212,7 +519,7 @@ func (s *CangJieDocServer) handleGetDocumentOverview(ctx context.Context, reques
 		return mcp.NewToolResultText(treeText), nil
 	default: // overview
 		// 生成总览
-		response := s.generateOverview(category, maxItems)
+		response := s.generateOverview(category, maxItems, offset, cursorKey)
 		data, err := json.MarshalIndent(response, "", "  ")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
@@ -250,6 +557,12 @@ func (s *CangJieDocServer) handleListDocuments(ctx context.Context, request mcp.
 		maxItems = int(mi)
 	}
 
+	cursorKey := cursorQuery("cangjie_list_docs", request)
+	offset, err := s.pageOffset(request, cursorKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// 解析路径
 	pathParts := []string{}
 	if subcategory != "" {
@@ -267,7 +580,7 @@ func (s *CangJieDocServer) handleListDocuments(ctx context.Context, request mcp.
 		return s.listDirectories(category, pathParts[0], builder)
 	} else {
 		// 深度2+：显示文档列表
-		return s.listDocumentsAtPath(category, subcategory, pathParts, sortBy, includePreview, maxItems, builder)
+		return s.listDocumentsAtPath(category, subcategory, pathParts, sortBy, includePreview, maxItems, offset, cursorKey, builder)
 	}
 }
 
@@ -289,7 +602,7 @@ func (s *CangJieDocServer) listSubcategories(category string, builder strings.Bu
 	sort.Strings(subcats)
 
 	// 标题
-	builder.WriteString(fmt.Sprintf("📋 %s\n\n", types.CategoryNames[types.DocumentCategory(category)]))
+	builder.WriteString(fmt.Sprintf("📋 %s\n\n", s.scanner.CategoryName(types.DocumentCategory(category))))
 	builder.WriteString("| 子分类 | 文档数 |\n")
 	builder.WriteString("|---|---|\n")
 
@@ -331,7 +644,7 @@ func (s *CangJieDocServer) listDirectories(category, subcategory string, builder
 	sort.Strings(dirs)
 
 	// 标题
-	builder.WriteString(fmt.Sprintf("📋 %s / %s\n\n", types.CategoryNames[types.DocumentCategory(category)], subcategory))
+	builder.WriteString(fmt.Sprintf("📋 %s / %s\n\n", s.scanner.CategoryName(types.DocumentCategory(category)), subcategory))
 	builder.WriteString("| 目录 | 文档数 |\n")
 	builder.WriteString("|---|---|\n")
 
@@ -348,7 +661,7 @@ func (s *CangJieDocServer) listDirectories(category, subcategory string, builder
 
 // listDocumentsAtPath 列出指定路径下的文档
 func (s *CangJieDocServer) listDocumentsAtPath(category, subcategory string, pathParts []string,
-	sortBy string, includePreview bool, maxItems int, builder strings.Builder) (*mcp.CallToolResult, error) {
+	sortBy string, includePreview bool, maxItems, offset int, cursorKey string, builder strings.Builder) (*mcp.CallToolResult, error) {
 
 	// 筛选文档
 	var documents []*types.Document
@@ -383,19 +696,24 @@ func (s *CangJieDocServer) listDocumentsAtPath(category, subcategory string, pat
 	// 排序
 	s.sortDocuments(documents, sortBy)
 
-	// 限制结果数量
+	// 取出当前页
 	maxDocs := 100
 	if maxItems > 0 {
 		maxDocs = maxItems
 	}
+	total := len(documents)
+	if offset > total {
+		offset = total
+	}
+	documents = documents[offset:]
 	if len(documents) > maxDocs {
 		documents = documents[:maxDocs]
 	}
 
 	// 标题
-	title := fmt.Sprintf("📋 %s", types.CategoryNames[types.DocumentCategory(category)])
+	title := fmt.Sprintf("📋 %s", s.scanner.CategoryName(types.DocumentCategory(category)))
 	title += fmt.Sprintf(" / %s", subcategory)
-	title += fmt.Sprintf(" (%d docs)", len(documents))
+	title += fmt.Sprintf(" (%d docs)", total)
 	builder.WriteString(title + "\n\n")
 
 	// 表头
@@ -410,21 +728,20 @@ func (s *CangJieDocServer) listDocumentsAtPath(category, subcategory string, pat
 	// 表格内容
 	for _, doc := range documents {
 		// 截断描述
-		description := doc.Description
-		if len(description) > 50 {
-			description = description[:47] + "..."
-		}
+		description := utils.Truncate(doc.Description, 50)
 
 		id := doc.ID
 		title := doc.Title
 		difficulty := doc.Difficulty
+		// 非官方文档标注来源
+		if doc.Source != "" && doc.Source != types.SourceOfficial {
+			title = fmt.Sprintf("%s [%s]", title, doc.Source)
+		}
 
 		if includePreview {
 			// 包含内容预览
-			preview := doc.Content
-			if len(preview) > 80 {
-				preview = preview[:77] + "..."
-			}
+			// 合并换行后截断，避免破坏表格
+			preview := utils.Truncate(strings.Join(strings.Fields(doc.Content), " "), 80)
 			// 转义管道符
 			preview = strings.ReplaceAll(preview, "|", "\\|")
 			builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
@@ -436,8 +753,11 @@ func (s *CangJieDocServer) listDocumentsAtPath(category, subcategory string, pat
 	}
 
 	// 添加统计信息
-	builder.WriteString(fmt.Sprintf("\n📊 排序方式: %s | 显示: %d/%d\n",
-		sortBy, len(documents), maxDocs))
+	builder.WriteString(fmt.Sprintf("\n📊 排序方式: %s | 显示: %d-%d/%d\n",
+		sortBy, min(offset+1, total), offset+len(documents), total))
+	if next := s.nextCursor(cursorKey, offset, maxDocs, total); next != "" {
+		builder.WriteString(fmt.Sprintf("➡️ nextCursor: %s （作为 cursor 参数传入获取下一页）\n", next))
+	}
 
 	return mcp.NewToolResultText(builder.String()), nil
 }
@@ -481,6 +801,24 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 		section = sec
 	}
 
+	sectionIndex := 0
+	if si, ok := request.GetArguments()["section_index"].(float64); ok {
+		sectionIndex = int(si)
+	}
+
+	unit := types.ChunkUnitLine
+	if u, ok := request.GetArguments()["unit"].(string); ok && u == types.ChunkUnitToken {
+		unit = u
+	}
+
+	offset, limit := -1, 0
+	if o, ok := request.GetArguments()["offset"].(float64); ok && o >= 0 {
+		offset = int(o)
+	}
+	if l, ok := request.GetArguments()["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
 	// 查找文档（支持通过 ID 或 FullPathID 查找）
 	doc, exists := s.documents[docID]
 	if !exists {
@@ -501,8 +839,62 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 	// 处理内容
 	content := doc.Content
 	if section != "" {
-		// 提取特定章节
-		content = s.extractSection(doc.Content, section)
+		// 提取特定章节：优先按目录中的章节ID或锚点查找
+		if tocSection, ok := findTOCSection(s.scanner.ParseDocumentTOC(doc.Content, doc.ID), section); ok {
+			content = tocSection.Content
+		} else {
+			content = s.extractSection(doc.Content, section)
+		}
+	}
+
+	// 按顺序读取第 N 节
+	var sectionInfo map[string]interface{}
+	if sectionIndex != 0 {
+		sections := readingSections(s.scanner.ParseDocumentTOC(content, doc.ID), content, doc.Title)
+		if sectionIndex < 1 || sectionIndex > len(sections) {
+			return mcp.NewToolResultError(fmt.Sprintf("section_index out of range: %d (document has %d sections)", sectionIndex, len(sections))), nil
+		}
+		current := sections[sectionIndex-1]
+		content = current.content
+		sectionInfo = map[string]interface{}{
+			"section_index": sectionIndex,
+			"section_count": len(sections),
+			"section_title": current.title,
+			"section_line":  current.line,
+		}
+		if sectionIndex < len(sections) {
+			sectionInfo["next_section_index"] = sectionIndex + 1
+		}
+	}
+
+	// 按行或 token 分块读取
+	var chunkInfo map[string]interface{}
+	if offset >= 0 || limit > 0 {
+		if limit == 0 {
+			limit = types.DefaultChunkLines
+			if unit == types.ChunkUnitToken {
+				limit = types.DefaultChunkTokens
+			}
+		}
+		chunk, err := chunkContent(content, max(offset, 0), limit, unit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		content = chunk.text
+		chunkInfo = map[string]interface{}{
+			"unit":   unit,
+			"offset": chunk.offset,
+			"limit":  limit,
+			"total":  chunk.total,
+			"lines":  fmt.Sprintf("%d-%d", chunk.firstLine, chunk.lastLine),
+		}
+		if chunk.more {
+			chunkInfo["next_offset"] = chunk.nextOffset
+		}
+		// 续读时不再重复元数据
+		if chunk.offset > 0 {
+			includeMetadata = false
+		}
 	}
 
 	// 根据格式返回结果
@@ -521,10 +913,18 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 				"difficulty":    doc.Difficulty,
 				"keywords":      doc.Keywords,
 				"relative_path": doc.RelativePath,
+				"source":        doc.Source,
 				"file_size":     doc.FileSize,
 				"last_modified": doc.LastModified.Format("2006-01-02 15:04:05"),
+				"tables":        len(doc.Tables),
 			}
 		}
+		for key, value := range sectionInfo {
+			response[key] = value
+		}
+		for key, value := range chunkInfo {
+			response[key] = value
+		}
 
 		data, err := json.MarshalIndent(response, "", "  ")
 		if err != nil {
@@ -536,10 +936,14 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 		if includeMetadata {
 			content = fmt.Sprintf(`标题: %s
 分类: %s/%s
+来源: %s
 难度: %s
 描述: %s
 
-%s`, doc.Title, string(doc.Category), doc.Subcategory, doc.Difficulty, doc.Description, content)
+%s`, doc.Title, string(doc.Category), doc.Subcategory, doc.Source, doc.Difficulty, doc.Description, content)
+		}
+		if sectionInfo != nil || chunkInfo != nil {
+			content += readingFooter(sectionInfo, chunkInfo)
 		}
 		return mcp.NewToolResultText(content), nil
 	} else { // markdown
@@ -552,6 +956,7 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 - **子分类**: %s
 - **难度**: %s
 - **文件路径**: %s
+- **来源**: %s
 - **最后修改**: %s
 - **关键词**: %s
 
@@ -565,19 +970,23 @@ func (s *CangJieDocServer) handleGetDocumentContent(ctx context.Context, request
 				doc.Subcategory,
 				doc.Difficulty,
 				doc.RelativePath,
+				doc.Source,
 				doc.LastModified.Format("2006-01-02 15:04:05"),
 				strings.Join(doc.Keywords, ", "),
 				doc.Description,
 				content)
 		}
+		if sectionInfo != nil || chunkInfo != nil {
+			content += readingFooter(sectionInfo, chunkInfo)
+		}
 		return mcp.NewToolResultText(content), nil
 	}
 }
 
 // 辅助函数
 
-// generateOverview 生成文档总览
-func (s *CangJieDocServer) generateOverview(category types.DocumentCategory, maxItems int) map[string]interface{} {
+// generateOverview 生成文档总览，子分类按名称排序，从 offset 开始最多列出 maxItems 个
+func (s *CangJieDocServer) generateOverview(category types.DocumentCategory, maxItems, offset int, cursorKey string) map[string]interface{} {
 	// 统计信息
 	totalDocs := len(s.documents)
 	categoryStats := make(map[types.DocumentCategory]int)
@@ -599,71 +1008,103 @@ func (s *CangJieDocServer) generateOverview(category types.DocumentCategory, max
 	}
 
 	// 添加分类信息
-	categories := []types.DocumentCategory{types.CategoryManual, types.CategoryLibs, types.CategoryTools, types.CategoryExtra, types.CategoryOhos}
-	for _, cat := range categories {
+	index, total := 0, 0
+	for _, cat := range s.scanner.Categories() {
 		if category != "" && cat != category {
 			continue
 		}
 
 		catInfo := map[string]interface{}{
 			"name":        cat,
-			"display_name": types.CategoryNames[cat],
+			"display_name": s.scanner.CategoryName(cat),
 			"count":       categoryStats[cat],
 			"subcategories": make([]map[string]interface{}, 0),
 		}
 
 		// 添加子分类信息
-		if subcats, ok := subcategoryStats[string(cat)]; ok {
-			for subcat, count := range subcats {
+		subcats := subcategoryStats[string(cat)]
+		names := make([]string, 0, len(subcats))
+		for subcat := range subcats {
+			names = append(names, subcat)
+		}
+		sort.Strings(names)
+		total += len(names)
+		for _, subcat := range names {
+			if index >= offset && (maxItems <= 0 || index < offset+maxItems) {
 				catInfo["subcategories"] = append(catInfo["subcategories"].([]map[string]interface{}), map[string]interface{}{
 					"name":  subcat,
-					"count": count,
+					"count": subcats[subcat],
 				})
 			}
+			index++
 		}
 
 		response["categories"] = append(response["categories"].([]map[string]interface{}), catInfo)
 	}
 
+	if next := s.nextCursor(cursorKey, offset, maxItems, total); next != "" {
+		response["nextCursor"] = next
+	}
+
 	return response
 }
 
-// generateDocumentMap 生成文档地图
-func (s *CangJieDocServer) generateDocumentMap(category types.DocumentCategory, maxItems int) map[string]interface{} {
-	// 构建分类->子分类->文档的层次结构
-	docMap := make(map[string]map[string][]map[string]interface{})
-
+// generateDocumentMap 生成文档地图，文档按子分类和ID排序，从 offset 开始最多列出 maxItems 篇
+func (s *CangJieDocServer) generateDocumentMap(category types.DocumentCategory, maxItems, offset int, cursorKey string) map[string]interface{} {
+	var documents []*types.Document
 	for _, doc := range s.documents {
 		if category != "" && doc.Category != category {
 			continue
 		}
+		documents = append(documents, doc)
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		if documents[i].Category != documents[j].Category {
+			return documents[i].Category < documents[j].Category
+		}
+		if documents[i].Subcategory != documents[j].Subcategory {
+			return documents[i].Subcategory < documents[j].Subcategory
+		}
+		return documents[i].ID < documents[j].ID
+	})
+
+	// 取出当前页
+	total := len(documents)
+	if offset > total {
+		offset = total
+	}
+	page := documents[offset:]
+	if maxItems > 0 && len(page) > maxItems {
+		page = page[:maxItems]
+	}
 
+	// 构建分类->子分类->文档的层次结构
+	docMap := make(map[string]map[string][]map[string]interface{})
+	for _, doc := range page {
 		catStr := string(doc.Category)
 		if docMap[catStr] == nil {
 			docMap[catStr] = make(map[string][]map[string]interface{})
 		}
-		if docMap[catStr][doc.Subcategory] == nil {
-			docMap[catStr][doc.Subcategory] = make([]map[string]interface{}, 0)
-		}
-
-		// 限制每个子分类的文档数量
-		if len(docMap[catStr][doc.Subcategory]) < maxItems/5 {
-			docMap[catStr][doc.Subcategory] = append(docMap[catStr][doc.Subcategory], map[string]interface{}{
-				"id":          doc.ID,
-				"title":       doc.Title,
-				"description": doc.Description,
-				"difficulty":  doc.Difficulty,
-				"keywords":    doc.Keywords,
-			})
-		}
+		docMap[catStr][doc.Subcategory] = append(docMap[catStr][doc.Subcategory], map[string]interface{}{
+			"id":          doc.ID,
+			"title":       doc.Title,
+			"description": doc.Description,
+			"difficulty":  doc.Difficulty,
+			"keywords":    doc.Keywords,
+		})
 	}
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"map_type":     "document_hierarchy",
 		"categories":   docMap,
-		"total_docs":   len(s.documents),
+		"count":        len(page),
+		"total_docs":   total,
 		"generated_at": time.Now().Format("2006-01-02 15:04:05"),
 	}
+	if next := s.nextCursor(cursorKey, offset, maxItems, total); next != "" {
+		response["nextCursor"] = next
+	}
+	return response
 }
 
 // generateNavigationTree 生成导航树
@@ -695,7 +1136,8 @@ func (s *CangJieDocServer) generateNavigationTree(category types.DocumentCategor
 	}
 
 	// 第二遍：构建树结构（只包含原始文档，不包含分割后的子文档）
-	for _, doc := range s.documents {
+	// 按文档ID顺序遍历，子节点顺序固定，截断续读时响应才能保持一致
+	for _, doc := range s.sortedDocuments() {
 		if category != "" && doc.Category != category {
 			continue
 		}
@@ -711,7 +1153,7 @@ func (s *CangJieDocServer) generateNavigationTree(category types.DocumentCategor
 		// 创建分类节点（如果不存在）
 		if _, exists := treeMap[catStr]; !exists {
 			catNode := &TreeNode{
-				Name:     types.CategoryNames[doc.Category],
+				Name:     s.scanner.CategoryName(doc.Category),
 				Type:     "category",
 				ID:       catStr,
 				Children: make([]TreeNode, 0),
@@ -821,11 +1263,27 @@ func (s *CangJieDocServer) generateNavigationTree(category types.DocumentCategor
 	}
 }
 
+// sortedDocuments 返回按ID排序的全部文档
+func (s *CangJieDocServer) sortedDocuments() []*types.Document {
+	documents := make([]*types.Document, 0, len(s.documents))
+	for _, doc := range s.documents {
+		documents = append(documents, doc)
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID < documents[j].ID
+	})
+	return documents
+}
+
 // sortDocuments 排序文档
 func (s *CangJieDocServer) sortDocuments(documents []*types.Document, sortBy string) {
+	// 先按ID排序，排序键相同的文档顺序固定，分页结果才能保持一致
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID < documents[j].ID
+	})
 	switch sortBy {
 	case "title":
-		sort.Slice(documents, func(i, j int) bool {
+		sort.SliceStable(documents, func(i, j int) bool {
 			return documents[i].Title < documents[j].Title
 		})
 	case "difficulty":
@@ -835,7 +1293,7 @@ func (s *CangJieDocServer) sortDocuments(documents []*types.Document, sortBy str
 			"intermediate": 2,
 			"advanced":     3,
 		}
-		sort.Slice(documents, func(i, j int) bool {
+		sort.SliceStable(documents, func(i, j int) bool {
 			orderI := difficultyOrder[documents[i].Difficulty]
 			orderJ := difficultyOrder[documents[j].Difficulty]
 			if orderI != orderJ {
@@ -844,7 +1302,7 @@ func (s *CangJieDocServer) sortDocuments(documents []*types.Document, sortBy str
 			return documents[i].Title < documents[j].Title
 		})
 	case "last_modified":
-		sort.Slice(documents, func(i, j int) bool {
+		sort.SliceStable(documents, func(i, j int) bool {
 			return documents[i].LastModified.After(documents[j].LastModified)
 		})
 	}
@@ -940,8 +1398,8 @@ func (s *CangJieDocServer) generateNavigationTreeText(category types.DocumentCat
 		subcatDocCounts[subcatKey]++
 	}
 
-	// 遍历文档构建树
-	for _, doc := range s.documents {
+	// 按文档ID顺序遍历文档构建树，子节点顺序固定，截断续读时响应才能保持一致
+	for _, doc := range s.sortedDocuments() {
 		if category != "" && doc.Category != category {
 			continue
 		}
@@ -955,7 +1413,7 @@ func (s *CangJieDocServer) generateNavigationTreeText(category types.DocumentCat
 
 		// 创建或获取分类节点
 		catKey := catStr
-		catNode := getOrCreateNode(catKey, types.CategoryNames[doc.Category], "category")
+		catNode := getOrCreateNode(catKey, s.scanner.CategoryName(doc.Category), "category")
 
 		// 创建或获取子分类节点
 		subcatKey := catStr + "/" + doc.Subcategory
@@ -982,6 +1440,10 @@ func (s *CangJieDocServer) generateNavigationTreeText(category types.DocumentCat
 			dirKey := strings.Join(pathParts[:i+1], "/")
 			dirName := pathParts[i]
 			dirNode := getOrCreateNode(dirKey, dirName, "directory")
+			// libs 的子分类包含第一级目录（如 std/core），其键与该目录相同，不能作为自己的子节点
+			if dirNode == currentNode {
+				continue
+			}
 
 			// 确保目录是当前节点的子节点
 			found = false
@@ -1018,7 +1480,7 @@ func (s *CangJieDocServer) generateNavigationTreeText(category types.DocumentCat
 		}
 	}
 
-	builder.WriteString(fmt.Sprintf("📚 %s (%d docs)\n\n", types.CategoryNames[category], totalDocs))
+	builder.WriteString(fmt.Sprintf("📚 %s (%d docs)\n\n", s.scanner.CategoryName(category), totalDocs))
 
 	// 递归生成树形文本
 	var printTree func([]*TreeNode, string, int)
@@ -1046,10 +1508,7 @@ func (s *CangJieDocServer) generateNavigationTreeText(category types.DocumentCat
 				}
 			case "document":
 				if node.Description != "" {
-					desc := node.Description
-					if len(desc) > 60 {
-						desc = desc[:57] + "..."
-					}
+					desc := utils.Truncate(node.Description, 60)
 					nodeStr = fmt.Sprintf("%s - %s", node.Name, desc)
 				} else {
 					nodeStr = node.Name
@@ -0,0 +1,321 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// 配置表格的列名，按小写匹配
+var (
+	configKeyColumns         = []string{"配置项", "字段", "键", "键名", "参数", "参数名", "选项", "名称", "key", "name", "option", "flag", "field"}
+	configTypeColumns        = []string{"类型", "值类型", "取值", "取值范围", "可选值", "type", "value"}
+	configDefaultColumns     = []string{"默认值", "缺省值", "默认", "default"}
+	configDescriptionColumns = []string{"说明", "描述", "含义", "作用", "功能", "备注", "description"}
+)
+
+var (
+	// 标题中的命令行选项，如 "### `--output-type=[exe|staticlib|dylib]` <sup>[frontend]</sup>"
+	flagHeadingPattern = regexp.MustCompile("^#{2,6}\\s+`(-[^`]+)`")
+	// 标题或列表中的每个选项代码，如 "`-o <value>`, `--output=<value>`"
+	flagCodePattern = regexp.MustCompile("`(-[^`]+)`")
+	// 列表中的命令行选项，如 "- `-i, --incremental`: 用于指定增量编译"
+	flagListPattern = regexp.MustCompile("^\\s*[-*]\\s+`(-[^`]+)`\\s*[:：]?\\s*(.*)$")
+	// 标题中的 cjpm.toml 配置项，如 "### `cross-compile-configuration`" 或 "### \"output-type\""
+	keyHeadingPattern = regexp.MustCompile("^#{2,6}\\s+[`\"]([a-z][a-z0-9_.-]*)[`\"]\\s*$")
+	// 标题中的 cjpm 子命令，如 "### `cjpm build`"
+	commandHeadingPattern = regexp.MustCompile("^#{2,6}\\s+`(cjpm\\s+[a-z-]+)`")
+	// 说明中的默认值，如 "默认值为 exe"、"默认为 `false`"
+	defaultValuePattern = regexp.MustCompile("默认(?:值)?(?:为|是)\\s*`?([^`，。,;；\\s]+)`?")
+	// 选项中的取值列表或参数，如 "=[exe|staticlib|dylib]"、" <value>"
+	flagValuePattern = regexp.MustCompile(`(?:=|\s+)[\[<]([^\]>]+)[\]>]`)
+)
+
+// BuildConfigReference 从 cjpm 和 cjc 的工具文档中提取配置项和命令行选项
+// 支持三种写法：配置表格、以选项为标题的章节、以选项开头的列表项
+func (s *Scanner) BuildConfigReference(documents map[string]*types.Document) []types.ConfigEntry {
+	docIDs := make([]string, 0, len(documents))
+	for id := range documents {
+		docIDs = append(docIDs, id)
+	}
+	sort.Strings(docIDs)
+
+	var entries []types.ConfigEntry
+	seen := make(map[string]bool)
+	for _, id := range docIDs {
+		doc := documents[id]
+		tool := configTool(doc)
+		if tool == "" {
+			continue
+		}
+
+		docEntries := extractTableEntries(doc, tool)
+		docEntries = append(docEntries, extractHeadingEntries(doc, tool)...)
+		docEntries = append(docEntries, extractListEntries(doc, tool)...)
+
+		for _, entry := range docEntries {
+			// 分割后的文档与原文档可能包含相同的章节
+			key := entry.Tool + "|" + entry.Key + "|" + entry.Section
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// configTool 判断文档属于哪个工具的说明，不是 cjpm/cjc 文档时返回空
+func configTool(doc *types.Document) string {
+	if doc.Category != types.CategoryTools {
+		return ""
+	}
+	path := strings.ToLower(doc.RelativePath + " " + doc.Title)
+	switch {
+	case strings.Contains(path, "cjpm"):
+		return "cjpm"
+	case strings.Contains(path, "cjc"), strings.Contains(path, "compile_option"), strings.Contains(path, "编译选项"):
+		return "cjc"
+	}
+	return ""
+}
+
+// extractTableEntries 从配置表格中提取配置项
+func extractTableEntries(doc *types.Document, tool string) []types.ConfigEntry {
+	var entries []types.ConfigEntry
+	for _, table := range parseMarkdownTables(doc.Content) {
+		keyCol := findColumn(table.Headers, configKeyColumns)
+		if keyCol < 0 {
+			continue
+		}
+		typeCol := findColumn(table.Headers, configTypeColumns)
+		defaultCol := findColumn(table.Headers, configDefaultColumns)
+		descCol := findColumn(table.Headers, configDescriptionColumns)
+
+		for _, row := range table.Rows {
+			names := splitOptionNames(row[keyCol])
+			if len(names) == 0 {
+				continue
+			}
+
+			entry := types.ConfigEntry{
+				Key:     names[0],
+				Aliases: names[1:],
+				Tool:    tool,
+				Kind:    configKind(names[0]),
+				DocID:   doc.ID,
+				Section: table.Section,
+			}
+			if typeCol >= 0 {
+				entry.Type = stripMarkdown(row[typeCol])
+			}
+			if defaultCol >= 0 {
+				entry.Default = stripMarkdown(row[defaultCol])
+			}
+			if descCol >= 0 {
+				entry.Description = stripMarkdown(row[descCol])
+			} else {
+				// 没有说明列时使用其余列
+				var rest []string
+				for i, cell := range row {
+					if i != keyCol && i != typeCol && i != defaultCol && cell != "" {
+						rest = append(rest, stripMarkdown(cell))
+					}
+				}
+				entry.Description = strings.Join(rest, "; ")
+			}
+			if entry.Default == "" {
+				entry.Default = defaultFromDescription(entry.Description)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// extractHeadingEntries 从以选项、配置项或子命令为标题的章节中提取，说明取标题后的第一段
+func extractHeadingEntries(doc *types.Document, tool string) []types.ConfigEntry {
+	var entries []types.ConfigEntry
+	lines := strings.Split(doc.Content, "\n")
+	inCodeBlock := false
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || !strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var entry types.ConfigEntry
+		if m := flagHeadingPattern.FindStringSubmatch(line); m != nil {
+			var flags []string
+			for _, code := range flagCodePattern.FindAllStringSubmatch(line, -1) {
+				flags = append(flags, code[1])
+			}
+			names := splitOptionNames(strings.Join(flags, ","))
+			if len(names) == 0 {
+				continue
+			}
+			entry = types.ConfigEntry{Key: names[0], Aliases: names[1:], Kind: types.ConfigKindOption}
+			if v := flagValuePattern.FindStringSubmatch(m[1]); v != nil {
+				entry.Type = v[1]
+			}
+		} else if m := commandHeadingPattern.FindStringSubmatch(line); m != nil {
+			entry = types.ConfigEntry{Key: strings.Join(strings.Fields(m[1]), " "), Kind: types.ConfigKindCommand}
+		} else if m := keyHeadingPattern.FindStringSubmatch(line); m != nil && tool == "cjpm" {
+			entry = types.ConfigEntry{Key: m[1], Kind: types.ConfigKindConfig}
+		} else {
+			continue
+		}
+
+		entry.Tool = tool
+		entry.DocID = doc.ID
+		entry.Section = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		entry.Description = firstParagraph(lines[i+1:])
+		entry.Default = defaultFromDescription(entry.Description)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// extractListEntries 从以选项开头的列表项中提取
+func extractListEntries(doc *types.Document, tool string) []types.ConfigEntry {
+	var entries []types.ConfigEntry
+	section := ""
+	inCodeBlock := false
+
+	for _, line := range strings.Split(doc.Content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+
+		m := flagListPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		names := splitOptionNames(m[1])
+		if len(names) == 0 {
+			continue
+		}
+
+		entry := types.ConfigEntry{
+			Key:         names[0],
+			Aliases:     names[1:],
+			Tool:        tool,
+			Kind:        types.ConfigKindOption,
+			Description: stripMarkdown(m[2]),
+			DocID:       doc.ID,
+			Section:     section,
+		}
+		if v := flagValuePattern.FindStringSubmatch(m[1]); v != nil {
+			entry.Type = v[1]
+		}
+		entry.Default = defaultFromDescription(entry.Description)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// findColumn 查找列名匹配的列，没有时返回-1
+func findColumn(headers []string, names []string) int {
+	for i, header := range headers {
+		header = strings.ToLower(stripMarkdown(header))
+		for _, name := range names {
+			if header == name {
+				return i
+			}
+		}
+	}
+	// 再尝试包含匹配，如 "配置项名称"
+	for i, header := range headers {
+		header = strings.ToLower(stripMarkdown(header))
+		for _, name := range names {
+			if len([]rune(name)) > 1 && strings.Contains(header, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitOptionNames 拆分选项名及其别名，如 "-o, --output <value>" -> [--output, -o]
+// 长选项排在最前作为主键
+func splitOptionNames(cell string) []string {
+	var names []string
+	for _, part := range strings.Split(stripMarkdown(cell), ",") {
+		name := strings.TrimSpace(part)
+		if idx := strings.IndexAny(name, " =[<"); idx > 0 {
+			name = name[:idx]
+		}
+		name = strings.Trim(name, `"'`)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.HasPrefix(names[i], "--") && !strings.HasPrefix(names[j], "--")
+	})
+	return names
+}
+
+// configKind 根据名称判断条目类型
+func configKind(key string) string {
+	switch {
+	case strings.HasPrefix(key, "-"):
+		return types.ConfigKindOption
+	case strings.HasPrefix(key, "cjpm "):
+		return types.ConfigKindCommand
+	default:
+		return types.ConfigKindConfig
+	}
+}
+
+// firstParagraph 返回第一段正文（跳过空行，遇到标题、代码块或空行结束）
+func firstParagraph(lines []string) string {
+	var paragraph []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "```") {
+			break
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+	return stripMarkdown(strings.Join(paragraph, " "))
+}
+
+// defaultFromDescription 从说明文字中提取默认值
+func defaultFromDescription(description string) string {
+	if m := defaultValuePattern.FindStringSubmatch(description); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// stripMarkdown 去掉单元格中的常见行内标记
+func stripMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "<br>", " ")
+	text = strings.ReplaceAll(text, "<br/>", " ")
+	text = strings.ReplaceAll(text, "**", "")
+	text = strings.ReplaceAll(text, "`", "")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestBuildConfigReference(t *testing.T) {
+	cjpmDoc := &types.Document{
+		ID:           "tools_cjpm_manual",
+		Category:     types.CategoryTools,
+		RelativePath: "tools/source_zh_cn/cjpm/cjpm_manual.md",
+		Title:        "cjpm 介绍",
+		Content: "# cjpm 介绍\n\n" +
+			"## 配置文件说明\n\n" +
+			"| 配置项 | 类型 | 说明 |\n" +
+			"|---|---|---|\n" +
+			"| `output-type` | `String` | 输出类型，默认值为 `executable` |\n" +
+			"| **name** | String | 包名 |\n" +
+			"|  | String | 没有名称的行 |\n\n" +
+			"### `cjpm build`\n\n" +
+			"编译当前项目。\n" +
+			"默认为 `release`。\n\n" +
+			"### `cross-compile-configuration`\n\n" +
+			"```toml\n" +
+			"- `--not-an-option`: 代码块中的内容\n" +
+			"```\n",
+	}
+	cjcDoc := &types.Document{
+		ID:           "tools_cjc_options",
+		Category:     types.CategoryTools,
+		RelativePath: "tools/source_zh_cn/compile_options.md",
+		Title:        "cjc 编译选项",
+		Content: "# cjc 编译选项\n\n" +
+			"### `--output-type=[exe|staticlib|dylib]` <sup>[frontend]</sup>\n\n" +
+			"指定输出文件的类型，默认是 exe。\n\n" +
+			"### `-o <value>`, `--output=<value>`\n\n" +
+			"指定输出文件的路径。\n\n" +
+			"### `\"not-a-cjc-key\"`\n\n" +
+			"## 其他选项\n\n" +
+			"- `-i, --incremental`: 用于指定增量编译\n" +
+			"* `--verbose`：输出详细信息\n",
+	}
+	manualDoc := &types.Document{
+		ID:           "manual_basic",
+		Category:     types.CategoryManual,
+		RelativePath: "manual/source_zh_cn/cjpm_basic.md",
+		Content:      "- `--ignored`: 不是工具文档\n",
+	}
+	documents := map[string]*types.Document{cjpmDoc.ID: cjpmDoc, cjcDoc.ID: cjcDoc, manualDoc.ID: manualDoc}
+
+	want := []types.ConfigEntry{
+		{Key: "--output-type", Aliases: []string{}, Tool: "cjc", Kind: types.ConfigKindOption, Type: "exe|staticlib|dylib", Default: "exe",
+			Description: "指定输出文件的类型，默认是 exe。", DocID: cjcDoc.ID, Section: "`--output-type=[exe|staticlib|dylib]` <sup>[frontend]</sup>"},
+		{Key: "--output", Aliases: []string{"-o"}, Tool: "cjc", Kind: types.ConfigKindOption, Type: "value",
+			Description: "指定输出文件的路径。", DocID: cjcDoc.ID, Section: "`-o <value>`, `--output=<value>`"},
+		{Key: "--incremental", Aliases: []string{"-i"}, Tool: "cjc", Kind: types.ConfigKindOption,
+			Description: "用于指定增量编译", DocID: cjcDoc.ID, Section: "其他选项"},
+		{Key: "--verbose", Aliases: []string{}, Tool: "cjc", Kind: types.ConfigKindOption,
+			Description: "输出详细信息", DocID: cjcDoc.ID, Section: "其他选项"},
+		{Key: "output-type", Aliases: []string{}, Tool: "cjpm", Kind: types.ConfigKindConfig, Type: "String", Default: "executable",
+			Description: "输出类型，默认值为 executable", DocID: cjpmDoc.ID, Section: "配置文件说明"},
+		{Key: "name", Aliases: []string{}, Tool: "cjpm", Kind: types.ConfigKindConfig, Type: "String",
+			Description: "包名", DocID: cjpmDoc.ID, Section: "配置文件说明"},
+		{Key: "cjpm build", Tool: "cjpm", Kind: types.ConfigKindCommand, Default: "release",
+			Description: "编译当前项目。 默认为 release。", DocID: cjpmDoc.ID, Section: "`cjpm build`"},
+		{Key: "cross-compile-configuration", Tool: "cjpm", Kind: types.ConfigKindConfig,
+			DocID: cjpmDoc.ID, Section: "`cross-compile-configuration`"},
+	}
+
+	got := NewScanner("").BuildConfigReference(documents)
+	if len(got) != len(want) {
+		t.Fatalf("BuildConfigReference returned %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("entry %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSplitOptionNames(t *testing.T) {
+	tests := []struct {
+		cell string
+		want []string
+	}{
+		{"`-o, --output <value>`", []string{"--output", "-o"}},
+		{"--jobs=<N>, -j", []string{"--jobs", "-j"}},
+		{`"output-type"`, []string{"output-type"}},
+		{"--target[=<triple>]", []string{"--target"}},
+		{" , ", nil},
+	}
+	for _, tt := range tests {
+		if got := splitOptionNames(tt.cell); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitOptionNames(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestFindColumn(t *testing.T) {
+	tests := []struct {
+		headers []string
+		want    int
+	}{
+		{[]string{"说明", "**配置项**"}, 1},
+		{[]string{"Key", "Description"}, 0},
+		// 精确匹配优先于包含匹配
+		{[]string{"配置项名称", "名称"}, 1},
+		{[]string{"配置项名称", "说明"}, 0},
+		{[]string{"示例", "备注"}, -1},
+	}
+	for _, tt := range tests {
+		if got := findColumn(tt.headers, configKeyColumns); got != tt.want {
+			t.Errorf("findColumn(%q) = %d, want %d", tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultFromDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"输出类型，默认值为 `executable`。", "executable"},
+		{"是否开启，默认是false，可选", "false"},
+		{"默认为 8; 最大 64", "8"},
+		{"没有默认值的说明", ""},
+	}
+	for _, tt := range tests {
+		if got := defaultFromDescription(tt.description); got != tt.want {
+			t.Errorf("defaultFromDescription(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// packageTitlePattern 包概述文档的标题，如 "std.collection" 或 "std.collection 包"
+var packageTitlePattern = regexp.MustCompile(`^(stdx?(?:\.[A-Za-z0-9_]+)+)`)
+
+// assignPackages 为标准库API文档标注所属包名
+// 优先使用目录中包概述文档的标题（如 "std.collection"），否则按目录结构推导
+func (s *Scanner) assignPackages(documents map[string]*types.Document) {
+	dirPackages := make(map[string]string)
+	for _, doc := range documents {
+		if doc.Category != types.CategoryLibs || doc.Package != "" {
+			continue
+		}
+		if m := packageTitlePattern.FindStringSubmatch(doc.Title); m != nil {
+			dirPackages[packageDir(doc.RelativePath)] = m[1]
+		}
+	}
+
+	for _, doc := range documents {
+		if doc.Category != types.CategoryLibs || doc.Package != "" {
+			continue
+		}
+
+		// 查找最近的已知包目录
+		dir := packageDir(doc.RelativePath)
+		for dir != "." && dir != "" {
+			if pkg, ok := dirPackages[dir]; ok {
+				doc.Package = pkg
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+
+		if doc.Package == "" {
+			doc.Package = packageFromLibsPath(doc.RelativePath)
+		}
+	}
+}
+
+// packageDir 返回文档所在的包目录，xxx_package_api 子目录归属于上一级
+func packageDir(relativePath string) string {
+	dir := filepath.Dir(relativePath)
+	if strings.HasSuffix(filepath.Base(dir), "_package_api") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// packageFromLibsPath 按目录结构推导包名
+// libs/std/collection/... -> std.collection，libs/std/collection_concurrent/... -> std.collection.concurrent
+func packageFromLibsPath(relativePath string) string {
+	parts := strings.Split(filepath.ToSlash(packageDir(relativePath)), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	dirs := parts[1:]
+	module := dirs[0]
+	if module != "std" && module != "stdx" {
+		return strings.Join(dirs, ".")
+	}
+	if len(dirs) == 1 {
+		return module
+	}
+
+	// 标准库包名不含下划线，目录名中的下划线对应子包的分隔符
+	return module + "." + strings.ReplaceAll(strings.Join(dirs[1:], "."), "_", ".")
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// ParseRootSpec 解析额外文档根配置
+// 格式为逗号分隔的 key=value，例如：
+//
+//	name=internal,path=/data/internal-docs,category=internal,title=内部库,priority=2
+//
+// path 必填；name 默认取目录名；category 默认取 name，可以是内置分类（如 libs）或自定义分类；
+// format 为 markdown（默认）或 cj，cj 表示从仓颉源码的文档注释生成API文档
+func ParseRootSpec(spec string) (types.DocRoot, error) {
+	var root types.DocRoot
+
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return root, fmt.Errorf("invalid root option %q, expected key=value", field)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "name":
+			root.Name = value
+		case "path":
+			root.Path = value
+		case "category":
+			root.Category = types.DocumentCategory(strings.ToLower(value))
+		case "title":
+			root.DisplayName = value
+		case "format":
+			root.Format = strings.ToLower(value)
+		case "priority":
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return root, fmt.Errorf("invalid root priority %q: %w", value, err)
+			}
+			root.Priority = priority
+		default:
+			return root, fmt.Errorf("unknown root option %q", key)
+		}
+	}
+
+	if root.Path == "" {
+		return root, fmt.Errorf("root path is required: %q", spec)
+	}
+	if root.Name == "" {
+		root.Name = filepath.Base(filepath.Clean(root.Path))
+	}
+	if root.Category == "" {
+		root.Category = types.DocumentCategory(strings.ToLower(root.Name))
+	}
+	if strings.ContainsAny(string(root.Category), `/\ `) {
+		return root, fmt.Errorf("invalid root category %q", root.Category)
+	}
+	switch root.Format {
+	case "":
+		root.Format = types.RootFormatMarkdown
+	case types.RootFormatMarkdown, types.RootFormatCangjie:
+	default:
+		return root, fmt.Errorf("unknown root format %q", root.Format)
+	}
+	if root.Name == types.SourceOfficial || root.Name == types.SourceOverlay {
+		return root, fmt.Errorf("root name %q is reserved", root.Name)
+	}
+
+	return root, nil
+}
+
+// AddRoot 添加额外的文档根目录
+// 不是内置分类的 category 会注册为该扫描器的自定义分类，以便通过相同的工具浏览
+func (s *Scanner) AddRoot(root types.DocRoot) {
+	if _, builtin := types.CategoryNames[root.Category]; !builtin && !s.isCustomCategory(root.Category) {
+		if s.categoryNames == nil {
+			s.categoryNames = make(map[types.DocumentCategory]string)
+		}
+		name := root.DisplayName
+		if name == "" {
+			name = string(root.Category)
+		}
+		s.customCategories = append(s.customCategories, root.Category)
+		s.categoryNames[root.Category] = name
+	}
+	s.roots = append(s.roots, root)
+}
+
+// Categories 返回所有分类：内置分类在前，自定义分类按注册顺序在后
+func (s *Scanner) Categories() []types.DocumentCategory {
+	categories := make([]types.DocumentCategory, 0, len(types.BuiltinCategories)+len(s.customCategories))
+	categories = append(categories, types.BuiltinCategories...)
+	return append(categories, s.customCategories...)
+}
+
+// CategoryName 返回分类的显示名称，内置分类的名称不会被额外文档根覆盖
+func (s *Scanner) CategoryName(category types.DocumentCategory) string {
+	if name, ok := types.CategoryNames[category]; ok {
+		return name
+	}
+	return s.categoryNames[category]
+}
+
+// isCustomCategory 判断是否为通过额外文档根注册的自定义分类
+func (s *Scanner) isCustomCategory(category types.DocumentCategory) bool {
+	for _, custom := range s.customCategories {
+		if custom == category {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoots 获取额外的文档根目录
+func (s *Scanner) GetRoots() []types.DocRoot {
+	return s.roots
+}
+
+// scanRoots 扫描额外文档根并按优先级合并到文档集合
+// 文档ID冲突时保留优先级高的文档，官方文档优先级为0
+func (s *Scanner) scanRoots(documents map[string]*types.Document) error {
+	roots := make([]types.DocRoot, len(s.roots))
+	copy(roots, s.roots)
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].Priority > roots[j].Priority
+	})
+
+	priorities := make(map[string]int, len(documents))
+	for id := range documents {
+		priorities[id] = 0
+	}
+
+	for _, root := range roots {
+		if _, err := os.Stat(root.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skip document root %s: %v\n", root.Name, err)
+			continue
+		}
+
+		rootDocs := make(map[string]*types.Document)
+		var err error
+		if root.Format == types.RootFormatCangjie {
+			err = s.scanSourceDirectory(root, rootDocs)
+		} else {
+			err = s.scanDirectory(root.Path, string(root.Category), rootDocs)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scan root %s: %w", root.Name, err)
+		}
+
+		for id, doc := range rootDocs {
+			if existing, exists := priorities[id]; exists && existing >= root.Priority {
+				continue
+			}
+			doc.Source = root.Name
+			documents[id] = doc
+			priorities[id] = root.Priority
+		}
+	}
+
+	return nil
+}
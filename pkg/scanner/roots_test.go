@@ -0,0 +1,218 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// writeFiles 在目录下按相对路径写入文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseRootSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want types.DocRoot
+	}{
+		{
+			spec: "name=internal,path=/data/internal-docs,category=Internal,title=内部库,priority=2",
+			want: types.DocRoot{Name: "internal", Path: "/data/internal-docs", Category: "internal", DisplayName: "内部库", Priority: 2, Format: types.RootFormatMarkdown},
+		},
+		{
+			spec: " path = /data/team-guide/ , ",
+			want: types.DocRoot{Name: "team-guide", Path: "/data/team-guide/", Category: "team-guide", Format: types.RootFormatMarkdown},
+		},
+		{
+			spec: "path=/src/mylib,category=libs,format=CJ,priority=-1",
+			want: types.DocRoot{Name: "mylib", Path: "/src/mylib", Category: types.CategoryLibs, Priority: -1, Format: types.RootFormatCangjie},
+		},
+		{
+			spec: "name=Design,path=/data/design,format=markdown",
+			want: types.DocRoot{Name: "Design", Path: "/data/design", Category: "design", Format: types.RootFormatMarkdown},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseRootSpec(tt.spec)
+		if err != nil {
+			t.Errorf("ParseRootSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRootSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRootSpecErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "root path is required"},
+		{"name=internal", "root path is required"},
+		{"path=/data/docs,internal", `invalid root option "internal"`},
+		{"path=/data/docs,owner=me", `unknown root option "owner"`},
+		{"path=/data/docs,priority=high", `invalid root priority "high"`},
+		{"path=/data/docs,category=team/docs", `invalid root category "team/docs"`},
+		{"path=/data/docs,category=team docs", `invalid root category "team docs"`},
+		{"path=/data/docs,format=html", `unknown root format "html"`},
+		{"name=official,path=/data/docs", `root name "official" is reserved`},
+		{"path=/data/overlay", `root name "overlay" is reserved`},
+	}
+	for _, tt := range tests {
+		_, err := ParseRootSpec(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseRootSpec(%q) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestAddRootCategories(t *testing.T) {
+	s := NewScanner(t.TempDir())
+	s.AddRoot(types.DocRoot{Name: "team", Path: "/data/team", Category: "internal", DisplayName: "内部库"})
+	s.AddRoot(types.DocRoot{Name: "guide", Path: "/data/guide", Category: "guide"})
+	s.AddRoot(types.DocRoot{Name: "team2", Path: "/data/team2", Category: "internal", DisplayName: "另一个名称"})
+	s.AddRoot(types.DocRoot{Name: "stdx", Path: "/data/stdx", Category: types.CategoryLibs, DisplayName: "扩展库"})
+
+	want := append(append([]types.DocumentCategory{}, types.BuiltinCategories...), "internal", "guide")
+	if got := s.Categories(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Categories() = %v, want %v", got, want)
+	}
+
+	// 自定义分类使用首次注册时的显示名称，内置分类的名称不被覆盖
+	names := map[types.DocumentCategory]string{
+		"internal":         "内部库",
+		"guide":            "guide",
+		types.CategoryLibs: types.CategoryNames[types.CategoryLibs],
+		"not_registered":   "",
+	}
+	for category, name := range names {
+		if got := s.CategoryName(category); got != name {
+			t.Errorf("CategoryName(%q) = %q, want %q", category, got, name)
+		}
+	}
+
+	if roots := s.GetRoots(); len(roots) != 4 {
+		t.Errorf("GetRoots() = %d roots, want 4", len(roots))
+	}
+
+	// 自定义分类的第一级目录作为子分类
+	category, subcategory := s.determineCategory(filepath.Join("internal", "net", "http.md"))
+	if category != "internal" || subcategory != "net" {
+		t.Errorf("determineCategory(internal/net/http.md) = %s, %q", category, subcategory)
+	}
+}
+
+func TestScanRootsPriority(t *testing.T) {
+	official := t.TempDir()
+	writeFiles(t, official, map[string]string{
+		"libs/std/core/string.md": "# String\n\n官方文档。",
+		"libs/std/core/option.md": "# Option\n\n官方文档。",
+	})
+
+	low, same, high, higher := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	writeFiles(t, low, map[string]string{
+		"std/core/string.md": "# String\n\n低优先级。",
+		"std/core/extra.md":  "# Extra\n\n只在低优先级文档根中。",
+	})
+	writeFiles(t, same, map[string]string{"std/core/option.md": "# Option\n\n同优先级。"})
+	writeFiles(t, high, map[string]string{"std/core/string.md": "# String\n\n优先级 1。"})
+	writeFiles(t, higher, map[string]string{"std/core/string.md": "# String\n\n优先级 2。"})
+
+	s := NewScanner(official)
+	// 添加顺序与优先级相反，结果只由优先级决定
+	s.AddRoot(types.DocRoot{Name: "low", Path: low, Category: types.CategoryLibs, Priority: -1})
+	s.AddRoot(types.DocRoot{Name: "same", Path: same, Category: types.CategoryLibs})
+	s.AddRoot(types.DocRoot{Name: "high", Path: high, Category: types.CategoryLibs, Priority: 1})
+	s.AddRoot(types.DocRoot{Name: "higher", Path: higher, Category: types.CategoryLibs, Priority: 2})
+	s.AddRoot(types.DocRoot{Name: "missing", Path: filepath.Join(official, "missing"), Category: types.CategoryLibs, Priority: 3})
+
+	documents, err := s.ScanAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id      string
+		source  string
+		content string
+	}{
+		{"libs_std/core_string", "higher", "优先级 2"},
+		{"libs_std/core_option", types.SourceOfficial, "官方文档"},
+		{"libs_std/core_extra", "low", "只在低优先级文档根中"},
+	}
+	for _, tt := range tests {
+		doc := documents[tt.id]
+		if doc == nil {
+			t.Errorf("%s: missing", tt.id)
+			continue
+		}
+		if doc.Source != tt.source || !strings.Contains(doc.Content, tt.content) {
+			t.Errorf("%s: source %q content %q, want source %q", tt.id, doc.Source, doc.Content, tt.source)
+		}
+	}
+	if len(documents) != len(tests) {
+		t.Errorf("scanned %d documents, want %d", len(documents), len(tests))
+	}
+}
+
+func TestScanRootsFormat(t *testing.T) {
+	source, docs := t.TempDir(), t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"src/util/strings.cj": "package mylib.util\n\n/** 拼接字符串 */\npublic func join(parts: Array<String>): String {\n    return \"\"\n}\n",
+		"README.md":           "# mylib\n\n源码目录中的 markdown 文件不会被扫描。",
+	})
+	writeFiles(t, docs, map[string]string{
+		"net/http.md":   "# HTTP\n\n内部网络库。",
+		"net/client.cj": "package internal.net\n\npublic func get(): Unit {}\n",
+	})
+
+	s := NewScanner(t.TempDir())
+	s.AddRoot(types.DocRoot{Name: "mylib", Path: source, Category: "mylib", Format: types.RootFormatCangjie})
+	s.AddRoot(types.DocRoot{Name: "internal", Path: docs, Category: "internal", Format: types.RootFormatMarkdown})
+
+	documents, err := s.ScanAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("scanned %v, want one document from each root", ids)
+	}
+
+	// cj 格式从源码声明生成API文档
+	var api *types.Document
+	for _, doc := range documents {
+		if doc.Source == "mylib" {
+			api = doc
+		}
+	}
+	if api == nil || api.Package != "mylib.util" || api.Category != "mylib" || !strings.HasSuffix(api.RelativePath, ".cj") || !strings.Contains(api.Content, "拼接字符串") {
+		t.Errorf("cj root documents = %v, want the join function of mylib.util", ids)
+	}
+
+	// markdown 格式只扫描 .md 文件
+	doc := documents["internal_net_http"]
+	if doc == nil || doc.Source != "internal" || doc.Category != "internal" || doc.Subcategory != "net" {
+		t.Errorf("markdown root documents = %v, want internal_net_http", ids)
+	}
+}
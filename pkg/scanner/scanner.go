@@ -9,11 +9,17 @@ import (
 	"strings"
 
 	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
 )
 
 // Scanner 文档扫描器
 type Scanner struct {
-	docRoot string
+	docRoot    string
+	overlayDir string          // 本地补充文档目录，与官方文档合并索引
+	roots      []types.DocRoot // 额外的文档根目录
+
+	customCategories []types.DocumentCategory          // 额外文档根注册的自定义分类（按注册顺序）
+	categoryNames    map[types.DocumentCategory]string // 自定义分类的显示名称
 }
 
 // NewScanner 创建新的文档扫描器
@@ -26,23 +32,75 @@ func NewScanner(docRoot string) *Scanner {
 	}
 }
 
+// SetOverlayDir 设置本地补充文档目录
+// 补充目录按与官方文档相同的目录结构组织，相对路径相同的文件会覆盖官方文档
+func (s *Scanner) SetOverlayDir(overlayDir string) {
+	s.overlayDir = overlayDir
+}
+
 // ScanAll 扫描所有文档
 func (s *Scanner) ScanAll() (map[string]*types.Document, error) {
 	documents := make(map[string]*types.Document)
 
-	err := s.scanDirectory("", documents)
+	err := s.scanDirectory(s.docRoot, "", documents)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan documents: %w", err)
 	}
+	for _, doc := range documents {
+		doc.Source = types.SourceOfficial
+	}
+
+	if err := s.mergeOverlay(documents); err != nil {
+		return nil, fmt.Errorf("failed to scan overlay documents: %w", err)
+	}
+
+	if err := s.scanRoots(documents); err != nil {
+		return nil, err
+	}
+
+	s.assignPackages(documents)
+	s.assignTables(documents)
 
 	return documents, nil
 }
 
-// scanDirectory 扫描目录
-func (s *Scanner) scanDirectory(relativePath string, documents map[string]*types.Document) error {
-	fullPath := filepath.Join(s.docRoot, relativePath)
+// mergeOverlay 扫描补充目录并合并到文档集合
+func (s *Scanner) mergeOverlay(documents map[string]*types.Document) error {
+	if s.overlayDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(s.overlayDir); os.IsNotExist(err) {
+		return nil
+	}
 
-	return filepath.WalkDir(fullPath, func(path string, d fs.DirEntry, err error) error {
+	overlayDocs := make(map[string]*types.Document)
+	if err := s.scanDirectory(s.overlayDir, "", overlayDocs); err != nil {
+		return err
+	}
+
+	// 被补充文档覆盖的官方文件（包括其分割出的章节）整体移除
+	overridden := make(map[string]bool)
+	for _, doc := range overlayDocs {
+		overridden[doc.RelativePath] = true
+	}
+	for id, doc := range documents {
+		if overridden[doc.RelativePath] {
+			delete(documents, id)
+		}
+	}
+
+	for id, doc := range overlayDocs {
+		doc.Source = types.SourceOverlay
+		documents[id] = doc
+	}
+
+	return nil
+}
+
+// scanDirectory 扫描根目录，文档相对路径会加上 pathPrefix 前缀
+// 额外文档根使用分类名作为前缀，使其路径结构与官方文档一致（分类/子分类/...）
+func (s *Scanner) scanDirectory(root, pathPrefix string, documents map[string]*types.Document) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -53,10 +111,11 @@ func (s *Scanner) scanDirectory(relativePath string, documents map[string]*types
 		}
 
 		// 获取相对路径
-		relPath, err := filepath.Rel(s.docRoot, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
 		}
+		relPath = filepath.Join(pathPrefix, relPath)
 
 		// 解析文档
 		doc, err := s.parseDocument(path, relPath)
@@ -245,6 +304,13 @@ func (s *Scanner) determineCategory(relativePath string) (types.DocumentCategory
 		}
 		return types.CategoryOhos, ""
 	default:
+		// 额外文档根注册的自定义分类：第一级目录作为子分类
+		if category := types.DocumentCategory(parts[0]); s.isCustomCategory(category) {
+			if len(parts) > 2 {
+				return category, parts[1]
+			}
+			return category, ""
+		}
 		return types.CategoryManual, ""
 	}
 }
@@ -321,12 +387,7 @@ func (s *Scanner) generateContentPreview(content string) string {
 		}
 	}
 
-	preview := strings.Join(previewLines, " ")
-	if len(preview) > 200 {
-		preview = preview[:200] + "..."
-	}
-
-	return preview
+	return utils.TruncateRunes(strings.Join(previewLines, " "), 200)
 }
 
 // GetDocRoot 获取文档根目录
@@ -334,6 +395,11 @@ func (s *Scanner) GetDocRoot() string {
 	return s.docRoot
 }
 
+// GetOverlayDir 获取本地补充文档目录
+func (s *Scanner) GetOverlayDir() string {
+	return s.overlayDir
+}
+
 // contains 检查字符串是否在切片中
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -352,7 +418,7 @@ func (s *Scanner) splitDocumentIfNeeded(doc *types.Document) []*types.Document {
 	}
 
 	// 解析文档的TOC
-	toc := s.parseDocumentTOC(doc.Content, doc.ID)
+	toc := s.ParseDocumentTOC(doc.Content, doc.ID)
 
 	// 如果只有少数几个章节，且每个章节都不太大，不需要分割
 	if len(toc.Sections) <= 5 {
@@ -389,9 +455,9 @@ func (s *Scanner) splitDocumentIfNeeded(doc *types.Document) []*types.Document {
 	return splitDocs
 }
 
-// parseDocumentTOC 解析文档目录结构
+// ParseDocumentTOC 解析文档目录结构
 // 使用两阶段解析：第一阶段收集标题层级关系，第二阶段构建包含子章节内容的完整章节
-func (s *Scanner) parseDocumentTOC(content, docID string) *types.DocumentTOC {
+func (s *Scanner) ParseDocumentTOC(content, docID string) *types.DocumentTOC {
 	lines := strings.Split(content, "\n")
 
 	// 第一阶段：解析所有标题及其位置、层级
@@ -571,9 +637,14 @@ func (s *Scanner) splitLargeSection(doc *types.Document, section types.DocumentS
 	return subDocs
 }
 
+// SectionDocumentID 返回文档分割后第 index 个章节（ParseDocumentTOC 中的序号）对应的文档ID
+func (s *Scanner) SectionDocumentID(docID string, section types.DocumentSection, index int) string {
+	return fmt.Sprintf("%s_%s_%d", docID, sanitizeID(section.Title), index)
+}
+
 // createSectionDocument 创建章节文档
 func (s *Scanner) createSectionDocument(doc *types.Document, section types.DocumentSection, index int) *types.Document {
-	sectionID := fmt.Sprintf("%s_%s_%d", doc.ID, sanitizeID(section.Title), index)
+	sectionID := s.SectionDocumentID(doc.ID, section, index)
 
 	return &types.Document{
 		ID:           sectionID,
@@ -591,6 +662,7 @@ func (s *Scanner) createSectionDocument(doc *types.Document, section types.Docum
 		LastModified:  doc.LastModified,
 		Content:       section.Content,
 		ContentPreview: s.generateContentPreview(section.Content),
+		ParentTitle:   doc.Title,
 	}
 }
 
@@ -617,6 +689,7 @@ func (s *Scanner) createSubSectionDocument(doc *types.Document, parentSection ty
 		LastModified:  doc.LastModified,
 		Content:       subSection.Content,
 		ContentPreview: s.generateContentPreview(subSection.Content),
+		ParentTitle:   doc.Title,
 	}
 }
 
@@ -642,12 +715,7 @@ func (s *Scanner) generateSectionDescription(content string) string {
 		}
 	}
 
-	desc := strings.Join(descLines, " ")
-	if len(desc) > 150 {
-		desc = desc[:150] + "..."
-	}
-
-	return desc
+	return utils.TruncateRunes(strings.Join(descLines, " "), 150)
 }
 
 // sanitizeID 清理ID中的特殊字符
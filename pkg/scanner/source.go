@@ -0,0 +1,249 @@
+package scanner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cangje-docs-mcp/pkg/cjlang"
+	"cangje-docs-mcp/pkg/types"
+)
+
+// 扫描仓颉源码时跳过的目录
+var skippedSourceDirs = map[string]bool{
+	".git":   true,
+	"target": true,
+	"build":  true,
+	"output": true,
+}
+
+// scanSourceDirectory 扫描仓颉源码目录，为每个非 private 的顶层声明生成一篇API文档
+// 文档路径按包名组织（分类/包名各段/源文件），与标准库文档的层级结构一致
+func (s *Scanner) scanSourceDirectory(root types.DocRoot, documents map[string]*types.Document) error {
+	fullPathIDs := make(map[string]bool)
+	return filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root.Path && (skippedSourceDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// 只处理 .cj 源文件，跳过单元测试文件
+		name := d.Name()
+		if !strings.HasSuffix(name, ".cj") || strings.HasSuffix(name, "_test.cj") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root.Path, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		}
+
+		docs, err := s.parseSourceFile(root, path, relPath)
+		if err != nil {
+			// 记录错误但继续扫描其他文件
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse source %s: %v\n", path, err)
+			return nil
+		}
+
+		for _, doc := range docs {
+			id := doc.ID
+			for n := 2; documents[id] != nil; n++ {
+				// 重载函数等同名声明
+				id = fmt.Sprintf("%s_%d", doc.ID, n)
+			}
+			doc.ID = id
+			documents[id] = doc
+
+			// 同一文件中的重载也需要不同的完整路径ID，以便通过完整路径ID读取
+			fullPathID := doc.FullPathID
+			for n := 2; fullPathIDs[fullPathID]; n++ {
+				fullPathID = fmt.Sprintf("%s_%d", doc.FullPathID, n)
+			}
+			doc.FullPathID = fullPathID
+			fullPathIDs[fullPathID] = true
+		}
+
+		return nil
+	})
+}
+
+// parseSourceFile 解析单个仓颉源文件，返回其中声明对应的文档
+func (s *Scanner) parseSourceFile(root types.DocRoot, fullPath, relativePath string) ([]*types.Document, error) {
+	fileInfo, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	file := cjlang.ParseSource(string(content))
+	pkg := file.Package
+	if pkg == "" {
+		pkg = packageFromPath(root, relativePath)
+	}
+
+	// 按包名组织文档路径：category/mycorp/net/http/client.cj
+	pathParts := append([]string{string(root.Category)}, strings.Split(pkg, ".")...)
+	pathParts = append(pathParts, filepath.Base(relativePath))
+	docRelPath := filepath.Join(pathParts...)
+
+	category, subcategory := s.determineCategory(docRelPath)
+	_, fileID := s.generateID(category, subcategory, docRelPath)
+
+	var docs []*types.Document
+	for _, decl := range file.Decls {
+		if decl.IsPrivate() {
+			continue
+		}
+
+		markdown := renderDeclMarkdown(pkg, decl)
+		name := sanitizeID(decl.Kind + "_" + decl.Name)
+
+		keywords := s.extractKeywords(markdown)
+		for _, symbol := range declSymbols(decl) {
+			symbol = strings.ToLower(symbol)
+			if !contains(keywords, symbol) {
+				keywords = append(keywords, symbol)
+			}
+		}
+
+		docs = append(docs, &types.Document{
+			ID:             fmt.Sprintf("%s_%s_%s", category, strings.ReplaceAll(pkg, ".", "_"), name),
+			FullPathID:     fileID + "_" + name,
+			Title:          declTitle(decl),
+			Category:       category,
+			Subcategory:    subcategory,
+			Description:    declDescription(decl),
+			FilePath:       fullPath,
+			RelativePath:   docRelPath,
+			Keywords:       keywords,
+			Prerequisites:  []string{},
+			RelatedDocs:    []string{},
+			Difficulty:     "intermediate",
+			FileSize:       int64(len(markdown)),
+			LastModified:   fileInfo.ModTime(),
+			Content:        markdown,
+			ContentPreview: s.generateContentPreview(markdown),
+			Package:        pkg,
+		})
+	}
+
+	return docs, nil
+}
+
+// packageFromPath 源文件未声明包名时，按 cjpm 约定由目录推导：src 目录对应根包
+func packageFromPath(root types.DocRoot, relativePath string) string {
+	dir := filepath.Dir(relativePath)
+	parts := strings.Split(filepath.ToSlash(dir), "/")
+	if len(parts) > 0 && (parts[0] == "src" || parts[0] == ".") {
+		parts = parts[1:]
+	}
+
+	pkg := root.Name
+	if len(parts) > 0 {
+		pkg += "." + strings.Join(parts, ".")
+	}
+	return pkg
+}
+
+// declTitle 生成声明标题，与标准库文档的标题格式一致，如 class ArrayList<T>
+func declTitle(decl cjlang.Decl) string {
+	return decl.Kind + " " + decl.Name + decl.Generics
+}
+
+// declHeading 生成成员标题，如 func get(path: String)
+func declHeading(decl cjlang.Decl) string {
+	signature := decl.Signature
+	for _, modifier := range decl.Modifiers {
+		signature = strings.TrimSpace(strings.TrimPrefix(signature, modifier))
+	}
+	// 去掉返回类型等尾部内容，只保留到参数列表结束
+	if idx := matchingParen(signature); idx > 0 {
+		signature = signature[:idx+1]
+	} else if idx := strings.Index(signature, ":"); idx > 0 && decl.Kind == "prop" {
+		signature = strings.TrimSpace(signature[:idx])
+	}
+	return signature
+}
+
+// matchingParen 返回第一个左括号对应的右括号位置，没有括号返回-1
+func matchingParen(s string) int {
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return -1
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// declDescription 使用文档注释的第一段作为描述，没有注释时使用签名
+func declDescription(decl cjlang.Decl) string {
+	if decl.Doc == "" {
+		return decl.Signature
+	}
+	paragraph := strings.SplitN(decl.Doc, "\n\n", 2)[0]
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+// declSymbols 返回声明及其成员的名称，用于关键词索引
+func declSymbols(decl cjlang.Decl) []string {
+	symbols := []string{decl.Name}
+	for _, member := range decl.Members {
+		if !member.IsPrivate() {
+			symbols = append(symbols, member.Name)
+		}
+	}
+	return symbols
+}
+
+// renderDeclMarkdown 将声明渲染为与标准库API文档结构一致的 Markdown
+func renderDeclMarkdown(pkg string, decl cjlang.Decl) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# %s\n\n", declTitle(decl)))
+	builder.WriteString(fmt.Sprintf("所属包：`%s`\n\n", pkg))
+	writeDeclBody(&builder, decl)
+
+	for _, member := range decl.Members {
+		if member.IsPrivate() {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("## %s\n\n", declHeading(member)))
+		writeDeclBody(&builder, member)
+	}
+
+	return builder.String()
+}
+
+// writeDeclBody 写入声明签名代码块和文档注释
+func writeDeclBody(builder *strings.Builder, decl cjlang.Decl) {
+	builder.WriteString("```cangjie\n")
+	builder.WriteString(decl.Signature)
+	builder.WriteString("\n```\n\n")
+	if decl.Doc != "" {
+		builder.WriteString(decl.Doc)
+		builder.WriteString("\n\n")
+	}
+}
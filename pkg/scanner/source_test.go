@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestScanSourceDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/net/http.cj": "package mylib.net\n\n" +
+			"/** 发送请求 */\npublic func get(url: String): String { \"\" }\n" +
+			"/** 带超时发送请求 */\npublic func get(url: String, timeout: Int64): String { \"\" }\n" +
+			"public class Client {}\n" +
+			"private func hidden(): Unit {}\n",
+		"src/net/more.cj":      "package mylib.net\n\n/** 无参数的重载 */\npublic func get(): Unit {}\n",
+		"src/util/text.cj":     "public func trim(s: String): String { s }\n",
+		"src/main.cj":          "main(): Int64 { 0 }\npublic func run(): Unit {}\n",
+		"src/net/http_test.cj": "package mylib.net\n\npublic func testGet(): Unit {}\n",
+		"target/gen.cj":        "public func generated(): Unit {}\n",
+		".cache/old.cj":        "public func cached(): Unit {}\n",
+		"README.md":            "# mylib\n",
+	})
+
+	root := types.DocRoot{Name: "mylib", Path: dir, Category: "mylib", Format: types.RootFormatCangjie}
+	s := NewScanner(t.TempDir())
+	s.AddRoot(root)
+	documents := make(map[string]*types.Document)
+	if err := s.scanSourceDirectory(root, documents); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同名声明按遍历顺序（文件名字典序、文件内出现顺序）加 _2、_3 后缀，完整路径ID在同一文件内同样去重
+	var got []string
+	for id, doc := range documents {
+		got = append(got, fmt.Sprintf("%s | %s | %s | %s | %s", id, doc.FullPathID, filepath.ToSlash(doc.RelativePath), doc.Package, doc.Title))
+		if doc.ID != id || doc.Category != "mylib" || doc.Subcategory != "mylib" {
+			t.Errorf("%s: id %q category %s/%s", id, doc.ID, doc.Category, doc.Subcategory)
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		// main.cj 没有 package 声明，src 目录对应根包
+		"mylib_mylib_func_run | mylib_mylib_main_func_run | mylib/mylib/main.cj | mylib | func run",
+		"mylib_mylib_net_class_Client | mylib_mylib_net_http_class_Client | mylib/mylib/net/http.cj | mylib.net | class Client",
+		"mylib_mylib_net_func_get | mylib_mylib_net_http_func_get | mylib/mylib/net/http.cj | mylib.net | func get",
+		"mylib_mylib_net_func_get_2 | mylib_mylib_net_http_func_get_2 | mylib/mylib/net/http.cj | mylib.net | func get",
+		"mylib_mylib_net_func_get_3 | mylib_mylib_net_more_func_get | mylib/mylib/net/more.cj | mylib.net | func get",
+		// text.cj 没有 package 声明，包名由目录推导
+		"mylib_mylib_util_func_trim | mylib_mylib_util_text_func_trim | mylib/mylib/util/text.cj | mylib.util | func trim",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("documents:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 后缀对应源码中的声明顺序
+	overloads := map[string]string{
+		"mylib_mylib_net_func_get":   "发送请求",
+		"mylib_mylib_net_func_get_2": "带超时发送请求",
+		"mylib_mylib_net_func_get_3": "无参数的重载",
+	}
+	for id, comment := range overloads {
+		if doc := documents[id]; doc == nil || !strings.Contains(doc.Content, comment) {
+			t.Errorf("%s does not contain %q", id, comment)
+		}
+	}
+}
+
+func TestPackageFromPath(t *testing.T) {
+	root := types.DocRoot{Name: "mylib"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/main.cj", "mylib"},
+		{"src/net/http/client.cj", "mylib.net.http"},
+		{"main.cj", "mylib"},
+		{"net/http.cj", "mylib.net"},
+	}
+	for _, tt := range tests {
+		if got := packageFromPath(root, filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("packageFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
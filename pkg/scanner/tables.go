@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// assignTables 解析文档中的表格并附加到文档
+func (s *Scanner) assignTables(documents map[string]*types.Document) {
+	for _, doc := range documents {
+		doc.Tables = parseMarkdownTables(doc.Content)
+	}
+}
+
+// parseMarkdownTables 解析内容中的 Markdown 表格，代码块中的内容不处理
+func parseMarkdownTables(content string) []types.Table {
+	var tables []types.Table
+	lines := strings.Split(content, "\n")
+	section := ""
+	inCodeBlock := false
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+
+		// 表头行后面必须紧跟分隔行，如 |---|:---:|
+		if !isTableRow(line) || i+1 >= len(lines) || !isTableDelimiter(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+
+		table := types.Table{
+			Index:   len(tables) + 1,
+			Headers: splitTableRow(line),
+			Section: section,
+			Line:    i + 1,
+		}
+		i += 2
+		for ; i < len(lines); i++ {
+			row := strings.TrimSpace(lines[i])
+			if !isTableRow(row) || strings.HasPrefix(row, "#") {
+				break
+			}
+			cells := splitTableRow(row)
+			// 省略两侧竖线的行需要与表头列数相同，否则视为紧跟表格的普通段落（如行内代码中的竖线）
+			if !strings.HasPrefix(row, "|") && len(cells) != len(table.Headers) {
+				break
+			}
+			// 补齐或截断到表头的列数
+			for len(cells) < len(table.Headers) {
+				cells = append(cells, "")
+			}
+			table.Rows = append(table.Rows, cells[:len(table.Headers)])
+		}
+		i--
+
+		tables = append(tables, table)
+	}
+
+	return tables
+}
+
+// isTableRow 判断是否为表格行，两侧的竖线可以省略，此时两列表格的每行只有一个竖线
+func isTableRow(line string) bool {
+	return strings.Contains(line, "|") && !strings.HasPrefix(line, "```")
+}
+
+// isTableDelimiter 判断是否为表头分隔行
+func isTableDelimiter(line string) bool {
+	if !strings.Contains(line, "-") || !strings.Contains(line, "|") {
+		return false
+	}
+	for _, r := range line {
+		if r != '|' && r != '-' && r != ':' && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTableRow 将表格行切分为单元格，忽略转义的竖线和代码中的竖线
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	// 反引号不成对时不把它当作代码区间，以免整行被合并成一个单元格
+	protectCode := strings.Count(line, "`")%2 == 0
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '`' && protectCode:
+			inCode = !inCode
+			cell.WriteByte('`')
+		case line[i] == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	cells = append(cells, strings.TrimSpace(cell.String()))
+	return cells
+}
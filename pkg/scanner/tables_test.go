@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestParseMarkdownTables(t *testing.T) {
+	content := "# 配置\n" +
+		"\n" +
+		"## 基本类型\n" +
+		"\n" +
+		"| 类型 | 说明 | 示例 |\n" +
+		"|:---|---:|:---:|\n" +
+		"| `Int64` | 64 位整数 | `1 \\| 2` |\n" +
+		"| Bool | 布尔 |\n" +
+		"| `a|b` | 代码中的竖线 | x | 多余的列 |\n" +
+		"\n" +
+		"```markdown\n" +
+		"| 代码块 | 中的表格 |\n" +
+		"|---|---|\n" +
+		"```\n" +
+		"\n" +
+		"## 运算符\n" +
+		"运算符 | 含义\n" +
+		"--- | ---\n" +
+		"`+` | 加\n" +
+		"`` ` `` | 反引号\n" +
+		"不是表格的段落\n" +
+		"| 只有表头 |\n" +
+		"| 没有分隔行 |\n"
+
+	want := []types.Table{
+		{
+			Index:   1,
+			Section: "基本类型",
+			Line:    5,
+			Headers: []string{"类型", "说明", "示例"},
+			Rows: [][]string{
+				{"`Int64`", "64 位整数", "`1 | 2`"},
+				{"Bool", "布尔", ""},
+				{"`a|b`", "代码中的竖线", "x"},
+			},
+		},
+		{
+			Index:   2,
+			Section: "运算符",
+			Line:    17,
+			Headers: []string{"运算符", "含义"},
+			Rows: [][]string{
+				{"`+`", "加"},
+				{"`` ` ``", "反引号"},
+			},
+		},
+	}
+
+	if got := parseMarkdownTables(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMarkdownTables:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseMarkdownTablesEnd(t *testing.T) {
+	content := "## 运算符\n" +
+		"\n" +
+		"| 运算符 | 含义 |\n" +
+		"|---|---|\n" +
+		"| `&` | 按位与 |\n" +
+		"使用 `a | b` 表示按位或，紧跟表格的段落不是表格行。\n" +
+		"\n" +
+		"| 空行之后 | 不属于表格 |\n" +
+		"\n" +
+		"关键字 | 说明\n" +
+		"--- | ---\n" +
+		"`let` | 不可变变量\n" +
+		"表格后的段落，`x | y` 中的竖线在代码里\n" +
+		"## 标题 | 也不是表格行\n" +
+		"\n" +
+		"| 类型 | 说明 |\n" +
+		"|---|---|\n" +
+		"| Int64 | 整数 |\n"
+
+	want := []types.Table{
+		{
+			Index:   1,
+			Section: "运算符",
+			Line:    3,
+			Headers: []string{"运算符", "含义"},
+			Rows:    [][]string{{"`&`", "按位与"}},
+		},
+		{
+			Index:   2,
+			Section: "运算符",
+			Line:    10,
+			Headers: []string{"关键字", "说明"},
+			Rows:    [][]string{{"`let`", "不可变变量"}},
+		},
+		{
+			Index:   3,
+			Section: "标题 | 也不是表格行",
+			Line:    16,
+			Headers: []string{"类型", "说明"},
+			Rows:    [][]string{{"Int64", "整数"}},
+		},
+	}
+
+	if got := parseMarkdownTables(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMarkdownTables:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitTableRow(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"| a | b |", []string{"a", "b"}},
+		{"a | b", []string{"a", "b"}},
+		{"| a \\| b | c \\|", []string{"a | b", "c |"}},
+		{"| `x || y` | 或 |", []string{"`x || y`", "或"}},
+		// 反引号不成对时按普通字符处理
+		{"| ` | 反引号 |", []string{"`", "反引号"}},
+		{"| | 空单元格 |", []string{"", "空单元格"}},
+	}
+	for _, tt := range tests {
+		if got := splitTableRow(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitTableRow(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestIsTableDelimiter(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"|---|---|", true},
+		{"| :--- | ---: | :---: |", true},
+		{"--- | ---", true},
+		{"---", false},
+		{"| a | b |", false},
+		{"|||", false},
+	}
+	for _, tt := range tests {
+		if got := isTableDelimiter(tt.line); got != tt.want {
+			t.Errorf("isTableDelimiter(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
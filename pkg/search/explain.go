@@ -0,0 +1,65 @@
+package search
+
+import "cangje-docs-mcp/pkg/types"
+
+// 各搜索模式的分数计算方式
+const (
+	keywordNormalization  = "keyword: sum of weighted field matches (no normalization), then boosts; results below min_confidence are dropped"
+	semanticNormalization = "semantic: cosine similarity between the query and document LSA vectors (both L2-normalized), then boosts; below 0.1 is dropped"
+	hybridNormalization   = "hybrid: reciprocal rank fusion, sum of 1/(60+rank) over the keyword and semantic rankings"
+)
+
+// scoreTrace 记录一篇文档的分数组成，为 nil 时不记录，不需要解释时没有额外开销
+type scoreTrace struct {
+	components []types.ScoreComponent
+}
+
+// newScoreTrace 需要解释分数时返回新的记录，否则返回 nil
+func newScoreTrace(explain bool) *scoreTrace {
+	if !explain {
+		return nil
+	}
+	return &scoreTrace{}
+}
+
+// add 记录一项得分，得分为权重乘以命中次数
+func (t *scoreTrace) add(stage, term, field string, weight float64, count int) {
+	if t == nil || count == 0 {
+		return
+	}
+	t.components = append(t.components, types.ScoreComponent{
+		Stage:  stage,
+		Term:   term,
+		Field:  field,
+		Weight: weight,
+		Count:  count,
+		Score:  weight * float64(count),
+	})
+}
+
+// merge 把另一条记录的各项按系数合并进来，用于同义词扩展词
+func (t *scoreTrace) merge(other *scoreTrace, stage string, factor float64) {
+	if t == nil || other == nil {
+		return
+	}
+	for _, component := range other.components {
+		component.Stage = stage
+		component.Weight *= factor
+		component.Score *= factor
+		t.components = append(t.components, component)
+	}
+}
+
+// explanation 根据记录和加成生成分数说明
+func (t *scoreTrace) explanation(base float64, boosts []types.ScoreBoost, score float64, normalization string) *types.ScoreExplanation {
+	if t == nil {
+		return nil
+	}
+	return &types.ScoreExplanation{
+		Components:    t.components,
+		Base:          base,
+		Boosts:        boosts,
+		Score:         score,
+		Normalization: normalization,
+	}
+}
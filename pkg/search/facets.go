@@ -0,0 +1,99 @@
+package search
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// computeFacets 统计命中文档在各维度上的分布，每个维度按文档数从多到少排列，最多保留 MaxFacetValues 个取值
+func computeFacets(results []types.SearchResult) types.SearchFacets {
+	counts := map[string]map[string]int{
+		"category":    {},
+		"subcategory": {},
+		"package":     {},
+		"difficulty":  {},
+		"doc_type":    {},
+		"language":    {},
+	}
+	for i := range results {
+		doc := &results[i].Document
+		values := map[string]string{
+			"category":    string(doc.Category),
+			"subcategory": doc.Subcategory,
+			"package":     doc.Package,
+			"difficulty":  doc.Difficulty,
+			"doc_type":    DocumentType(doc),
+			"language":    DocumentLanguage(doc),
+		}
+		for facet, value := range values {
+			if value != "" {
+				counts[facet][value]++
+			}
+		}
+	}
+
+	facets := make(types.SearchFacets)
+	for facet, valueCounts := range counts {
+		values := make([]types.FacetValue, 0, len(valueCounts))
+		for value, count := range valueCounts {
+			values = append(values, types.FacetValue{Value: value, Count: count})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return values[i].Value < values[j].Value
+		})
+		if len(values) > types.MaxFacetValues {
+			values = values[:types.MaxFacetValues]
+		}
+		facets[facet] = values
+	}
+	return facets
+}
+
+// DocumentType 返回文档类型：开发工具文档为 tool，标准库和带包名的文档为 api，其余为 guide
+func DocumentType(doc *types.Document) string {
+	switch {
+	case doc.Category == types.CategoryTools:
+		return types.DocTypeTool
+	case doc.Category == types.CategoryLibs || doc.Package != "":
+		return types.DocTypeAPI
+	default:
+		return types.DocTypeGuide
+	}
+}
+
+// DocumentLanguage 返回文档语言（zh 或 en）：优先按 source_zh_cn/source_en 目录判断，否则按开头文本中汉字的比例判断
+func DocumentLanguage(doc *types.Document) string {
+	for _, part := range strings.Split(filepath.ToSlash(doc.RelativePath), "/") {
+		switch part {
+		case "source_zh_cn":
+			return "zh"
+		case "source_en":
+			return "en"
+		}
+	}
+
+	han, letters, seen := 0, 0, 0
+	for _, r := range doc.Title + "\n" + doc.Description + "\n" + doc.Content {
+		if seen++; seen > 2000 {
+			break
+		}
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	// 一个汉字约相当于一个英文单词，按英文字母数的 1/5 比较
+	if han > 0 && han*5 >= letters {
+		return "zh"
+	}
+	return "en"
+}
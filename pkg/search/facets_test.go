@@ -0,0 +1,36 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestSearchFacetsIndependentOfPageSize(t *testing.T) {
+	se, _ := loadRelevanceFixture(t)
+	for _, query := range []string{"hashmap", "文件 读取", "string"} {
+		var first types.SearchPage
+		for i, maxResults := range []int{1, 5, 50} {
+			page := se.SearchPage(types.SearchRequest{
+				Query:      query,
+				MaxResults: maxResults,
+				Collapse:   types.DefaultCollapseSections,
+				Facets:     true,
+			})
+			if page.Total == 0 {
+				t.Fatalf("%q: no results", query)
+			}
+			if i == 0 {
+				first = page
+				continue
+			}
+			if page.Total != first.Total {
+				t.Errorf("%q: total = %d with max_results %d, %d with max_results 1", query, page.Total, maxResults, first.Total)
+			}
+			if !reflect.DeepEqual(page.Facets, first.Facets) {
+				t.Errorf("%q: facets differ between max_results 1 and %d:\n%v\n%v", query, maxResults, first.Facets, page.Facets)
+			}
+		}
+	}
+}
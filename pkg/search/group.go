@@ -0,0 +1,52 @@
+package search
+
+import "cangje-docs-mcp/pkg/types"
+
+// ParentID 返回文档所属源文件的父文档ID：分割后的章节为父文档ID，未分割的文档为自身ID
+func ParentID(doc *types.Document) string {
+	if len(doc.Prerequisites) > 0 {
+		return doc.Prerequisites[0]
+	}
+	return doc.ID
+}
+
+// collapseResults 按源文件分组：每组保留排名最高的 perFile 个章节，其余章节折叠计数，命中多个章节的组在结果上记录分组信息
+// 排序规则：各组按组内最高分排列，组内保留的章节按分数紧跟在组内第一个结果之后，
+// 因此组内排名靠后的章节可能排在分数更高的下一组之前，同组的结果在一页中连续出现
+func collapseResults(results []types.SearchResult, perFile int) []types.SearchResult {
+	groups := make(map[string]*types.SearchGroup)
+	members := make(map[string][]types.SearchResult)
+	var order []string
+	for _, result := range results {
+		parentID := ParentID(&result.Document)
+		group, ok := groups[parentID]
+		if !ok {
+			title := result.Document.ParentTitle
+			if title == "" {
+				title = result.Document.Title
+			}
+			group = &types.SearchGroup{ParentID: parentID, ParentTitle: title}
+			groups[parentID] = group
+			order = append(order, parentID)
+		}
+		group.Matches++
+		if group.Shown < perFile {
+			group.Shown++
+			members[parentID] = append(members[parentID], result)
+		} else {
+			group.Collapsed++
+		}
+	}
+
+	collapsed := make([]types.SearchResult, 0, len(results))
+	for _, parentID := range order {
+		group := groups[parentID]
+		for _, result := range members[parentID] {
+			if group.Matches > 1 {
+				result.Group = group
+			}
+			collapsed = append(collapsed, result)
+		}
+	}
+	return collapsed
+}
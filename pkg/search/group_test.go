@@ -0,0 +1,138 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// groupResult 生成测试用的搜索结果，parent 不为空时表示分割后的章节
+func groupResult(id, parent string, score float64) types.SearchResult {
+	doc := types.Document{ID: id, Title: id}
+	if parent != "" {
+		doc.Prerequisites = []string{parent}
+		doc.ParentTitle = parent + " 标题"
+	}
+	return types.SearchResult{Document: doc, Score: score}
+}
+
+// groupLines 把结果展开为 "文档ID 分数 父文档ID matches/shown/collapsed" 形式，没有分组信息时省略后两项
+func groupLines(results []types.SearchResult) []string {
+	var lines []string
+	for _, result := range results {
+		line := fmt.Sprintf("%s %.0f", result.Document.ID, result.Score)
+		if group := result.Group; group != nil {
+			line += fmt.Sprintf(" %s %d/%d/%d", group.ParentID, group.Matches, group.Shown, group.Collapsed)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestCollapseResults(t *testing.T) {
+	results := []types.SearchResult{
+		groupResult("a_1", "a", 10),
+		groupResult("b", "", 9),
+		groupResult("a_2", "a", 8),
+		groupResult("c_1", "c", 7),
+		groupResult("a_3", "a", 6),
+		groupResult("a_4", "a", 5),
+		groupResult("c_2", "c", 4),
+	}
+
+	tests := []struct {
+		perFile int
+		want    []string
+	}{
+		{1, []string{
+			"a_1 10 a 4/1/3",
+			"b 9",
+			"c_1 7 c 2/1/1",
+		}},
+		// 组内保留的章节紧跟在组内第一个结果之后，a_2 排在分数更高的 b 之前
+		{2, []string{
+			"a_1 10 a 4/2/2",
+			"a_2 8 a 4/2/2",
+			"b 9",
+			"c_1 7 c 2/2/0",
+			"c_2 4 c 2/2/0",
+		}},
+		{10, []string{
+			"a_1 10 a 4/4/0",
+			"a_2 8 a 4/4/0",
+			"a_3 6 a 4/4/0",
+			"a_4 5 a 4/4/0",
+			"b 9",
+			"c_1 7 c 2/2/0",
+			"c_2 4 c 2/2/0",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("collapse %d", tt.perFile), func(t *testing.T) {
+			got := groupLines(collapseResults(results, tt.perFile))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collapseResults:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	// 同组结果共享同一个分组，父文档标题取自章节的 ParentTitle
+	collapsed := collapseResults(results, 2)
+	if collapsed[0].Group != collapsed[1].Group {
+		t.Error("results of the same file do not share the group")
+	}
+	if collapsed[0].Group.ParentTitle != "a 标题" {
+		t.Errorf("ParentTitle = %q, want a 标题", collapsed[0].Group.ParentTitle)
+	}
+	// 原结果不被修改
+	if results[0].Group != nil {
+		t.Error("collapseResults modified its input")
+	}
+}
+
+func TestSearchPageCollapse(t *testing.T) {
+	documents := make(map[string]*types.Document)
+	for i := 1; i <= 4; i++ {
+		id := fmt.Sprintf("guide_%d", i)
+		documents[id] = &types.Document{ID: id, Title: fmt.Sprintf("线程 第%d节", i), Category: types.CategoryManual,
+			Content: "thread spawn", Prerequisites: []string{"guide"}, ParentTitle: "并发指南"}
+	}
+	documents["api"] = &types.Document{ID: "api", Title: "线程 API", Category: types.CategoryLibs, Content: "thread"}
+	se := NewSearchEngine()
+	se.BuildIndex(documents)
+
+	req := types.SearchRequest{Query: "线程", MaxResults: 100}
+	full := se.SearchPage(req)
+	if full.Total != 5 {
+		t.Fatalf("uncollapsed total = %d, want 5", full.Total)
+	}
+
+	req.Collapse = 1
+	collapsed := se.SearchPage(req)
+	if collapsed.Total != 2 {
+		t.Fatalf("collapsed total = %d, want 2", collapsed.Total)
+	}
+	var group *types.SearchGroup
+	for _, result := range collapsed.Results {
+		if result.Group != nil {
+			group = result.Group
+		}
+	}
+	if group == nil || group.ParentID != "guide" || group.ParentTitle != "并发指南" || group.Matches != 4 || group.Shown != 1 || group.Collapsed != 3 {
+		t.Fatalf("group = %+v, want guide with 4 matches, 1 shown and 3 collapsed", group)
+	}
+
+	// 按父文档展开时不折叠
+	req.ParentID = "guide"
+	expanded := se.SearchPage(req)
+	if expanded.Total != group.Matches {
+		t.Errorf("expanded %d results, group reports %d matches", expanded.Total, group.Matches)
+	}
+	for _, result := range expanded.Results {
+		if ParentID(&result.Document) != "guide" || result.Group != nil {
+			t.Errorf("expanded result %s (group %+v), want sections of guide without group", result.Document.ID, result.Group)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package search
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cangje-docs-mcp/pkg/scanner"
+	"cangje-docs-mcp/pkg/types"
+)
+
+// updateRelevance 用当前指标重写基线：go test ./pkg/search -run TestSearchRelevance -update-relevance
+var updateRelevance = flag.Bool("update-relevance", false, "用当前指标更新 testdata/relevance/baseline.json")
+
+const (
+	// 指标低于基线超过此值时测试失败
+	relevanceTolerance = 0.02
+	// nDCG 和召回率的截断位置
+	relevanceCutoff = 10
+)
+
+var (
+	relevanceCorpus   = filepath.Join("testdata", "corpus")
+	relevanceGolden   = filepath.Join("testdata", "relevance", "golden.json")
+	relevanceBaseline = filepath.Join("testdata", "relevance", "baseline.json")
+	relevanceModes    = []string{types.SearchModeKeyword, types.SearchModeSemantic, types.SearchModeHybrid}
+)
+
+// goldenQuery 标注好的查询：relevant 为相关文档的相对路径或文档ID
+type goldenQuery struct {
+	Query    string   `json:"query"`
+	Relevant []string `json:"relevant"`
+}
+
+// relevanceMetrics 一组查询的平均指标
+type relevanceMetrics struct {
+	MRR    float64 `json:"mrr"`
+	NDCG   float64 `json:"ndcg@10"`
+	Recall float64 `json:"recall@10"`
+}
+
+// loadRelevanceFixture 扫描测试语料并建立索引，语义索引只保存在内存中
+func loadRelevanceFixture(tb testing.TB) (*SearchEngine, []goldenQuery) {
+	tb.Helper()
+	documents, err := scanner.NewScanner(relevanceCorpus).ScanAll()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	se := NewSearchEngine()
+	se.BuildIndex(documents)
+
+	data, err := os.ReadFile(relevanceGolden)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var queries []goldenQuery
+	if err := json.Unmarshal(data, &queries); err != nil {
+		tb.Fatalf("%s: %v", relevanceGolden, err)
+	}
+	return se, queries
+}
+
+// evaluateRelevance 计算一种搜索模式在全部查询上的平均 MRR、nDCG@10 和召回率@10，并返回前10条中没有相关文档的查询
+func evaluateRelevance(se *SearchEngine, queries []goldenQuery, mode string) (relevanceMetrics, []string) {
+	var total relevanceMetrics
+	var misses []string
+	for _, q := range queries {
+		results := se.Search(types.SearchRequest{
+			Query:      q.Query,
+			Mode:       mode,
+			MaxResults: relevanceCutoff,
+			Collapse:   types.DefaultCollapseSections,
+		})
+
+		// 同一相关文档（包括其分割后的多个章节）只计一次
+		found := make(map[string]bool)
+		reciprocalRank, dcg := 0.0, 0.0
+		for i, result := range results {
+			target := relevantTarget(&result.Document, q.Relevant)
+			if target == "" || found[target] {
+				continue
+			}
+			found[target] = true
+			if reciprocalRank == 0 {
+				reciprocalRank = 1 / float64(i+1)
+			}
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+		idealDCG := 0.0
+		for i := 0; i < len(q.Relevant) && i < relevanceCutoff; i++ {
+			idealDCG += 1 / math.Log2(float64(i+2))
+		}
+
+		total.MRR += reciprocalRank
+		total.NDCG += dcg / idealDCG
+		total.Recall += float64(len(found)) / float64(len(q.Relevant))
+		if len(found) == 0 {
+			misses = append(misses, q.Query)
+		}
+	}
+
+	n := float64(len(queries))
+	return relevanceMetrics{
+		MRR:    round4(total.MRR / n),
+		NDCG:   round4(total.NDCG / n),
+		Recall: round4(total.Recall / n),
+	}, misses
+}
+
+// relevantTarget 返回结果对应的相关文档标注，不相关时返回空字符串
+func relevantTarget(doc *types.Document, relevant []string) string {
+	path := filepath.ToSlash(doc.RelativePath)
+	for _, target := range relevant {
+		if target == path || target == doc.ID || target == ParentID(doc) {
+			return target
+		}
+	}
+	return ""
+}
+
+func round4(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// TestSearchRelevance 在测试语料上评估各搜索模式，任一指标比基线下降超过 relevanceTolerance 时失败
+func TestSearchRelevance(t *testing.T) {
+	se, queries := loadRelevanceFixture(t)
+
+	current := make(map[string]relevanceMetrics)
+	for _, mode := range relevanceModes {
+		metrics, misses := evaluateRelevance(se, queries, mode)
+		current[mode] = metrics
+		t.Logf("%-8s MRR=%.4f nDCG@10=%.4f recall@10=%.4f", mode, metrics.MRR, metrics.NDCG, metrics.Recall)
+		if len(misses) > 0 {
+			t.Logf("%-8s no relevant result in top %d: %q", mode, relevanceCutoff, misses)
+		}
+	}
+
+	if *updateRelevance {
+		data, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(relevanceBaseline, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("baseline updated: %s", relevanceBaseline)
+		return
+	}
+
+	data, err := os.ReadFile(relevanceBaseline)
+	if err != nil {
+		t.Fatalf("%v (run with -update-relevance to create it)", err)
+	}
+	var baseline map[string]relevanceMetrics
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		t.Fatalf("%s: %v", relevanceBaseline, err)
+	}
+
+	for _, mode := range relevanceModes {
+		want, ok := baseline[mode]
+		if !ok {
+			t.Errorf("%s: no baseline for mode %s", relevanceBaseline, mode)
+			continue
+		}
+		got := current[mode]
+		checks := []struct {
+			name      string
+			got, want float64
+		}{
+			{"MRR", got.MRR, want.MRR},
+			{"nDCG@10", got.NDCG, want.NDCG},
+			{"recall@10", got.Recall, want.Recall},
+		}
+		for _, check := range checks {
+			switch {
+			case check.got < check.want-relevanceTolerance:
+				t.Errorf("%s %s dropped from %.4f to %.4f", mode, check.name, check.want, check.got)
+			case check.got > check.want+relevanceTolerance:
+				t.Logf("%s %s improved from %.4f to %.4f, run with -update-relevance to raise the baseline", mode, check.name, check.want, check.got)
+			}
+		}
+	}
+}
+
+// BenchmarkSearch 在测试语料上执行全部标注查询
+func BenchmarkSearch(b *testing.B) {
+	se, queries := loadRelevanceFixture(b)
+	for _, mode := range relevanceModes {
+		b.Run(mode, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, q := range queries {
+					se.Search(types.SearchRequest{Query: q.Query, Mode: mode, MaxResults: relevanceCutoff})
+				}
+			}
+		})
+	}
+}
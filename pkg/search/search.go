@@ -2,17 +2,30 @@ package search
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
 
 	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
 )
 
 // SearchEngine 搜索引擎
 type SearchEngine struct {
-	documents    map[string]*types.Document
-	keywordIndex map[string][]string // 关键词到文档ID的映射
+	documents      map[string]*types.Document
+	keywordIndex   map[string][]string          // 关键词到文档ID的映射
+	packageIndex   map[string][]string          // 包名到文档ID的映射
+	symbolIndex    map[string][]types.APISymbol // 符号名到符号定义的映射
+	memberIndex    map[string][]types.APISymbol // 类型名到成员定义的映射
+	codeWords      map[string]map[string]bool   // 文档ID到代码词（API符号、代码块）集合的映射
+	vocabulary     []string                     // 用于拼写纠正的英文词表
+	synonyms       map[string][]string          // 同义词和中英文对照表
+	semantic       *semanticIndex               // 语义索引（LSA）
+	indexFile      string                       // 持久化索引文件路径
+	rootPriorities map[string]int               // 文档来源到优先级的映射
+	generation     int                          // BuildIndex 的次数
+	snapshot       string                       // 当前索引的快照标识
 }
 
 // NewSearchEngine 创建新的搜索引擎
@@ -20,77 +33,162 @@ func NewSearchEngine() *SearchEngine {
 	return &SearchEngine{
 		documents:    make(map[string]*types.Document),
 		keywordIndex: make(map[string][]string),
+		packageIndex: make(map[string][]string),
+		synonyms:     buildSynonyms(defaultSynonymGroups),
 	}
 }
 
+// SetRootPriorities 设置各文档来源的优先级，优先级高的来源搜索分数更高
+func (se *SearchEngine) SetRootPriorities(priorities map[string]int) {
+	se.rootPriorities = priorities
+}
+
 // BuildIndex 构建搜索索引
 func (se *SearchEngine) BuildIndex(documents map[string]*types.Document) {
 	se.documents = documents
+	docIDs := make([]string, 0, len(documents))
+	for docID := range documents {
+		docIDs = append(docIDs, docID)
+	}
+	sort.Strings(docIDs)
+	fingerprint := corpusFingerprint(documents, docIDs)
+	se.generation++
+	se.snapshot = fmt.Sprintf("%d-%s", se.generation, fingerprint)
+
 	se.buildKeywordIndex()
+	se.buildVocabulary()
+	se.buildPackageIndex()
+	se.buildSymbolIndex()
+	if err := se.buildSemanticIndex(docIDs, fingerprint); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// Snapshot 返回当前索引的快照标识，由建立索引的次数和语料指纹组成，
+// 重新建立索引或文档内容变化后都会改变，未建立索引时返回空字符串
+func (se *SearchEngine) Snapshot() string {
+	return se.snapshot
+}
+
+// buildPackageIndex 构建包名索引
+func (se *SearchEngine) buildPackageIndex() {
+	se.packageIndex = make(map[string][]string)
+	for docID, doc := range se.documents {
+		if doc.Package != "" {
+			se.packageIndex[doc.Package] = append(se.packageIndex[doc.Package], docID)
+		}
+	}
+	for _, docIDs := range se.packageIndex {
+		sort.Strings(docIDs)
+	}
+}
+
+// PackageDocuments 返回属于指定包的文档
+func (se *SearchEngine) PackageDocuments(pkg string) []*types.Document {
+	var docs []*types.Document
+	for _, docID := range se.packageIndex[pkg] {
+		docs = append(docs, se.documents[docID])
+	}
+	return docs
+}
+
+// Packages 返回有文档的全部包名
+func (se *SearchEngine) Packages() []string {
+	packages := make([]string, 0, len(se.packageIndex))
+	for pkg := range se.packageIndex {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	return packages
 }
 
 // buildKeywordIndex 构建关键词索引
 func (se *SearchEngine) buildKeywordIndex() {
 	se.keywordIndex = make(map[string][]string)
+	se.codeWords = make(map[string]map[string]bool)
 
 	for docID, doc := range se.documents {
+		// 同一文档的词只记录一次
+		indexed := make(map[string]bool)
+
 		// 为标题建立索引
 		titleWords := se.extractWords(doc.Title)
 		for _, word := range titleWords {
-			se.addToIndex(word, docID)
+			se.addToIndex(word, docID, indexed)
 		}
 
 		// 为描述建立索引
 		descriptionWords := se.extractWords(doc.Description)
 		for _, word := range descriptionWords {
-			se.addToIndex(word, docID)
+			se.addToIndex(word, docID, indexed)
 		}
 
 		// 为关键词建立索引
 		for _, keyword := range doc.Keywords {
-			se.addToIndex(strings.ToLower(keyword), docID)
+			se.addToIndex(strings.ToLower(keyword), docID, indexed)
 		}
 
 		// 为内容建立索引（只取前1000个字符）
-		content := doc.Content
-		if len(content) > 1000 {
-			content = content[:1000]
-		}
-		contentWords := se.extractWords(content)
+		contentWords := se.extractWords(utils.RunePrefix(doc.Content, 1000))
 		for _, word := range contentWords {
-			se.addToIndex(word, docID)
+			se.addToIndex(word, docID, indexed)
+		}
+
+		// 为表格单元格建立索引，表格常位于长文档后部，不受内容长度限制
+		for _, table := range doc.Tables {
+			for _, row := range table.Rows {
+				for _, cell := range row {
+					for _, word := range se.extractWords(cell) {
+						se.addToIndex(word, docID, indexed)
+					}
+				}
+			}
 		}
 
 		// 为文件名建立索引
 		filename := strings.TrimSuffix(doc.RelativePath, ".md")
 		filenameWords := se.extractWords(filename)
 		for _, word := range filenameWords {
-			se.addToIndex(word, docID)
+			se.addToIndex(word, docID, indexed)
 		}
-	}
-}
-
-// addToIndex 添加到索引
-func (se *SearchEngine) addToIndex(keyword, docID string) {
-	if _, exists := se.keywordIndex[keyword]; !exists {
-		se.keywordIndex[keyword] = []string{}
-	}
 
-	// 避免重复添加
-	for _, id := range se.keywordIndex[keyword] {
-		if id == docID {
-			return
+		// 为API符号和代码块建立代码词索引，代码块不受内容长度限制
+		var code strings.Builder
+		code.WriteString(doc.Title + "\n" + doc.Package + "\n")
+		if doc.Package != "" {
+			for _, symbol := range extractDocSymbols(doc) {
+				code.WriteString(symbol.Section + "\n")
+			}
+		}
+		code.WriteString(extractCodeBlocks(doc.Content))
+		codeWords := make(map[string]bool)
+		for _, word := range se.extractCodeWords(code.String(), true) {
+			codeWords[word] = true
+			se.addToIndex(word, docID, indexed)
 		}
+		se.codeWords[docID] = codeWords
 	}
+}
 
+// addToIndex 添加到索引，indexed 为当前文档已添加的词，避免同一文档重复出现在一个词的文档列表中
+func (se *SearchEngine) addToIndex(keyword, docID string, indexed map[string]bool) {
+	if indexed[keyword] {
+		return
+	}
+	indexed[keyword] = true
 	se.keywordIndex[keyword] = append(se.keywordIndex[keyword], docID)
 }
 
 // Search 执行搜索
 func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
+	return se.SearchPage(req).Results
+}
+
+// SearchPage 执行搜索，返回从 req.Offset 开始的一页结果、结果总数、按需统计的维度分布和拼写建议
+func (se *SearchEngine) SearchPage(req types.SearchRequest) types.SearchPage {
 	query := strings.ToLower(strings.TrimSpace(req.Query))
 	if query == "" {
-		return []types.SearchResult{}
+		return types.SearchPage{Results: []types.SearchResult{}}
 	}
 
 	maxResults := req.MaxResults
@@ -98,13 +196,68 @@ func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
 		maxResults = types.DefaultMaxResults
 	}
 
+	var results []types.SearchResult
+	switch req.Mode {
+	case types.SearchModeSemantic:
+		results = se.semanticSearch(req, query)
+	case types.SearchModeHybrid:
+		results = fuseRankings(se.keywordSearch(req, query), se.semanticSearch(req, query), req.Explain)
+	default:
+		results = se.keywordSearch(req, query)
+	}
+
+	// 维度统计基于全部命中文档，不受折叠和分页影响
+	var facets types.SearchFacets
+	if req.Facets {
+		facets = computeFacets(results)
+	}
+
+	// 同一源文件的章节折叠后再分页，保证各页分组一致
+	if req.Collapse > 0 && req.ParentID == "" {
+		results = collapseResults(results, req.Collapse)
+	}
+
+	// 取出当前页
+	total := len(results)
+	if req.Offset > 0 {
+		if req.Offset >= total {
+			return types.SearchPage{Results: []types.SearchResult{}, Total: total, Facets: facets, DidYouMean: se.DidYouMean(req.Query, false)}
+		}
+		results = results[req.Offset:]
+	}
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+
+	// 查询中有拼写错误（已自动纠正）或第一页结果较差时给出建议的查询
+	poor := req.Offset == 0 && (len(results) == 0 || results[0].Score < types.PoorResultScore)
+	didYouMean := se.DidYouMean(req.Query, poor)
+
+	// 只为当前页的结果提取匹配片段，查询词包括拼写纠正后的词
+	for i := range results {
+		results[i].MatchText, results[i].Table = se.extractMatchText(&results[i].Document, query)
+		if req.Snippets > 0 {
+			results[i].Snippets = se.Snippets(&results[i].Document, query, req.Snippets, req.SnippetLength)
+		}
+	}
+
+	return types.SearchPage{Results: results, Total: total, Facets: facets, DidYouMean: didYouMean}
+}
+
+// keywordSearch 关键词搜索：精确匹配、倒排索引匹配、同义词扩展和模糊匹配，返回按分数排序的全部结果
+func (se *SearchEngine) keywordSearch(req types.SearchRequest, query string) []types.SearchResult {
 	minConfidence := req.MinConfidence
 	if minConfidence <= 0 {
 		minConfidence = types.DefaultMinConfidence
 	}
 
-	// 提取查询词
-	queryWords := se.extractWords(query)
+	// 提取查询词，代码标识符（Int64、std.collection、@Derive）按代码分词规则保留完整形式
+	queryWords := mergeWords(se.extractWords(query), se.extractCodeWords(query, false))
+
+	// 索引中不存在的词按拼写纠正后的词搜索，如 hashmpa -> hashmap
+	for _, correction := range se.corrections(queryWords, false) {
+		queryWords = mergeWords(queryWords, []string{correction})
+	}
 
 	// 收集候选文档
 	candidateDocs := make(map[string]*DocumentScore)
@@ -112,11 +265,13 @@ func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
 	// 1. 精确匹配
 	for _, doc := range se.documents {
 		if se.matchesQuery(doc, query, req.Category) {
-			score := se.calculateScore(doc, query, queryWords, "exact")
+			trace := newScoreTrace(req.Explain)
+			score := se.calculateScore(doc, query, queryWords, "exact", trace)
 			candidateDocs[doc.ID] = &DocumentScore{
 				Document: doc,
 				Score:    score,
 				MatchType: "exact",
+				trace:    trace,
 			}
 		}
 	}
@@ -129,12 +284,15 @@ func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
 					if existing, exists := candidateDocs[docID]; exists {
 						// 累加分数
 						existing.Score += types.TitleMatchWeight
+						existing.trace.add("keyword", word, "index", types.TitleMatchWeight, 1)
 					} else {
-						score := se.calculateScore(doc, query, queryWords, "keyword")
+						trace := newScoreTrace(req.Explain)
+						score := se.calculateScore(doc, query, queryWords, "keyword", trace)
 						candidateDocs[docID] = &DocumentScore{
 							Document: doc,
 							Score:    score,
 							MatchType: "keyword",
+							trace:    trace,
 						}
 					}
 				}
@@ -142,16 +300,48 @@ func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
 		}
 	}
 
+	// 同义词和中英文对照扩展词，分数低于原查询词
+	for _, word := range se.ExpandQuery(query) {
+		for _, docID := range se.keywordIndex[word] {
+			doc := se.documents[docID]
+			if !se.matchesCategory(doc, req.Category) {
+				continue
+			}
+			synonymTrace := newScoreTrace(req.Explain)
+			score := se.calculateScore(doc, word, []string{word}, "keyword", synonymTrace)
+			if score == 0 {
+				synonymTrace = newScoreTrace(req.Explain)
+				score = se.calculateFuzzyScore(doc, word, []string{word}, synonymTrace)
+			}
+			score *= types.SynonymWeight
+			existing, exists := candidateDocs[docID]
+			if exists {
+				existing.Score += score
+			} else {
+				existing = &DocumentScore{
+					Document:  doc,
+					Score:     score,
+					MatchType: "synonym",
+					trace:     newScoreTrace(req.Explain),
+				}
+				candidateDocs[docID] = existing
+			}
+			existing.trace.merge(synonymTrace, "synonym", types.SynonymWeight)
+		}
+	}
+
 	// 3. 模糊匹配（如果候选文档不够）
-	if len(candidateDocs) < maxResults {
+	if len(candidateDocs) < types.FuzzyMatchThreshold {
 		for _, doc := range se.documents {
 			if _, exists := candidateDocs[doc.ID]; !exists && se.matchesCategory(doc, req.Category) {
-				score := se.calculateFuzzyScore(doc, query, queryWords)
+				trace := newScoreTrace(req.Explain)
+				score := se.calculateFuzzyScore(doc, query, queryWords, trace)
 				if score > 0 {
 					candidateDocs[doc.ID] = &DocumentScore{
 						Document: doc,
 						Score:    score,
 						MatchType: "fuzzy",
+						trace:    trace,
 					}
 				}
 			}
@@ -161,27 +351,65 @@ func (se *SearchEngine) Search(req types.SearchRequest) []types.SearchResult {
 	// 转换为结果列表并排序
 	var results []types.SearchResult
 	for _, docScore := range candidateDocs {
-		if docScore.Score >= minConfidence {
+		boosts, ok := se.scoreBoosts(docScore.Document, req)
+		if !ok {
+			continue
+		}
+		score := applyBoosts(docScore.Score, boosts)
+		if score >= minConfidence {
 			results = append(results, types.SearchResult{
-				Document:  *docScore.Document,
-				Score:     docScore.Score,
-				MatchType: docScore.MatchType,
-				MatchText: se.extractMatchText(docScore.Document, query),
+				Document:    *docScore.Document,
+				Score:       score,
+				MatchType:   docScore.MatchType,
+				Explanation: docScore.trace.explanation(docScore.Score, boosts, score, keywordNormalization),
 			})
 		}
 	}
 
 	// 按分数排序
+	sortResults(results)
+
+	return results
+}
+
+// sortResults 按分数从高到低排序，分数相同时按文档ID排序，保证同一索引上的分页结果一致
+func sortResults(results []types.SearchResult) {
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Document.ID < results[j].Document.ID
 	})
+}
 
-	// 限制结果数量
-	if len(results) > maxResults {
-		results = results[:maxResults]
+// scoreBoosts 应用来源、父文档和范围过滤，返回文档的来源优先级和范围加成，文档被过滤时返回 false
+func (se *SearchEngine) scoreBoosts(doc *types.Document, req types.SearchRequest) ([]types.ScoreBoost, bool) {
+	if !se.matchesSource(doc, req.Source) {
+		return nil, false
 	}
+	if req.ParentID != "" && ParentID(doc) != req.ParentID {
+		return nil, false
+	}
+	inScope := req.Scope != nil && req.Scope.DocIDs[doc.ID]
+	if req.Scope != nil && req.Scope.Mode == types.ScopeModeFilter && !inScope {
+		return nil, false
+	}
+	var boosts []types.ScoreBoost
+	if boost := se.priorityBoost(doc); boost != 1 {
+		boosts = append(boosts, types.ScoreBoost{Name: "priority", Factor: boost})
+	}
+	if inScope && req.Scope.Mode != types.ScopeModeFilter {
+		boosts = append(boosts, types.ScoreBoost{Name: "scope", Factor: types.ScopeBoostFactor})
+	}
+	return boosts, true
+}
 
-	return results
+// applyBoosts 把加成依次乘到分数上
+func applyBoosts(score float64, boosts []types.ScoreBoost) float64 {
+	for _, boost := range boosts {
+		score *= boost.Factor
+	}
+	return score
 }
 
 // DocumentScore 文档分数结构
@@ -189,6 +417,7 @@ type DocumentScore struct {
 	Document  *types.Document
 	Score     float64
 	MatchType string
+	trace     *scoreTrace // 分数组成，不需要解释时为 nil
 }
 
 // matchesQuery 检查文档是否匹配查询
@@ -230,8 +459,26 @@ func (se *SearchEngine) matchesCategory(doc *types.Document, category types.Docu
 	return doc.Category == category
 }
 
+// matchesSource 检查文档是否匹配来源
+func (se *SearchEngine) matchesSource(doc *types.Document, source string) bool {
+	if source == "" {
+		return true
+	}
+	return doc.Source == source
+}
+
+// priorityBoost 根据文档来源的优先级计算分数加成
+func (se *SearchEngine) priorityBoost(doc *types.Document) float64 {
+	priority := se.rootPriorities[doc.Source]
+	boost := 1 + types.RootPriorityBoost*float64(priority)
+	if boost < types.RootPriorityBoost {
+		boost = types.RootPriorityBoost
+	}
+	return boost
+}
+
 // calculateScore 计算文档分数
-func (se *SearchEngine) calculateScore(doc *types.Document, query string, queryWords []string, matchType string) float64 {
+func (se *SearchEngine) calculateScore(doc *types.Document, query string, queryWords []string, matchType string, trace *scoreTrace) float64 {
 	var score float64
 
 	lowerTitle := strings.ToLower(doc.Title)
@@ -243,13 +490,16 @@ func (se *SearchEngine) calculateScore(doc *types.Document, query string, queryW
 		// 精确匹配
 		if strings.Contains(lowerTitle, lowerQuery) {
 			score += types.ExactMatchWeight
+			trace.add(matchType, lowerQuery, "title", types.ExactMatchWeight, 1)
 		}
 		if strings.Contains(lowerDesc, lowerQuery) {
 			score += types.DescriptionWeight
+			trace.add(matchType, lowerQuery, "description", types.DescriptionWeight, 1)
 		}
 		for _, keyword := range doc.Keywords {
 			if strings.Contains(strings.ToLower(keyword), lowerQuery) {
 				score += types.ExactMatchWeight
+				trace.add(matchType, lowerQuery, "keywords", types.ExactMatchWeight, 1)
 				break
 			}
 		}
@@ -259,27 +509,37 @@ func (se *SearchEngine) calculateScore(doc *types.Document, query string, queryW
 		for _, word := range queryWords {
 			if strings.Contains(lowerTitle, word) {
 				score += types.TitleMatchWeight
+				trace.add(matchType, word, "title", types.TitleMatchWeight, 1)
 			}
 			if strings.Contains(lowerDesc, word) {
 				score += types.DescriptionWeight
+				trace.add(matchType, word, "description", types.DescriptionWeight, 1)
+			}
+			rows := matchingTableRows(doc, word)
+			score += types.TableCellMatchWeight * float64(rows)
+			trace.add(matchType, word, "table", types.TableCellMatchWeight, rows)
+			if se.codeWords[doc.ID][word] {
+				score += types.CodeMatchWeight
+				trace.add(matchType, word, "code", types.CodeMatchWeight, 1)
 			}
 		}
 
 	case "fuzzy":
 		// 模糊匹配
-		score = se.calculateFuzzyScore(doc, query, queryWords)
+		score = se.calculateFuzzyScore(doc, query, queryWords, trace)
 	}
 
 	// 文件名匹配加分
 	if strings.Contains(strings.ToLower(doc.RelativePath), lowerQuery) {
 		score += types.FilenameMatchWeight
+		trace.add(matchType, lowerQuery, "filename", types.FilenameMatchWeight, 1)
 	}
 
 	return score
 }
 
 // calculateFuzzyScore 计算模糊匹配分数
-func (se *SearchEngine) calculateFuzzyScore(doc *types.Document, query string, queryWords []string) float64 {
+func (se *SearchEngine) calculateFuzzyScore(doc *types.Document, query string, queryWords []string, trace *scoreTrace) float64 {
 	var score float64
 	content := strings.ToLower(doc.Content)
 
@@ -288,6 +548,7 @@ func (se *SearchEngine) calculateFuzzyScore(doc *types.Document, query string, q
 		count := strings.Count(content, word)
 		if count > 0 {
 			score += types.ContentMatchWeight * float64(count)
+			trace.add("fuzzy", word, "content", types.ContentMatchWeight, count)
 		}
 	}
 
@@ -295,9 +556,11 @@ func (se *SearchEngine) calculateFuzzyScore(doc *types.Document, query string, q
 	for _, word := range queryWords {
 		if strings.Contains(strings.ToLower(doc.Title), word) {
 			score += types.TitleMatchWeight * 0.5
+			trace.add("fuzzy", word, "title", types.TitleMatchWeight*0.5, 1)
 		}
 		if strings.Contains(strings.ToLower(doc.Description), word) {
 			score += types.DescriptionWeight * 0.5
+			trace.add("fuzzy", word, "description", types.DescriptionWeight*0.5, 1)
 		}
 	}
 
@@ -321,63 +584,94 @@ func (se *SearchEngine) extractWords(text string) []string {
 	return words
 }
 
+// stopWords 停用词
+var stopWords = map[string]bool{
+	"的": true, "了": true, "在": true, "是": true, "我": true, "有": true,
+	"和": true, "就": true, "不": true, "人": true, "都": true, "一": true,
+	"一个": true, "上": true, "也": true, "很": true, "到": true, "说": true,
+	"the": true, "a": true, "an": true, "and": true, "or": true, "but": true,
+	"in": true, "on": true, "at": true, "to": true, "for": true, "of": true,
+	"with": true, "by": true, "as": true, "is": true, "are": true, "was": true,
+	"were": true, "be": true, "been": true, "being": true, "have": true, "has": true,
+	"had": true, "do": true, "does": true, "did": true, "will": true, "would": true,
+	"could": true, "should": true, "may": true, "might": true, "can": true, "this": true,
+	"that": true, "these": true, "those": true, "i": true, "you": true, "he": true,
+	"she": true, "it": true, "we": true, "they": true, "what": true, "which": true,
+	"who": true, "when": true, "where": true, "why": true, "how": true, "all": true,
+	"each": true, "every": true, "both": true, "few": true, "more": true, "most": true,
+	"other": true, "some": true, "such": true, "only": true, "own": true, "same": true,
+	"so": true, "than": true, "too": true, "very": true, "just": true, "now": true,
+}
+
 // isStopWord 检查是否为停用词
 func (se *SearchEngine) isStopWord(word string) bool {
-	stopWords := map[string]bool{
-		"的": true, "了": true, "在": true, "是": true, "我": true, "有": true,
-		"和": true, "就": true, "不": true, "人": true, "都": true, "一": true,
-		"一个": true, "上": true, "也": true, "很": true, "到": true, "说": true,
-		"the": true, "a": true, "an": true, "and": true, "or": true, "but": true,
-		"in": true, "on": true, "at": true, "to": true, "for": true, "of": true,
-		"with": true, "by": true, "as": true, "is": true, "are": true, "was": true,
-		"were": true, "be": true, "been": true, "being": true, "have": true, "has": true,
-		"had": true, "do": true, "does": true, "did": true, "will": true, "would": true,
-		"could": true, "should": true, "may": true, "might": true, "can": true, "this": true,
-		"that": true, "these": true, "those": true, "i": true, "you": true, "he": true,
-		"she": true, "it": true, "we": true, "they": true, "what": true, "which": true,
-		"who": true, "when": true, "where": true, "why": true, "how": true, "all": true,
-		"each": true, "every": true, "both": true, "few": true, "more": true, "most": true,
-		"other": true, "some": true, "such": true, "only": true, "own": true, "same": true,
-		"so": true, "than": true, "too": true, "very": true, "just": true, "now": true,
-	}
-
 	return stopWords[word]
 }
 
-// extractMatchText 提取匹配文本片段
-func (se *SearchEngine) extractMatchText(doc *types.Document, query string) string {
-	content := doc.Content
-	lowerContent := strings.ToLower(content)
-	lowerQuery := strings.ToLower(query)
-
-	// 在内容中查找查询词
-	if index := strings.Index(lowerContent, lowerQuery); index != -1 {
-		start := index - 50
-		if start < 0 {
-			start = 0
-		}
-		end := index + len(query) + 50
-		if end > len(content) {
-			end = len(content)
+// extractMatchText 提取匹配文本片段：分数最高的一段，按字符和行截取
+// 匹配位于表格中时返回整行单元格（表头: 值），而不是截断的表格文本
+func (se *SearchEngine) extractMatchText(doc *types.Document, query string) (string, *types.TableRef) {
+	terms := se.snippetTerms(query)
+	lines := parseSnippetLines(doc.Content)
+	if passages := selectPassages(lines, terms, 1, types.MatchTextLength); len(passages) > 0 {
+		if text, ref := tableRowText(doc, passages[0].matchLine+1); ref != nil {
+			return text, ref
 		}
-
-		matchText := content[start:end]
-		if start > 0 {
-			matchText = "..." + matchText
-		}
-		if end < len(content) {
-			matchText = matchText + "..."
-		}
-		return matchText
+		return renderPassage(lines, terms, passages[0], types.MatchTextLength, false), nil
 	}
 
 	// 如果在内容中没找到，返回描述
 	if doc.Description != "" {
-		return doc.Description
+		return doc.Description, nil
 	}
 
 	// 否则返回标题
-	return doc.Title
+	return doc.Title, nil
+}
+
+// matchingTableRows 返回单元格包含查询词的表格行数，最多计3行
+func matchingTableRows(doc *types.Document, word string) int {
+	count := 0
+	for _, table := range doc.Tables {
+		for _, row := range table.Rows {
+			for _, cell := range row {
+				if strings.Contains(strings.ToLower(cell), word) {
+					count++
+					break
+				}
+			}
+			if count >= 3 {
+				return count
+			}
+		}
+	}
+	return count
+}
+
+// tableRowText 返回指定行所在的表格行文本，该行不在表格中时返回 nil
+func tableRowText(doc *types.Document, lineNo int) (string, *types.TableRef) {
+	for _, table := range doc.Tables {
+		// 表头行、分隔行，然后是数据行
+		row := lineNo - table.Line - 1
+		if lineNo < table.Line || row > len(table.Rows) {
+			continue
+		}
+
+		ref := &types.TableRef{Index: table.Index, Section: table.Section}
+		if row <= 0 {
+			return strings.Join(table.Headers, " | "), ref
+		}
+
+		ref.Row = row
+		var cells []string
+		for i, cell := range table.Rows[row-1] {
+			if cell != "" {
+				cells = append(cells, table.Headers[i]+": "+cell)
+			}
+		}
+		return strings.Join(cells, "; "), ref
+	}
+	return "", nil
 }
 
 // GetSuggestions 获取建议文档
@@ -0,0 +1,620 @@
+package search
+
+import (
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// 语义索引（LSA）参数，修改后需要增加 semanticModelVersion 使已保存的索引失效
+const (
+	semanticModelVersion    = 1
+	semanticDimensions      = 100   // 潜在语义空间的维数
+	semanticMaxTerms        = 20000 // 词表上限，按文档频率保留
+	semanticOversample      = 10    // 随机SVD的过采样列数
+	semanticPowerIterations = 2     // 随机SVD的幂迭代次数
+	semanticSeed            = 42    // 固定随机种子，保证同一语料得到相同的索引
+)
+
+// semanticIndex 潜在语义索引：TF-IDF 词-文档矩阵经截断SVD降维后的词基向量和文档向量
+// 整个结构保存在索引文件中，文档内容不变时启动直接加载
+type semanticIndex struct {
+	Version     int
+	Fingerprint string    // 语料指纹，文档或参数变化时重新计算
+	Dim         int       // 实际维数（小语料可能小于 semanticDimensions）
+	Terms       []string  // 词表
+	IDF         []float32 // 每个词的逆文档频率
+	Basis       []float32 // 词基向量，行优先：词 t 的向量为 Basis[t*Dim : (t+1)*Dim]
+	DocIDs      []string
+	Vectors     []float32 // 归一化的文档向量，行优先
+
+	termIndex map[string]int
+}
+
+// SetIndexFile 设置持久化索引文件的路径，为空时语义索引只保存在内存中
+func (se *SearchEngine) SetIndexFile(path string) {
+	se.indexFile = path
+}
+
+// buildSemanticIndex 加载或计算语义索引；索引文件中的语料指纹一致时直接加载，否则重新计算并保存
+// docIDs 为排好序的全部文档ID，fingerprint 为其语料指纹
+func (se *SearchEngine) buildSemanticIndex(docIDs []string, fingerprint string) error {
+	if se.indexFile != "" {
+		if index, err := loadSemanticIndex(se.indexFile); err == nil && index.Fingerprint == fingerprint {
+			se.semantic = index
+			return nil
+		}
+	}
+
+	se.semantic = se.computeSemanticIndex(docIDs)
+	se.semantic.Fingerprint = fingerprint
+	se.semantic.buildTermIndex()
+
+	if se.indexFile != "" {
+		if err := saveSemanticIndex(se.indexFile, se.semantic); err != nil {
+			return fmt.Errorf("failed to save index %s: %w", se.indexFile, err)
+		}
+	}
+	return nil
+}
+
+// semanticSearch 语义搜索：按查询与文档在潜在语义空间中的余弦相似度排序，返回按分数排序的全部结果
+// 查询的同义词和中英文对照扩展词以较低权重参与计算；结果不含匹配片段，由调用方按需提取
+func (se *SearchEngine) semanticSearch(req types.SearchRequest, query string) []types.SearchResult {
+	if se.semantic == nil {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for term, count := range se.semanticTerms(query) {
+		weights[term] += float64(count)
+	}
+	for _, word := range se.ExpandQuery(query) {
+		for term := range se.semanticTerms(word) {
+			weights[term] += types.SynonymWeight
+		}
+	}
+	vector := se.semantic.queryVector(weights)
+	if vector == nil {
+		return nil
+	}
+
+	var results []types.SearchResult
+	for j, docID := range se.semantic.DocIDs {
+		doc, exists := se.documents[docID]
+		if !exists || !se.matchesCategory(doc, req.Category) {
+			continue
+		}
+		similarity := se.semantic.similarity(vector, j)
+		if similarity < types.MinSemanticScore {
+			continue
+		}
+		boosts, ok := se.scoreBoosts(doc, req)
+		if !ok {
+			continue
+		}
+		score := applyBoosts(similarity, boosts)
+		trace := newScoreTrace(req.Explain)
+		trace.add("semantic", "", "vector", similarity, 1)
+		results = append(results, types.SearchResult{
+			Document:    *doc,
+			Score:       score,
+			MatchType:   "semantic",
+			Explanation: trace.explanation(similarity, boosts, score, semanticNormalization),
+		})
+	}
+
+	sortResults(results)
+	return results
+}
+
+// fuseRankings 用倒数排名融合（RRF）合并关键词和语义两组排序结果：分数为各组中 1/(60+排名) 之和
+// 两组都命中的文档保留关键词结果的匹配类型和片段；explain 为 true 时记录各自的排名
+func fuseRankings(keyword, semantic []types.SearchResult, explain bool) []types.SearchResult {
+	fused := make(map[string]*types.SearchResult)
+	traces := make(map[string]*scoreTrace)
+	var order []string
+	for i, ranking := range [][]types.SearchResult{keyword, semantic} {
+		source := [...]string{"keyword", "semantic"}[i]
+		for rank, result := range ranking {
+			score := 1 / float64(types.RRFRankConstant+rank+1)
+			trace := traces[result.Document.ID]
+			if existing, ok := fused[result.Document.ID]; ok {
+				existing.Score += score
+			} else {
+				result.Score = score
+				fused[result.Document.ID] = &result
+				order = append(order, result.Document.ID)
+				trace = newScoreTrace(explain)
+				traces[result.Document.ID] = trace
+			}
+			if trace != nil {
+				trace.components = append(trace.components, types.ScoreComponent{
+					Stage: "rrf", Field: source, Weight: score, Count: rank + 1, Score: score,
+				})
+			}
+		}
+	}
+
+	results := make([]types.SearchResult, 0, len(order))
+	for _, docID := range order {
+		result := *fused[docID]
+		result.Explanation = traces[docID].explanation(result.Score, nil, result.Score, hybridNormalization)
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// corpusFingerprint 根据模型参数、文档ID和内容计算语料指纹
+func corpusFingerprint(documents map[string]*types.Document, docIDs []string) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "v%d/%d/%d\n", semanticModelVersion, semanticDimensions, semanticMaxTerms)
+	for _, docID := range docIDs {
+		doc := documents[docID]
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", docID, doc.Title, doc.Description)
+		hash.Write([]byte(doc.Content))
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// semanticText 返回用于语义索引的文档文本，标题重复一次以提高权重
+func semanticText(doc *types.Document) string {
+	text := doc.Title + "\n" + doc.Title + "\n" + doc.Description + "\n"
+	for _, keyword := range doc.Keywords {
+		text += keyword + "\n"
+	}
+	return text + doc.Content
+}
+
+// semanticTerms 提取语义索引的词：英文词、代码标识符及其子词，连续汉字切成二元组（截取字符串 -> 截取、取字、字符、符串）
+func (se *SearchEngine) semanticTerms(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range se.extractWords(text) {
+		runes := []rune(word)
+		if !unicode.Is(unicode.Han, runes[0]) {
+			counts[word]++
+			continue
+		}
+		if len(runes) == 1 {
+			counts[word]++
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			if bigram := string(runes[i : i+2]); !se.isStopWord(bigram) {
+				counts[bigram]++
+			}
+		}
+	}
+	for _, word := range se.extractCodeWords(text, true) {
+		if _, exists := counts[word]; !exists {
+			counts[word]++
+		}
+	}
+	return counts
+}
+
+// computeSemanticIndex 计算语义索引
+// 词-文档矩阵用对数词频乘以IDF加权并按文档归一化，用随机SVD求前 k 个左奇异向量作为词基，
+// 文档向量为文档在词基上的投影
+func (se *SearchEngine) computeSemanticIndex(docIDs []string) *semanticIndex {
+	index := &semanticIndex{Version: semanticModelVersion, DocIDs: docIDs}
+	n := len(docIDs)
+
+	docTerms := make([]map[string]int, n)
+	df := make(map[string]int)
+	for j, docID := range docIDs {
+		docTerms[j] = se.semanticTerms(semanticText(se.documents[docID]))
+		for term := range docTerms[j] {
+			df[term]++
+		}
+	}
+
+	// 词表：大语料去掉只出现在一篇文档中的词和出现在一半以上文档中的词
+	minDF, maxDF := 1, n
+	if n >= 100 {
+		minDF, maxDF = 2, n/2
+	}
+	type termCount struct {
+		term  string
+		count int
+	}
+	var candidates []termCount
+	for term, count := range df {
+		if count >= minDF && count <= maxDF {
+			candidates = append(candidates, termCount{term, count})
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].count != candidates[b].count {
+			return candidates[a].count > candidates[b].count
+		}
+		return candidates[a].term < candidates[b].term
+	})
+	for i := 0; i < len(candidates) && i < semanticMaxTerms; i++ {
+		index.Terms = append(index.Terms, candidates[i].term)
+	}
+	index.buildTermIndex()
+	m := len(index.Terms)
+
+	index.IDF = make([]float32, m)
+	for t, term := range index.Terms {
+		index.IDF[t] = float32(math.Log(float64(1+n)/float64(1+df[term])) + 1)
+	}
+
+	// 稀疏的文档列向量
+	columns := make([]sparseVector, n)
+	for j := range docIDs {
+		weights := make(map[int]float64)
+		for term, count := range docTerms[j] {
+			if t, ok := index.termIndex[term]; ok {
+				weights[t] = (1 + math.Log(float64(count))) * float64(index.IDF[t])
+			}
+		}
+		columns[j] = newSparseVector(weights)
+	}
+
+	k := semanticDimensions
+	if k > n {
+		k = n
+	}
+	if k > m {
+		k = m
+	}
+	if k == 0 {
+		return index
+	}
+
+	basis := truncatedSVD(columns, m, k)
+	index.Dim = len(basis)
+	index.Basis = make([]float32, m*index.Dim)
+	for d, column := range basis {
+		for t, value := range column {
+			index.Basis[t*index.Dim+d] = float32(value)
+		}
+	}
+
+	index.Vectors = make([]float32, n*index.Dim)
+	for j, column := range columns {
+		copy(index.Vectors[j*index.Dim:], index.project(column))
+	}
+	return index
+}
+
+// buildTermIndex 建立词到词表序号的映射
+func (index *semanticIndex) buildTermIndex() {
+	index.termIndex = make(map[string]int, len(index.Terms))
+	for t, term := range index.Terms {
+		index.termIndex[term] = t
+	}
+}
+
+// project 把稀疏词向量投影到潜在语义空间并归一化
+func (index *semanticIndex) project(vector sparseVector) []float32 {
+	projected := make([]float64, index.Dim)
+	for i, t := range vector.indices {
+		row := index.Basis[t*index.Dim : (t+1)*index.Dim]
+		for d, value := range row {
+			projected[d] += vector.values[i] * float64(value)
+		}
+	}
+	normalize(projected)
+
+	result := make([]float32, index.Dim)
+	for d, value := range projected {
+		result[d] = float32(value)
+	}
+	return result
+}
+
+// queryVector 计算查询在潜在语义空间中的向量，weights 为查询词及其权重；没有已知词时返回 nil
+func (index *semanticIndex) queryVector(weights map[string]float64) []float32 {
+	known := make(map[int]float64)
+	for term, weight := range weights {
+		if t, ok := index.termIndex[term]; ok {
+			known[t] += weight * float64(index.IDF[t])
+		}
+	}
+	if len(known) == 0 || index.Dim == 0 {
+		return nil
+	}
+	return index.project(newSparseVector(known))
+}
+
+// similarity 返回查询向量与第 j 篇文档的余弦相似度
+func (index *semanticIndex) similarity(query []float32, j int) float64 {
+	var dot float64
+	for d, value := range index.Vectors[j*index.Dim : (j+1)*index.Dim] {
+		dot += float64(value) * float64(query[d])
+	}
+	return dot
+}
+
+// loadSemanticIndex 从文件加载语义索引
+func loadSemanticIndex(path string) (*semanticIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var index semanticIndex
+	if err := gob.NewDecoder(file).Decode(&index); err != nil {
+		return nil, err
+	}
+	if index.Version != semanticModelVersion {
+		return nil, fmt.Errorf("index version %d, want %d", index.Version, semanticModelVersion)
+	}
+	index.buildTermIndex()
+	return &index, nil
+}
+
+// saveSemanticIndex 保存语义索引，先写入同一目录下的临时文件再重命名，避免中断时留下损坏的索引
+// 临时文件名随机生成，多个进程同时保存时不会写入同一个临时文件
+func saveSemanticIndex(path string, index *semanticIndex) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+
+	// CreateTemp 创建的文件只有所有者可读写，改为与普通文件相同的权限
+	err = file.Chmod(0644)
+	if err == nil {
+		err = gob.NewEncoder(file).Encode(index)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// sparseVector 归一化的稀疏向量
+type sparseVector struct {
+	indices []int
+	values  []float64
+}
+
+// newSparseVector 由权重创建按序号排序的归一化稀疏向量
+func newSparseVector(weights map[int]float64) sparseVector {
+	vector := sparseVector{indices: make([]int, 0, len(weights))}
+	for t := range weights {
+		vector.indices = append(vector.indices, t)
+	}
+	sort.Ints(vector.indices)
+
+	var norm float64
+	vector.values = make([]float64, len(vector.indices))
+	for i, t := range vector.indices {
+		vector.values[i] = weights[t]
+		norm += weights[t] * weights[t]
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector.values {
+			vector.values[i] /= norm
+		}
+	}
+	return vector
+}
+
+// truncatedSVD 用随机算法（Halko 等）求 m×n 稀疏矩阵的前 k 个左奇异向量
+// 矩阵按列（文档）给出，返回 k 个长度为 m 的向量，按奇异值从大到小排列
+func truncatedSVD(columns []sparseVector, m, k int) [][]float64 {
+	n := len(columns)
+	l := k + semanticOversample
+	if l > n {
+		l = n
+	}
+	if l > m {
+		l = m
+	}
+
+	// Y = A Ω
+	random := rand.New(rand.NewSource(semanticSeed))
+	omega := make([][]float64, l)
+	for c := range omega {
+		omega[c] = make([]float64, n)
+		for j := range omega[c] {
+			omega[c][j] = random.NormFloat64()
+		}
+	}
+	q := orthonormalize(multiplyA(columns, omega, m))
+
+	// 幂迭代提高小奇异值之间的区分度：Q = orth(A Aᵀ Q)
+	for i := 0; i < semanticPowerIterations; i++ {
+		z := orthonormalize(multiplyAT(columns, q))
+		q = orthonormalize(multiplyA(columns, z, m))
+	}
+
+	// B = Qᵀ A（l×n），对 B Bᵀ 做特征分解得到 B 的左奇异向量 W，A 的左奇异向量为 Q W
+	b := multiplyAT(columns, q)
+	size := len(q)
+	gram := make([][]float64, size)
+	for i := range gram {
+		gram[i] = make([]float64, size)
+		for j := 0; j <= i; j++ {
+			gram[i][j] = dot(b[i], b[j])
+			gram[j][i] = gram[i][j]
+		}
+	}
+	eigenvalues, eigenvectors := symmetricEigen(gram)
+
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return eigenvalues[order[a]] > eigenvalues[order[b]] })
+
+	var basis [][]float64
+	for _, e := range order {
+		if len(basis) == k || eigenvalues[e] <= 1e-10 {
+			break
+		}
+		u := make([]float64, m)
+		for i := 0; i < size; i++ {
+			w := eigenvectors[i][e]
+			for t, value := range q[i] {
+				u[t] += w * value
+			}
+		}
+		basis = append(basis, u)
+	}
+	return basis
+}
+
+// multiplyA 计算 A X，X 以 l 个长度为 n 的向量给出，结果为 l 个长度为 m 的向量
+func multiplyA(columns []sparseVector, x [][]float64, m int) [][]float64 {
+	result := make([][]float64, len(x))
+	for c := range x {
+		result[c] = make([]float64, m)
+		for j, column := range columns {
+			scale := x[c][j]
+			if scale == 0 {
+				continue
+			}
+			for i, t := range column.indices {
+				result[c][t] += column.values[i] * scale
+			}
+		}
+	}
+	return result
+}
+
+// multiplyAT 计算 Aᵀ Y，Y 以若干长度为 m 的向量给出，结果为同样数量的长度为 n 的向量
+func multiplyAT(columns []sparseVector, y [][]float64) [][]float64 {
+	result := make([][]float64, len(y))
+	for c := range y {
+		result[c] = make([]float64, len(columns))
+		for j, column := range columns {
+			var sum float64
+			for i, t := range column.indices {
+				sum += column.values[i] * y[c][t]
+			}
+			result[c][j] = sum
+		}
+	}
+	return result
+}
+
+// orthonormalize 用修正的 Gram-Schmidt 正交化一组向量，丢弃线性相关的向量
+func orthonormalize(vectors [][]float64) [][]float64 {
+	var basis [][]float64
+	for _, v := range vectors {
+		for _, b := range basis {
+			projection := dot(v, b)
+			for i := range v {
+				v[i] -= projection * b[i]
+			}
+		}
+		if normalize(v) > 1e-10 {
+			basis = append(basis, v)
+		}
+	}
+	return basis
+}
+
+// symmetricEigen 用循环 Jacobi 方法求对称矩阵的特征值和特征向量（特征向量为结果矩阵的列）
+func symmetricEigen(matrix [][]float64) ([]float64, [][]float64) {
+	size := len(matrix)
+	a := make([][]float64, size)
+	v := make([][]float64, size)
+	for i := range a {
+		a[i] = append([]float64(nil), matrix[i]...)
+		v[i] = make([]float64, size)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		var offDiagonal float64
+		for i := 0; i < size; i++ {
+			for j := i + 1; j < size; j++ {
+				offDiagonal += a[i][j] * a[i][j]
+			}
+		}
+		if offDiagonal < 1e-20 {
+			break
+		}
+
+		for p := 0; p < size; p++ {
+			for q := p + 1; q < size; q++ {
+				if math.Abs(a[p][q]) < 1e-15 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < size; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < size; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < size; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	eigenvalues := make([]float64, size)
+	for i := range eigenvalues {
+		eigenvalues[i] = a[i][i]
+	}
+	return eigenvalues, v
+}
+
+// dot 计算两个向量的内积
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// normalize 把向量归一化为单位长度，返回原长度
+func normalize(v []float64) float64 {
+	norm := math.Sqrt(dot(v, v))
+	if norm > 0 {
+		for i := range v {
+			v[i] /= norm
+		}
+	}
+	return norm
+}
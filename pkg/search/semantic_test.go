@@ -0,0 +1,232 @@
+package search
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+
+	"cangje-docs-mcp/pkg/scanner"
+	"cangje-docs-mcp/pkg/types"
+)
+
+const semanticTolerance = 1e-9
+
+func TestSymmetricEigen(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix [][]float64
+		values []float64 // 升序
+	}{
+		{"diagonal", [][]float64{{3, 0, 0}, {0, 1, 0}, {0, 0, 2}}, []float64{1, 2, 3}},
+		{"2x2", [][]float64{{2, 1}, {1, 2}}, []float64{1, 3}},
+		{"tridiagonal", [][]float64{{2, -1, 0}, {-1, 2, -1}, {0, -1, 2}}, []float64{2 - math.Sqrt2, 2, 2 + math.Sqrt2}},
+		{"1x1", [][]float64{{5}}, []float64{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := make([][]float64, len(tt.matrix))
+			for i, row := range tt.matrix {
+				input[i] = append([]float64(nil), row...)
+			}
+			values, vectors := symmetricEigen(tt.matrix)
+			size := len(tt.matrix)
+			if !reflect.DeepEqual(tt.matrix, input) {
+				t.Errorf("input matrix modified: %v", tt.matrix)
+			}
+
+			sorted := append([]float64(nil), values...)
+			sort.Float64s(sorted)
+			for i := range sorted {
+				if math.Abs(sorted[i]-tt.values[i]) > semanticTolerance {
+					t.Errorf("eigenvalues = %v, want %v", sorted, tt.values)
+					break
+				}
+			}
+
+			// 特征向量（列）单位正交，且满足 A v = λ v
+			for e := 0; e < size; e++ {
+				column := make([]float64, size)
+				for i := range column {
+					column[i] = vectors[i][e]
+				}
+				for f := 0; f < size; f++ {
+					other := make([]float64, size)
+					for i := range other {
+						other[i] = vectors[i][f]
+					}
+					want := 0.0
+					if e == f {
+						want = 1
+					}
+					if got := dot(column, other); math.Abs(got-want) > semanticTolerance {
+						t.Errorf("v%d·v%d = %g, want %g", e, f, got, want)
+					}
+				}
+				for i := 0; i < size; i++ {
+					if got := dot(tt.matrix[i], column); math.Abs(got-values[e]*column[i]) > semanticTolerance {
+						t.Errorf("(A v%d)[%d] = %g, want %g", e, i, got, values[e]*column[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+// randomColumns 生成 n 列、每列有若干非零元的随机稀疏矩阵
+func randomColumns(random *rand.Rand, m, n, nonZero int) []sparseVector {
+	columns := make([]sparseVector, n)
+	for j := range columns {
+		weights := make(map[int]float64)
+		for len(weights) < nonZero {
+			weights[random.Intn(m)] = random.Float64() + 0.1
+		}
+		columns[j] = newSparseVector(weights)
+	}
+	return columns
+}
+
+func TestTruncatedSVDOrthonormal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	m, n, k := 60, 40, 10
+	columns := randomColumns(random, m, n, 6)
+
+	basis := truncatedSVD(columns, m, k)
+	if len(basis) != k {
+		t.Fatalf("truncatedSVD returned %d vectors, want %d", len(basis), k)
+	}
+	for i, u := range basis {
+		if len(u) != m {
+			t.Fatalf("vector %d has length %d, want %d", i, len(u), m)
+		}
+		for j, w := range basis {
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if got := dot(u, w); math.Abs(got-want) > 1e-8 {
+				t.Errorf("u%d·u%d = %g, want %g", i, j, got, want)
+			}
+		}
+	}
+
+	// 按奇异值 ‖Aᵀu‖ 从大到小排列
+	singular := make([]float64, len(basis))
+	for i, projection := range multiplyAT(columns, basis) {
+		singular[i] = math.Sqrt(dot(projection, projection))
+	}
+	for i := 1; i < len(singular); i++ {
+		if singular[i] > singular[i-1]+1e-8 {
+			t.Errorf("singular values not descending: %v", singular)
+			break
+		}
+	}
+}
+
+func TestTruncatedSVDLowRank(t *testing.T) {
+	// 只有两个不同的列方向，秩为2，请求更多维时只返回2个向量
+	a := newSparseVector(map[int]float64{0: 1, 1: 2})
+	b := newSparseVector(map[int]float64{2: 1, 3: 1, 4: 1})
+	columns := []sparseVector{a, b, a, b, a}
+
+	basis := truncatedSVD(columns, 5, 4)
+	if len(basis) != 2 {
+		t.Fatalf("truncatedSVD returned %d vectors for a rank-2 matrix, want 2", len(basis))
+	}
+	// 第一个向量对应出现三次的列方向
+	if got := math.Abs(dot(basis[0], []float64{1 / math.Sqrt(5), 2 / math.Sqrt(5), 0, 0, 0})); math.Abs(got-1) > 1e-8 {
+		t.Errorf("|u0·a| = %g, want 1", got)
+	}
+}
+
+// loadFixtureDocuments 扫描测试语料
+func loadFixtureDocuments(t *testing.T) map[string]*types.Document {
+	t.Helper()
+	documents, err := scanner.NewScanner(relevanceCorpus).ScanAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return documents
+}
+
+func TestSemanticIndexSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	indexFile := filepath.Join(dir, "cache", "index.gob")
+
+	documents := loadFixtureDocuments(t)
+	se := NewSearchEngine()
+	se.SetIndexFile(indexFile)
+	se.BuildIndex(documents)
+	if se.semantic.Dim == 0 {
+		t.Fatal("semantic index has no dimensions")
+	}
+
+	loaded, err := loadSemanticIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, se.semantic) {
+		t.Error("loaded index differs from the saved index")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("index directory has %d entries, want only the index file", len(entries))
+	}
+	if info, err := os.Stat(indexFile); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0044 == 0 {
+		t.Errorf("index file mode = %v, want readable by others", info.Mode().Perm())
+	}
+
+	// 语料指纹一致时直接加载索引文件：篡改保存的索引，新的搜索引擎应得到篡改后的内容
+	loaded.Terms = append([]string{"篡改标记"}, loaded.Terms[1:]...)
+	if err := saveSemanticIndex(indexFile, loaded); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewSearchEngine()
+	reloaded.SetIndexFile(indexFile)
+	reloaded.BuildIndex(loadFixtureDocuments(t))
+	if reloaded.semantic.Terms[0] != "篡改标记" {
+		t.Error("index with matching fingerprint was recomputed instead of loaded")
+	}
+
+	// 文档内容变化后指纹改变，重新计算并覆盖索引文件
+	changed := loadFixtureDocuments(t)
+	for _, doc := range changed {
+		doc.Content += "\n新增内容"
+		break
+	}
+	rebuilt := NewSearchEngine()
+	rebuilt.SetIndexFile(indexFile)
+	rebuilt.BuildIndex(changed)
+	if rebuilt.Snapshot() == se.Snapshot() {
+		t.Fatal("fingerprint did not change after document content changed")
+	}
+	if rebuilt.semantic.Terms[0] == "篡改标记" {
+		t.Error("stale index was loaded after the corpus changed")
+	}
+	saved, err := loadSemanticIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Fingerprint != rebuilt.semantic.Fingerprint {
+		t.Errorf("saved fingerprint = %s, want %s", saved.Fingerprint, rebuilt.semantic.Fingerprint)
+	}
+}
+
+func TestLoadSemanticIndexVersion(t *testing.T) {
+	indexFile := filepath.Join(t.TempDir(), "index.gob")
+	if err := saveSemanticIndex(indexFile, &semanticIndex{Version: semanticModelVersion + 1, Fingerprint: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSemanticIndex(indexFile); err == nil {
+		t.Error("loadSemanticIndex accepted an index with a different model version")
+	}
+}
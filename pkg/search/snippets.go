@@ -0,0 +1,290 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
+)
+
+// 片段中命中词的标记
+const snippetMark = "**"
+
+// snippetTerm 用于定位片段的查询词，扩展词的权重低于原查询词
+type snippetTerm struct {
+	runes  []rune
+	weight float64
+}
+
+// snippetLine 文档中的一行，记录所在章节的标题路径
+type snippetLine struct {
+	runes    []rune
+	lower    []rune
+	headings []string
+	block    int  // 所属段落的序号，空行、标题和代码块边界分隔段落
+	skip     bool // 空行、标题行或代码块标记行，不作为片段内容
+}
+
+// termMatch 一行中命中查询词的位置（按字符计）
+type termMatch struct {
+	start, end int
+	term       int
+}
+
+// passage 候选片段：同一段落中连续的若干行
+type passage struct {
+	first, last int // 起止行（下标）
+	matchLine   int // 第一个命中词所在的行
+	score       float64
+}
+
+// Snippets 按查询词为文档打分选出最相关的 n 个片段，每个片段不超过 length 个字符、不跨段落和章节，
+// 命中的词用 ** 标记，并给出片段所在章节的标题路径；length 不大于 0 时使用 DefaultSnippetLength
+func (se *SearchEngine) Snippets(doc *types.Document, query string, n, length int) []types.Snippet {
+	if length <= 0 {
+		length = types.DefaultSnippetLength
+	}
+	terms := se.snippetTerms(query)
+	lines := parseSnippetLines(doc.Content)
+	passages := selectPassages(lines, terms, n, length)
+
+	snippets := make([]types.Snippet, 0, len(passages))
+	for _, p := range passages {
+		snippets = append(snippets, types.Snippet{
+			Text:     renderPassage(lines, terms, p, length, true),
+			Headings: lines[p.first].headings,
+			Line:     p.first + 1,
+			Score:    math.Round(p.score*100) / 100,
+		})
+	}
+	return snippets
+}
+
+// snippetTerms 提取查询词：普通词、代码标识符、拼写纠正后的词、连续汉字中切出的词表词，以及权重减半的同义词扩展词
+func (se *SearchEngine) snippetTerms(query string) []snippetTerm {
+	query = strings.ToLower(query)
+	words := mergeWords(se.extractWords(query), se.extractCodeWords(query, false))
+	for _, correction := range se.corrections(words, false) {
+		words = mergeWords(words, []string{correction})
+	}
+	for _, word := range words {
+		if isHanWord(word) {
+			words = mergeWords(words, se.segmentHan(word))
+		}
+	}
+
+	var terms []snippetTerm
+	for _, word := range words {
+		terms = append(terms, snippetTerm{runes: []rune(word), weight: 1})
+	}
+	for _, word := range se.ExpandQuery(query) {
+		terms = append(terms, snippetTerm{runes: []rune(word), weight: types.SynonymWeight})
+	}
+	// 长词优先匹配，避免 hashmap 中再标出 map
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i].runes) > len(terms[j].runes) })
+	return terms
+}
+
+// parseSnippetLines 按行拆分文档，记录每行的标题路径和段落
+func parseSnippetLines(content string) []snippetLine {
+	var lines []snippetLine
+	var headings []string
+	var levels []int
+	block, inCode := 0, false
+	for _, text := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(text)
+		line := snippetLine{runes: []rune(text)}
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+			line.skip = true
+			block++
+		case inCode:
+		case trimmed == "":
+			line.skip = true
+			block++
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+				headings = headings[:len(headings)-1]
+			}
+			levels = append(levels, level)
+			headings = append(append([]string(nil), headings...), strings.TrimSpace(trimmed[level:]))
+			line.skip = true
+			block++
+		}
+		line.headings = headings
+		line.block = block
+		line.lower = lowerRunes(line.runes)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lowerRunes 逐个字符转小写，保持与原文相同的字符位置
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// findTerms 返回一行中命中查询词的位置，不重叠，按位置排序
+func findTerms(lower []rune, terms []snippetTerm) []termMatch {
+	var matches []termMatch
+	covered := make([]bool, len(lower))
+	for t, term := range terms {
+		size := len(term.runes)
+		for i := 0; i+size <= len(lower); i++ {
+			if covered[i] || !runesEqual(lower[i:i+size], term.runes) {
+				continue
+			}
+			overlap := false
+			for k := i; k < i+size; k++ {
+				overlap = overlap || covered[k]
+			}
+			if overlap {
+				continue
+			}
+			for k := i; k < i+size; k++ {
+				covered[k] = true
+			}
+			matches = append(matches, termMatch{start: i, end: i + size, term: t})
+			i += size - 1
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// selectPassages 以每个命中行为中心在同一段落内向前后扩展成候选片段，按分数选出不重叠的 n 个
+// 分数：命中的不同查询词的权重之和，重复命中按对数递增，章节标题中出现的查询词额外加分
+func selectPassages(lines []snippetLine, terms []snippetTerm, n, length int) []passage {
+	if n <= 0 || len(terms) == 0 {
+		return nil
+	}
+	lineMatches := make([][]termMatch, len(lines))
+	for i := range lines {
+		if !lines[i].skip {
+			lineMatches[i] = findTerms(lines[i].lower, terms)
+		}
+	}
+
+	var candidates []passage
+	for i := range lines {
+		if len(lineMatches[i]) == 0 {
+			continue
+		}
+		p := passage{first: i, last: i, matchLine: i}
+		size := len(lines[i].runes)
+		for grown := true; grown; {
+			grown = false
+			if next := p.last + 1; next < len(lines) && sameBlock(lines, i, next) && size+1+len(lines[next].runes) <= length {
+				p.last = next
+				size += 1 + len(lines[next].runes)
+				grown = true
+			}
+			if prev := p.first - 1; prev >= 0 && sameBlock(lines, i, prev) && size+1+len(lines[prev].runes) <= length {
+				p.first = prev
+				size += 1 + len(lines[prev].runes)
+				grown = true
+			}
+		}
+		p.score = scorePassage(lines, lineMatches, terms, p)
+		candidates = append(candidates, p)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	var selected []passage
+	for _, candidate := range candidates {
+		overlap := false
+		for _, p := range selected {
+			overlap = overlap || (candidate.first <= p.last && p.first <= candidate.last)
+		}
+		if !overlap {
+			selected = append(selected, candidate)
+			if len(selected) == n {
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// sameBlock 判断两行是否属于同一段落
+func sameBlock(lines []snippetLine, a, b int) bool {
+	return !lines[b].skip && lines[a].block == lines[b].block
+}
+
+// scorePassage 计算候选片段的分数
+func scorePassage(lines []snippetLine, lineMatches [][]termMatch, terms []snippetTerm, p passage) float64 {
+	counts := make(map[int]int)
+	for i := p.first; i <= p.last; i++ {
+		for _, match := range lineMatches[i] {
+			counts[match.term]++
+		}
+	}
+	var score float64
+	for term, count := range counts {
+		score += terms[term].weight * (1 + math.Log(float64(count)))
+	}
+	heading := strings.ToLower(strings.Join(lines[p.first].headings, " "))
+	for term := range terms {
+		if strings.Contains(heading, string(terms[term].runes)) {
+			score += 0.5 * terms[term].weight
+		}
+	}
+	return score
+}
+
+// renderPassage 生成片段文本，mark 为 true 时标记命中的词；单行超过长度时在该行内截取命中词附近的文字，截取后的行（包括省略号）不超过长度
+func renderPassage(lines []snippetLine, terms []snippetTerm, p passage, length int, mark bool) string {
+	var parts []string
+	for i := p.first; i <= p.last; i++ {
+		text := string(lines[i].runes)
+		if size := len(lines[i].runes); size > length {
+			start := 0
+			if matches := findTerms(lines[i].lower, terms); len(matches) > 0 {
+				start = matches[0].start - length/3
+			}
+			// 省略号也计入长度，截取位置不会切开代码片段和链接
+			if start = max(0, min(start, size-length)); start > 0 {
+				text = utils.Ellipsis + utils.SkipRunes(text, start+len(utils.Ellipsis))
+			}
+			text = utils.TruncateRunes(text, length)
+		}
+		if mark {
+			runes := []rune(text)
+			text = markTerms(runes, findTerms(lowerRunes(runes), terms))
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// markTerms 用 ** 包围命中的词
+func markTerms(runes []rune, matches []termMatch) string {
+	var builder strings.Builder
+	last := 0
+	for _, match := range matches {
+		builder.WriteString(string(runes[last:match.start]))
+		builder.WriteString(snippetMark + string(runes[match.start:match.end]) + snippetMark)
+		last = match.end
+	}
+	builder.WriteString(string(runes[last:]))
+	return builder.String()
+}
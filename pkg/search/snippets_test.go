@@ -0,0 +1,174 @@
+package search
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"cangje-docs-mcp/pkg/types"
+	"cangje-docs-mcp/pkg/utils"
+)
+
+// snippetsOf 为内容生成片段
+func snippetsOf(content, query string, n, length int) []types.Snippet {
+	return NewSearchEngine().Snippets(&types.Document{ID: "doc", Content: content}, query, n, length)
+}
+
+func TestSnippetsCropLongLine(t *testing.T) {
+	filler := strings.Repeat("仓颉 ", 100)
+	tests := []struct {
+		name    string
+		line    string
+		leading bool // 是否在开头截取
+	}{
+		{"match in the middle", filler + "使用 HashMap 存储键值对 " + filler, true},
+		{"match at the start", "HashMap 存储键值对 " + filler, false},
+		{"match at the end", filler + "最后是 HashMap", true},
+	}
+	for _, tt := range tests {
+		snippets := snippetsOf("# 标题\n\n"+tt.line+"\n", "hashmap", 1, 60)
+		if len(snippets) != 1 {
+			t.Fatalf("%s: got %d snippets", tt.name, len(snippets))
+		}
+		text := snippets[0].Text
+		if !strings.Contains(text, "**HashMap**") {
+			t.Errorf("%s: %q does not contain the marked match", tt.name, text)
+		}
+		plain := strings.ReplaceAll(text, snippetMark, "")
+		if size := utf8.RuneCountInString(plain); size > 60 {
+			t.Errorf("%s: %d characters, want at most 60: %q", tt.name, size, plain)
+		}
+		if strings.HasPrefix(plain, utils.Ellipsis) != tt.leading {
+			t.Errorf("%s: leading ellipsis = %v, want %v: %q", tt.name, !tt.leading, tt.leading, plain)
+		}
+		if snippets[0].Line != 3 || len(snippets[0].Headings) != 1 || snippets[0].Headings[0] != "标题" {
+			t.Errorf("%s: line %d headings %q, want line 3 under 标题", tt.name, snippets[0].Line, snippets[0].Headings)
+		}
+	}
+}
+
+func TestSnippetsStayInBlock(t *testing.T) {
+	content := strings.Join([]string{
+		"# 并发",                // 1
+		"## 创建线程",             // 2
+		"使用 spawn 创建线程。",      // 3
+		"线程结束后返回结果。",          // 4
+		"## 同步",               // 5
+		"spawn 的线程之间需要同步。",    // 6
+		"```cangjie",          // 7
+		"let f = spawn { 1 }", // 8
+		"```",                 // 9
+		"调用 spawn 后立即返回。",     // 10
+	}, "\n")
+
+	snippets := snippetsOf(content, "spawn", 5, 500)
+	want := []struct {
+		line     int
+		text     string
+		headings string
+	}{
+		{3, "使用 **spawn** 创建**线程**。\n**线程**结束后返回结果。", "并发/创建线程"},
+		{6, "**spawn** 的**线程**之间需要同步。", "并发/同步"},
+		{8, "let f = **spawn** { 1 }", "并发/同步"},
+		{10, "调用 **spawn** 后立即返回。", "并发/同步"},
+	}
+	if len(snippets) != len(want) {
+		t.Fatalf("got %d snippets, want %d: %+v", len(snippets), len(want), snippets)
+	}
+	found := make(map[int]types.Snippet)
+	for _, snippet := range snippets {
+		found[snippet.Line] = snippet
+		if strings.Contains(snippet.Text, "#") || strings.Contains(snippet.Text, "```") {
+			t.Errorf("snippet at line %d crosses a heading or code fence: %q", snippet.Line, snippet.Text)
+		}
+	}
+	for _, w := range want {
+		snippet, ok := found[w.line]
+		if !ok {
+			t.Errorf("no snippet at line %d", w.line)
+			continue
+		}
+		if snippet.Text != w.text || strings.Join(snippet.Headings, "/") != w.headings {
+			t.Errorf("line %d: %q under %q, want %q under %q", w.line, snippet.Text, strings.Join(snippet.Headings, "/"), w.text, w.headings)
+		}
+	}
+}
+
+func TestSnippetsNoOverlap(t *testing.T) {
+	var lines []string
+	for i := 0; i < 12; i++ {
+		if i%3 == 0 {
+			lines = append(lines, "ArrayList 可以动态增长，第"+strings.Repeat("行", i+1))
+		} else {
+			lines = append(lines, "这一行只是说明文字")
+		}
+	}
+	content := strings.Join(lines, "\n")
+
+	// 片段越长，与已选片段重叠而被跳过的候选越多，整段放得下时只有一个片段
+	for _, tt := range []struct{ length, want int }{{30, 3}, {60, 2}, {1000, 1}} {
+		length := tt.length
+		snippets := snippetsOf(content, "arraylist", 3, length)
+		if len(snippets) != tt.want {
+			t.Fatalf("length %d: got %d snippets, want %d", length, len(snippets), tt.want)
+		}
+		covered := make(map[int]bool)
+		for _, snippet := range snippets {
+			size := strings.Count(snippet.Text, "\n") + 1
+			for line := snippet.Line; line < snippet.Line+size; line++ {
+				if covered[line] {
+					t.Errorf("length %d: line %d is in more than one snippet", length, line)
+				}
+				covered[line] = true
+			}
+			if plain := strings.ReplaceAll(snippet.Text, snippetMark, ""); utf8.RuneCountInString(plain) > length {
+				t.Errorf("length %d: snippet at line %d has %d characters", length, snippet.Line, utf8.RuneCountInString(plain))
+			}
+			if !strings.Contains(snippet.Text, "**ArrayList**") {
+				t.Errorf("length %d: snippet at line %d has no match: %q", length, snippet.Line, snippet.Text)
+			}
+		}
+		for i := 1; i < len(snippets); i++ {
+			if snippets[i].Score > snippets[i-1].Score {
+				t.Errorf("length %d: snippets are not sorted by score", length)
+			}
+		}
+	}
+}
+
+func TestSnippetsCJKQuery(t *testing.T) {
+	content := "# 文本\n\n仓颉的字符串是不可变的。\n\n可以按分隔符把字符串分割成数组。\n\n使用 split 函数。\n\n数组的长度固定。\n"
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// 整个查询词优先标记，连续汉字中切出的词表词也能命中，英文对照词 split 权重较低
+		{"字符串分割", []string{"仓颉的**字符串**是不可变的。", "可以按分隔符把**字符串分割**成数组。", "使用 **split** 函数。"}},
+		// 中文查询通过中英文对照命中英文词
+		{"分割", []string{"可以按分隔符把字符串**分割**成数组。", "使用 **split** 函数。"}},
+		// 英文查询通过中英文对照命中中文词
+		{"string", []string{"仓颉的**字符串**是不可变的。", "可以按分隔符把**字符串**分割成数组。"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, snippet := range snippetsOf(content, tt.query, 5, 100) {
+			got = append(got, snippet.Text)
+			if snippet.Line%2 != 1 || len(snippet.Headings) != 1 || snippet.Headings[0] != "文本" {
+				t.Errorf("%q: snippet at line %d under %q", tt.query, snippet.Line, snippet.Headings)
+			}
+		}
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: snippets %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	// 命中原查询词的片段排在只命中扩展词的片段之前
+	snippets := snippetsOf(content, "分割", 2, 100)
+	if len(snippets) != 2 || !strings.Contains(snippets[0].Text, "**分割**") || snippets[0].Score <= snippets[1].Score {
+		t.Errorf("snippets for 分割 = %+v, want the direct match first", snippets)
+	}
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// 拼写纠正只处理至少4个字符的英文词（可带数字），更短的词编辑1次就可能变成另一个常见词
+	minCorrectableLength = 4
+	// 原词存在于索引中时，候选词的文档数至少是原词的多少倍才会作为建议
+	spellingFrequencyRatio = 5
+)
+
+// correctableWord 匹配可以纠正拼写的查询词
+var correctableWord = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// buildVocabulary 从关键词索引收集可用于拼写纠正的英文词表
+func (se *SearchEngine) buildVocabulary() {
+	se.vocabulary = nil
+	for word := range se.keywordIndex {
+		if len(word) >= minCorrectableLength-1 && correctableWord.MatchString(word) {
+			se.vocabulary = append(se.vocabulary, word)
+		}
+	}
+	sort.Strings(se.vocabulary)
+}
+
+// corrections 返回查询词的拼写纠正（原词 -> 纠正后的词）
+// 索引中不存在的词总是尝试纠正；poor 为 true（结果很差）时，出现次数很少的词也会纠正为常见得多的相近词
+func (se *SearchEngine) corrections(words []string, poor bool) map[string]string {
+	corrected := make(map[string]string)
+	for _, word := range words {
+		if len(word) < minCorrectableLength || !correctableWord.MatchString(word) {
+			continue
+		}
+		df := len(se.keywordIndex[word])
+		if df > 0 && !poor {
+			continue
+		}
+		if correction := se.closestTerm(word, df); correction != "" {
+			corrected[word] = correction
+		}
+	}
+	return corrected
+}
+
+// closestTerm 在词表中查找编辑距离最小的词，距离相同时选择出现在更多文档中的词
+// df 为原词的文档数，原词存在时候选词的文档数至少要是它的 spellingFrequencyRatio 倍
+func (se *SearchEngine) closestTerm(word string, df int) string {
+	maxDistance := editThreshold(word)
+	length := len(word)
+
+	best := ""
+	bestDistance, bestDF := maxDistance+1, 0
+	for _, candidate := range se.vocabulary {
+		if diff := len(candidate) - length; diff > maxDistance || -diff > maxDistance {
+			continue
+		}
+		candidateDF := len(se.keywordIndex[candidate])
+		if candidateDF < df*spellingFrequencyRatio {
+			continue
+		}
+		d := editDistance(word, candidate)
+		if d == 0 || d > bestDistance || d == bestDistance && candidateDF <= bestDF {
+			continue
+		}
+		best, bestDistance, bestDF = candidate, d, candidateDF
+	}
+	return best
+}
+
+// DidYouMean 返回纠正拼写后的查询，没有可纠正的词时返回空字符串
+// poor 表示原查询的结果很差，此时出现次数很少的词也会给出建议
+func (se *SearchEngine) DidYouMean(query string, poor bool) string {
+	words := mergeWords(se.extractWords(strings.ToLower(query)), se.extractCodeWords(strings.ToLower(query), false))
+	corrected := se.corrections(words, poor)
+	if len(corrected) == 0 {
+		return ""
+	}
+
+	suggestion := query
+	for word, correction := range corrected {
+		// 纠正为API符号时使用符号的原始大小写，如 hashmpa -> HashMap
+		if symbols := se.LookupSymbol(correction); len(symbols) > 0 {
+			correction = symbols[0].Name
+		}
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+		suggestion = pattern.ReplaceAllLiteralString(suggestion, correction)
+	}
+	return suggestion
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestCorrections(t *testing.T) {
+	se, _ := loadRelevanceFixture(t)
+	tests := []struct {
+		name  string
+		words []string
+		want  map[string]string
+	}{
+		{"misspelled API name", []string{"hashmpa"}, map[string]string{"hashmpa": "hashmap"}},
+		{"transposed letters", []string{"arrylist", "mutx"}, map[string]string{"arrylist": "arraylist", "mutx": "mutex"}},
+		{"correct words unchanged", []string{"hashmap", "arraylist", "mutex"}, map[string]string{}},
+		{"short words skipped", []string{"lst", "mpa", "fs"}, map[string]string{}},
+		{"non-ascii words skipped", []string{"遍历", "c++"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := se.corrections(tt.words, false); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("corrections(%q) = %v, want %v", tt.words, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	se, _ := loadRelevanceFixture(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"hashmpa", "HashMap"},
+		{"HashMpa 遍历", "HashMap 遍历"},
+		{"concurency", "concurrency"},
+		{"arraylist", ""},
+		{"ArrayList 遍历", ""},
+		{"lst", ""},
+	}
+	for _, tt := range tests {
+		if got := se.DidYouMean(tt.query, false); got != tt.want {
+			t.Errorf("DidYouMean(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPageDidYouMean(t *testing.T) {
+	se, _ := loadRelevanceFixture(t)
+
+	// 拼错的词按纠正后的词搜索，并返回建议的查询
+	page := se.SearchPage(types.SearchRequest{Query: "hashmpa"})
+	if page.DidYouMean != "HashMap" {
+		t.Errorf("did_you_mean = %q, want HashMap", page.DidYouMean)
+	}
+	correct := se.SearchPage(types.SearchRequest{Query: "hashmap"})
+	if page.Total == 0 || page.Results[0].Document.ID != correct.Results[0].Document.ID {
+		t.Errorf("misspelled query found %d results, want the same top result as the correct query", page.Total)
+	}
+	if correct.DidYouMean != "" {
+		t.Errorf("did_you_mean = %q for a correct query", correct.DidYouMean)
+	}
+
+	// 没有结果时也给出建议
+	page = se.SearchPage(types.SearchRequest{Query: "concurency"})
+	if page.Total != 0 || page.DidYouMean != "concurrency" {
+		t.Errorf("concurency: total %d, did_you_mean %q; want 0 results and concurrency", page.Total, page.DidYouMean)
+	}
+}
+
+func TestCorrectionsPoorResults(t *testing.T) {
+	se := NewSearchEngine()
+	se.keywordIndex = map[string][]string{
+		"thread":  {"a", "b", "c", "d", "e"},
+		"threat":  {"f"},
+		"threads": {"a", "b"},
+	}
+	se.buildVocabulary()
+
+	// 原词存在于索引中时只在结果很差时纠正，且候选词的文档数至少是原词的 spellingFrequencyRatio 倍
+	if got := se.corrections([]string{"threat"}, false); len(got) != 0 {
+		t.Errorf("corrections without poor results = %v, want none", got)
+	}
+	if got := se.corrections([]string{"threat"}, true); got["threat"] != "thread" {
+		t.Errorf("corrections with poor results = %v, want threat -> thread", got)
+	}
+	if got := se.corrections([]string{"threads"}, true); len(got) != 0 {
+		t.Errorf("corrections(threads) = %v, candidate is not frequent enough", got)
+	}
+	// 距离相同时选择文档数更多的词
+	if got := se.closestTerm("threaq", 0); got != "thread" {
+		t.Errorf("closestTerm(threaq) = %q, want thread", got)
+	}
+}
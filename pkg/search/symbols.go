@@ -0,0 +1,320 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+var (
+	// declHeadingPattern 匹配API文档中的声明标题，如 "## class ArrayList\<T>"、"## func println(String)"
+	declHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(?:(?:public|abstract|open|sealed|unsafe|foreign)\s+)*(class|struct|interface|enum|func|type|macro)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	// extendHeadingPattern 匹配扩展声明标题，如 "## extend\<T> ArrayList\<T> <: ToString"
+	extendHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+extend\s*(?:\\?<[^>]*>)?\s*([A-Za-z_][A-Za-z0-9_]*)`)
+	// memberHeadingPattern 匹配类型成员标题，如 "### func add(T)"、"### prop size"、"### init()"
+	memberHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(?:(?:public|static|operator|mut|override|open|redef|unsafe|const)\s+)*(func|prop|let|var|init)\b\s*([A-Za-z_][A-Za-z0-9_]*)?`)
+	// caseHeadingPattern 匹配枚举构造器标题，如 "### Some(T)"、"### None"
+	caseHeadingPattern = regexp.MustCompile(`^#{1,6}\s+([A-Za-z_][A-Za-z0-9_]*)\s*(?:\(.*\))?\s*$`)
+)
+
+// buildSymbolIndex 从标准库文档的声明标题构建符号索引和成员索引
+// 类型内部的成员函数不作为独立符号，它们随所属类型一起导入
+func (se *SearchEngine) buildSymbolIndex() {
+	se.symbolIndex = make(map[string][]types.APISymbol)
+	se.memberIndex = make(map[string][]types.APISymbol)
+
+	docIDs := make([]string, 0, len(se.documents))
+	for docID, doc := range se.documents {
+		if doc.Package != "" {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	sort.Strings(docIDs)
+
+	seen := make(map[string]bool)
+	for _, docID := range docIDs {
+		doc := se.documents[docID]
+		for _, symbol := range extractDocSymbols(doc) {
+			key := symbol.Package + "." + symbol.Parent + "." + symbol.Kind + "." + symbol.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if symbol.Parent != "" {
+				se.memberIndex[symbol.Parent] = append(se.memberIndex[symbol.Parent], symbol)
+			} else {
+				se.symbolIndex[symbol.Name] = append(se.symbolIndex[symbol.Name], symbol)
+			}
+		}
+	}
+}
+
+// extractDocSymbols 提取文档中声明的顶层符号和类型成员（包括扩展中的成员和枚举构造器）
+func extractDocSymbols(doc *types.Document) []types.APISymbol {
+	var symbols []types.APISymbol
+	typeLevel := 0 // 当前所在类型声明的标题级别，0表示不在类型内
+	typeName := ""
+	typeKind := ""
+	inCodeBlock := false
+
+	for _, line := range strings.Split(doc.Content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || !strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		level := len(line) - len(strings.TrimLeft(line, "#"))
+		if typeLevel > 0 && level <= typeLevel {
+			typeLevel = 0
+			typeName = ""
+			typeKind = ""
+		}
+		section := strings.ReplaceAll(strings.TrimSpace(strings.TrimLeft(line, "#")), `\<`, "<")
+
+		if typeLevel > 0 {
+			// 类型的成员
+			if m := memberHeadingPattern.FindStringSubmatch(line); m != nil {
+				name := m[3]
+				if m[2] == "init" || name == "" {
+					name = "init"
+				}
+				symbols = append(symbols, types.APISymbol{
+					Name:    name,
+					Kind:    m[2],
+					Package: doc.Package,
+					DocID:   doc.ID,
+					Section: section,
+					Parent:  typeName,
+				})
+			} else if m := caseHeadingPattern.FindStringSubmatch(line); m != nil && typeKind == "enum" {
+				symbols = append(symbols, types.APISymbol{
+					Name:    m[1],
+					Kind:    "case",
+					Package: doc.Package,
+					DocID:   doc.ID,
+					Section: section,
+					Parent:  typeName,
+				})
+			}
+			continue
+		}
+
+		if m := extendHeadingPattern.FindStringSubmatch(line); m != nil {
+			typeLevel = level
+			typeName = m[2]
+			typeKind = "extend"
+			continue
+		}
+
+		m := declHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := m[2]
+		if kind != "func" && kind != "macro" && kind != "type" {
+			typeLevel = level
+			typeName = m[3]
+			typeKind = kind
+		}
+
+		symbols = append(symbols, types.APISymbol{
+			Name:    m[3],
+			Kind:    kind,
+			Package: doc.Package,
+			DocID:   doc.ID,
+			Section: section,
+		})
+	}
+
+	return symbols
+}
+
+// LookupSymbol 查找符号定义，优先精确匹配，找不到时忽略大小写匹配
+func (se *SearchEngine) LookupSymbol(name string) []types.APISymbol {
+	if symbols, ok := se.symbolIndex[name]; ok {
+		return symbols
+	}
+
+	var symbols []types.APISymbol
+	for symbolName, defs := range se.symbolIndex {
+		if strings.EqualFold(symbolName, name) {
+			symbols = append(symbols, defs...)
+		}
+	}
+	return symbols
+}
+
+// SimilarSymbols 返回与名称相近的符号名（前缀或包含关系），用于找不到符号时给出建议
+func (se *SearchEngine) SimilarSymbols(name string, limit int) []string {
+	lower := strings.ToLower(name)
+	var prefixMatches, containsMatches []string
+	for symbolName := range se.symbolIndex {
+		candidate := strings.ToLower(symbolName)
+		switch {
+		case strings.HasPrefix(candidate, lower):
+			prefixMatches = append(prefixMatches, symbolName)
+		case strings.Contains(candidate, lower):
+			containsMatches = append(containsMatches, symbolName)
+		}
+	}
+	sort.Strings(prefixMatches)
+	sort.Strings(containsMatches)
+
+	matches := append(prefixMatches, containsMatches...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// LookupMember 查找类型的成员定义
+func (se *SearchEngine) LookupMember(typeName, member string) []types.APISymbol {
+	var members []types.APISymbol
+	for _, symbol := range se.memberIndex[typeName] {
+		if symbol.Name == member {
+			members = append(members, symbol)
+		}
+	}
+	return members
+}
+
+// HasMember 判断是否有任何类型定义了该名称的成员
+func (se *SearchEngine) HasMember(member string) bool {
+	for _, members := range se.memberIndex {
+		for _, symbol := range members {
+			if symbol.Name == member {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasType 判断符号索引中是否有该类型（类型成员索引以类型名为键）
+func (se *SearchEngine) HasType(typeName string) bool {
+	_, ok := se.memberIndex[typeName]
+	if ok {
+		return true
+	}
+	for _, symbol := range se.symbolIndex[typeName] {
+		if symbol.Kind != "func" && symbol.Kind != "macro" {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosestSymbols 按编辑距离返回最接近的顶层符号名，用于拼写错误的建议
+func (se *SearchEngine) ClosestSymbols(name string, limit int) []string {
+	candidates := make([]string, 0, len(se.symbolIndex))
+	for symbolName := range se.symbolIndex {
+		candidates = append(candidates, symbolName)
+	}
+	return closestNames(name, candidates, limit)
+}
+
+// ClosestMembers 按编辑距离返回类型中最接近的成员名；typeName 为空时在所有类型的成员中查找
+func (se *SearchEngine) ClosestMembers(typeName, member string, limit int) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(members []types.APISymbol) {
+		for _, symbol := range members {
+			if !seen[symbol.Name] {
+				seen[symbol.Name] = true
+				candidates = append(candidates, symbol.Name)
+			}
+		}
+	}
+	if typeName != "" {
+		add(se.memberIndex[typeName])
+	} else {
+		for _, members := range se.memberIndex {
+			add(members)
+		}
+	}
+	return closestNames(member, candidates, limit)
+}
+
+// ClosestPackages 按编辑距离返回最接近的包名
+func (se *SearchEngine) ClosestPackages(pkg string, limit int) []string {
+	return closestNames(pkg, se.Packages(), limit)
+}
+
+// closestNames 返回编辑距离在阈值内的候选名称，按距离升序
+func closestNames(name string, candidates []string, limit int) []string {
+	lower := strings.ToLower(name)
+	maxDistance := editThreshold(name)
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var matches []candidate
+	for _, c := range candidates {
+		d := editDistance(lower, strings.ToLower(c))
+		if d <= maxDistance {
+			matches = append(matches, candidate{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < limit; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// editThreshold 返回名称可容忍的最大编辑距离，随名称长度增加，短名称只容忍1个字符的差异
+func editThreshold(name string) int {
+	maxDistance := len([]rune(name))/3 + 1
+	if maxDistance > 3 {
+		maxDistance = 3
+	}
+	return maxDistance
+}
+
+// editDistance 计算两个字符串的编辑距离（Damerau-Levenshtein，相邻字符交换计为1次）
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	// 只保留最近三行
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,40 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+func TestExtractDocSymbolsEnumCases(t *testing.T) {
+	doc := &types.Document{
+		ID:      "libs_std_core_option",
+		Package: "std.core",
+		Content: "# 枚举\n\n" +
+			"## enum Option\\<T>\n\n```cangjie\npublic enum Option<T> {\n    | Some(T)\n    | None\n}\n```\n\n" +
+			"### Some(T)\n\n```cangjie\nSome(T)\n```\n\n" +
+			"### None\n\n" +
+			"### func getOrThrow()\n\n" +
+			"## class Box\n\n" +
+			"### Value\n\n" +
+			"### func get()\n",
+	}
+
+	var got []string
+	for _, symbol := range extractDocSymbols(doc) {
+		got = append(got, fmt.Sprintf("%s.%s %s", symbol.Parent, symbol.Name, symbol.Kind))
+	}
+	want := []string{
+		".Option enum",
+		"Option.Some case",
+		"Option.None case",
+		"Option.getOrThrow func",
+		".Box class",
+		"Box.get func",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractDocSymbols = %q, want %q", got, want)
+	}
+}
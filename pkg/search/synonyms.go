@@ -0,0 +1,238 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultSynonymGroups 内置的同义词和中英文对照词组，组内的词互相扩展
+// 主要用于英文提问检索中文文档（string split -> 字符串 分割），以及中文提问检索英文API名
+var defaultSynonymGroups = [][]string{
+	// 基础类型
+	{"字符串", "string"},
+	{"字符", "rune", "char"},
+	{"整数", "integer", "int64"},
+	{"浮点数", "float", "float64"},
+	{"布尔", "bool", "boolean"},
+	{"元组", "tuple"},
+	{"数组", "array"},
+	{"区间", "range"},
+	{"可选", "option", "optional"},
+	{"类型转换", "conversion", "convert", "cast"},
+	// 语法
+	{"变量", "variable"},
+	{"常量", "constant", "const"},
+	{"函数", "function", "func"},
+	{"闭包", "closure", "lambda"},
+	{"类", "class"},
+	{"结构体", "struct"},
+	{"接口", "interface"},
+	{"枚举", "enum"},
+	{"泛型", "generic", "generics"},
+	{"扩展", "extend", "extension"},
+	{"继承", "inheritance", "inherit"},
+	{"属性", "property", "prop"},
+	{"构造函数", "constructor", "init"},
+	{"运算符重载", "operator overloading"},
+	{"模式匹配", "pattern matching", "match"},
+	{"循环", "loop"},
+	{"迭代器", "iterator"},
+	{"宏", "macro"},
+	{"注解", "annotation"},
+	{"包", "package"},
+	{"模块", "module"},
+	{"导入", "import"},
+	{"异常", "exception"},
+	{"异常处理", "exception handling"},
+	{"错误", "error"},
+	// 并发
+	{"并发", "concurrency", "concurrent"},
+	{"线程", "thread", "spawn", "协程", "coroutine"},
+	{"互斥锁", "mutex", "lock"},
+	{"原子操作", "atomic"},
+	// 标准库
+	{"拆分", "分割", "split"},
+	{"拼接", "concat", "join"},
+	{"格式化", "format"},
+	{"打印", "print", "println"},
+	{"列表", "list", "arraylist"},
+	{"哈希表", "映射", "字典", "map", "hashmap", "dictionary"},
+	{"集合", "collection", "hashset"},
+	{"文件", "file"},
+	{"路径", "path"},
+	{"目录", "directory"},
+	{"网络", "network", "net"},
+	{"套接字", "socket"},
+	{"正则表达式", "正则", "regex", "regexp"},
+	{"时间", "time", "datetime"},
+	{"随机数", "random"},
+	{"数学", "math"},
+	{"序列化", "serialization", "serialize"},
+	{"反射", "reflection", "reflect"},
+	{"单元测试", "unittest", "unit test"},
+	// 工具与运行时
+	{"编译", "compile", "compiler", "cjc"},
+	{"构建", "build"},
+	{"交叉编译", "cross compile", "cross-compile"},
+	{"条件编译", "conditional compilation"},
+	{"互操作", "interop", "ffi", "foreign"},
+	{"垃圾回收", "garbage collection", "gc"},
+	{"内存", "memory"},
+}
+
+// buildSynonyms 由词组生成同义词表，组内每个词映射到组内的其他词
+func buildSynonyms(groups [][]string) map[string][]string {
+	synonyms := make(map[string][]string)
+	for _, group := range groups {
+		addSynonymGroup(synonyms, group)
+	}
+	return synonyms
+}
+
+// addSynonymGroup 把词组合并到同义词表
+func addSynonymGroup(synonyms map[string][]string, group []string) {
+	for _, term := range group {
+		for _, other := range group {
+			if other != term {
+				synonyms[term] = mergeWords(synonyms[term], []string{other})
+			}
+		}
+	}
+}
+
+// LoadSynonyms 从文件加载同义词，覆盖或补充内置词表
+// 每行一条，# 开头为注释：
+//
+//	字符串, String, str      词组，组内的词互相扩展，与内置词组合并
+//	线程 = thread, spawn     单向映射，替换该词的内置扩展
+//	宏 =                     关闭该词的扩展
+func (se *SearchEngine) LoadSynonyms(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if term, targets, ok := strings.Cut(line, "="); ok {
+			term = normalizeSynonym(term)
+			if term == "" {
+				return fmt.Errorf("%s:%d: missing term before '='", path, lineNo)
+			}
+			se.synonyms[term] = splitSynonyms(targets)
+			continue
+		}
+
+		group := splitSynonyms(line)
+		if len(group) < 2 {
+			return fmt.Errorf("%s:%d: synonym group needs at least two terms", path, lineNo)
+		}
+		addSynonymGroup(se.synonyms, group)
+	}
+	return scanner.Err()
+}
+
+// splitSynonyms 拆分逗号分隔的词
+func splitSynonyms(text string) []string {
+	var terms []string
+	for _, term := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' }) {
+		if term = normalizeSynonym(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// normalizeSynonym 统一为小写并合并空白
+func normalizeSynonym(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+// ExpandQuery 返回查询的同义词和中英文对照扩展词（不含查询中已有的词）
+// 英文词按整词匹配，多词短语（如 exception handling）按短语匹配，中文按最长词优先从连续汉字中切出词表中的词
+func (se *SearchEngine) ExpandQuery(query string) []string {
+	query = normalizeSynonym(query)
+	queryWords := mergeWords(se.extractWords(query), se.extractCodeWords(query, false))
+
+	var terms []string
+	for _, word := range queryWords {
+		if isHanWord(word) {
+			terms = append(terms, se.segmentHan(word)...)
+		} else if _, ok := se.synonyms[word]; ok {
+			terms = append(terms, word)
+		}
+	}
+	var phrases []string
+	for term := range se.synonyms {
+		if strings.Contains(term, " ") && strings.Contains(query, term) {
+			phrases = append(phrases, term)
+		}
+	}
+	sort.Strings(phrases)
+	terms = append(terms, phrases...)
+
+	existing := make(map[string]bool)
+	for _, word := range queryWords {
+		existing[word] = true
+	}
+	var expanded []string
+	for _, term := range terms {
+		for _, synonym := range se.synonyms[term] {
+			for _, word := range mergeWords(se.extractWords(synonym), se.extractCodeWords(synonym, false)) {
+				if !existing[word] {
+					existing[word] = true
+					expanded = append(expanded, word)
+				}
+			}
+		}
+	}
+	return expanded
+}
+
+// segmentHan 按最长匹配从连续汉字中切出同义词表中的词，如 字符串分割 -> 字符串、分割
+// 单字词（类、包、宏）只在整个词就是该字时匹配，避免 类型 被切出 类
+func (se *SearchEngine) segmentHan(word string) []string {
+	runes := []rune(word)
+	if _, ok := se.synonyms[word]; ok {
+		return []string{word}
+	}
+	var terms []string
+	for i := 0; i < len(runes); {
+		matched := 0
+		for j := len(runes); j > i+1; j-- {
+			if _, ok := se.synonyms[string(runes[i:j])]; ok {
+				matched = j - i
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		terms = append(terms, string(runes[i:i+matched]))
+		i += matched
+	}
+	return terms
+}
+
+// isHanWord 判断是否为汉字词
+func isHanWord(word string) bool {
+	for _, r := range word {
+		if !unicode.Is(unicode.Han, r) {
+			return false
+		}
+	}
+	return word != ""
+}
@@ -0,0 +1,167 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cangje-docs-mcp/pkg/types"
+)
+
+// writeSynonyms 把同义词文件写入临时目录并返回路径
+func writeSynonyms(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	se := NewSearchEngine()
+	path := writeSynonyms(t, `# 项目术语
+
+字符串, Str
+仓颉， Cangjie ,  CJ
+线程 = Fiber,  Green   Thread
+宏 =
+`)
+	if err := se.LoadSynonyms(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		term string
+		want []string
+	}{
+		// 词组与内置词组合并
+		{"字符串", []string{"string", "str"}},
+		{"str", []string{"字符串"}},
+		{"仓颉", []string{"cangjie", "cj"}},
+		{"cj", []string{"仓颉", "cangjie"}},
+		// 单向映射替换内置扩展，空白合并为一个空格
+		{"线程", []string{"fiber", "green thread"}},
+		{"thread", []string{"线程", "spawn", "协程", "coroutine"}},
+		// 空映射关闭扩展
+		{"宏", nil},
+	}
+	for _, tt := range tests {
+		if got := se.synonyms[tt.term]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("synonyms[%q] = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSynonymsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"missing term", "# 注释\n = thread\n", "synonyms.txt:2: missing term before '='"},
+		{"single term group", "字符串, string\n线程\n", "synonyms.txt:2: synonym group needs at least two terms"},
+		{"empty terms", "， ,\n", "synonyms.txt:1: synonym group needs at least two terms"},
+	}
+	for _, tt := range tests {
+		err := NewSearchEngine().LoadSynonyms(writeSynonyms(t, tt.content))
+		if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if err := NewSearchEngine().LoadSynonyms(filepath.Join(t.TempDir(), "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v, want not exist", err)
+	}
+}
+
+func TestExpandQuery(t *testing.T) {
+	se := NewSearchEngine()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// 英文查询扩展出中文词
+		{"string split", []string{"字符串", "拆分", "分割"}},
+		{"HashMap", []string{"哈希表", "映射", "字典", "map", "dictionary"}},
+		// 中文查询按最长匹配切词后扩展出英文词
+		{"字符串分割", []string{"string", "拆分", "split"}},
+		{"怎么创建线程", []string{"thread", "spawn", "协程", "coroutine"}},
+		// 多词短语按短语匹配
+		{"exception handling in cangjie", []string{"异常", "异常处理"}},
+		// 单字词只在整个词就是该字时匹配
+		{"类型", nil},
+		{"类", []string{"class"}},
+		// 查询中已有的词不重复
+		{"string 字符串", nil},
+	}
+	for _, tt := range tests {
+		if got := se.ExpandQuery(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpandQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSegmentHan(t *testing.T) {
+	se := NewSearchEngine()
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"字符串分割", []string{"字符串", "分割"}},
+		{"正则表达式匹配", []string{"正则表达式"}},
+		{"类型转换", []string{"类型转换"}},
+		{"类型", nil},
+		{"宏", []string{"宏"}},
+	}
+	for _, tt := range tests {
+		if got := se.segmentHan(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("segmentHan(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSynonymMatchesRankBelowOriginal(t *testing.T) {
+	se := NewSearchEngine()
+	se.BuildIndex(map[string]*types.Document{
+		"english": {ID: "english", Title: "String operations", Description: "Split a string", Category: types.CategoryManual,
+			Content: "Use split to cut a string into parts."},
+		"chinese": {ID: "chinese", Title: "字符串 操作", Description: "分割 字符串", Category: types.CategoryManual,
+			Content: "使用 分割 把 字符串 拆成多段。"},
+	})
+
+	scores := func(query string) map[string]types.SearchResult {
+		results := make(map[string]types.SearchResult)
+		for _, result := range se.Search(types.SearchRequest{Query: query, MaxResults: 10}) {
+			results[result.Document.ID] = result
+		}
+		return results
+	}
+
+	// 两个方向上，通过扩展词命中的文档都排在直接命中的文档之后，分数低于直接查询该词
+	for _, tt := range []struct{ query, translated, direct, expanded string }{
+		{"string", "字符串", "english", "chinese"},
+		{"字符串", "string", "chinese", "english"},
+	} {
+		results := scores(tt.query)
+		direct, ok := results[tt.direct]
+		if !ok {
+			t.Fatalf("%q: %s not found", tt.query, tt.direct)
+		}
+		expanded, ok := results[tt.expanded]
+		if !ok {
+			t.Fatalf("%q: %s not found through the expanded term %q", tt.query, tt.expanded, tt.translated)
+		}
+		if expanded.MatchType != "synonym" {
+			t.Errorf("%q: %s match type = %s, want synonym", tt.query, tt.expanded, expanded.MatchType)
+		}
+		if expanded.Score >= direct.Score {
+			t.Errorf("%q: expanded match %.2f >= direct match %.2f", tt.query, expanded.Score, direct.Score)
+		}
+		if original := scores(tt.translated)[tt.expanded]; expanded.Score >= original.Score {
+			t.Errorf("%q: %s scores %.2f through the expanded term, %.2f for %q directly", tt.query, tt.expanded, expanded.Score, original.Score, tt.translated)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// codeIdentifierPattern 匹配代码标识符，包括带包名限定的名称（std.collection.ArrayList）和注解/宏（@Derive）
+// 泛型参数不属于标识符，ArrayList<T> 会得到 ArrayList 和 T 两个标识符
+var codeIdentifierPattern = regexp.MustCompile(`@?[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// extractCodeWords 使用代码分词规则提取词，用于API符号和代码块
+// 与 extractWords 不同，保留数字后缀（Int64）、下划线、包名限定（std.collection）和 @ 前缀；
+// withParts 为 true 时还会把 camelCase 和 snake_case 拆成子词（toString -> to, string），
+// 建立索引时使用，查询时只使用完整标识符，避免 "to" 这样的子词匹配过多文档
+// 其他语言习惯的 :: 路径（std::collection::ArrayList）按 . 处理
+func (se *SearchEngine) extractCodeWords(text string, withParts bool) []string {
+	var words []string
+	seen := make(map[string]bool)
+	add := func(word string) {
+		if len([]rune(word)) > 1 && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+
+	for _, match := range codeIdentifierPattern.FindAllString(strings.ReplaceAll(text, "::", "."), -1) {
+		name := strings.TrimPrefix(match, "@")
+		if name != match {
+			add("@" + strings.ToLower(name))
+		}
+
+		segments := strings.Split(name, ".")
+		if len(segments) > 1 {
+			// 完整限定名和包名前缀，如 std.collection.arraylist、std.collection
+			for i := len(segments); i >= 2; i-- {
+				add(strings.ToLower(strings.Join(segments[:i], ".")))
+			}
+		}
+
+		for _, segment := range segments {
+			if lower := strings.ToLower(segment); !se.isStopWord(lower) {
+				add(lower)
+			}
+			if !withParts {
+				continue
+			}
+			parts := splitIdentifier(segment)
+			if len(parts) < 2 {
+				continue
+			}
+			for _, part := range parts {
+				if lower := strings.ToLower(part); !se.isStopWord(lower) {
+					add(lower)
+				}
+			}
+		}
+	}
+
+	return words
+}
+
+// splitIdentifier 按 camelCase 和 snake_case 拆分标识符，数字跟随前面的单词
+// 如 toString -> [to String]、HTTPClient -> [HTTP Client]、toInt64 -> [to Int64]、read_all -> [read all]
+func splitIdentifier(name string) []string {
+	var parts []string
+	runes := []rune(name)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, string(runes[start:end]))
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// 小写或数字后的大写字母开始新单词：toString、Int64Value
+			flush(i)
+			start = i
+		case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// 连续大写后接小写，最后一个大写字母属于下一个单词：HTTPClient
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	return parts
+}
+
+// extractCodeBlocks 返回 Markdown 内容中所有代码块的文本
+func extractCodeBlocks(content string) string {
+	var builder strings.Builder
+	inCodeBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			builder.WriteString(line)
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// mergeWords 合并两组词并去重，保持原有顺序
+func mergeWords(words []string, more []string) []string {
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		seen[word] = true
+	}
+	for _, word := range more {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
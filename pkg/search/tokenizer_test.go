@@ -0,0 +1,99 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"toString", []string{"to", "String"}},
+		{"ArrayList", []string{"Array", "List"}},
+		{"HTTPClient", []string{"HTTP", "Client"}},
+		{"parseJSON", []string{"parse", "JSON"}},
+		{"toInt64", []string{"to", "Int64"}},
+		{"Int64Value", []string{"Int64", "Value"}},
+		{"read_all_bytes", []string{"read", "all", "bytes"}},
+		{"_private__name_", []string{"private", "name"}},
+		{"MAX_SIZE", []string{"MAX", "SIZE"}},
+		{"size", []string{"size"}},
+		{"URL", []string{"URL"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitIdentifier(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitIdentifier(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractCodeWords(t *testing.T) {
+	se := NewSearchEngine()
+	tests := []struct {
+		text      string
+		withParts []string
+		whole     []string
+	}{
+		// camelCase 拆出的停用词 to 和单字符词不保留
+		{"toString()", []string{"tostring", "string"}, []string{"tostring"}},
+		{"read_all_bytes", []string{"read_all_bytes", "read", "bytes"}, []string{"read_all_bytes"}},
+		// 泛型参数是独立的标识符
+		{"ArrayList<T>", []string{"arraylist", "array", "list"}, []string{"arraylist"}},
+		{"HashMap<String, ArrayList<Int64>>",
+			[]string{"hashmap", "hash", "map", "string", "arraylist", "array", "list", "int64"},
+			[]string{"hashmap", "string", "arraylist", "int64"}},
+		// 包名限定的名称同时保留完整名称和各级前缀
+		{"std.collection.ArrayList",
+			[]string{"std.collection.arraylist", "std.collection", "std", "collection", "arraylist", "array", "list"},
+			[]string{"std.collection.arraylist", "std.collection", "std", "collection", "arraylist"}},
+		{"std::collection::ArrayList",
+			[]string{"std.collection.arraylist", "std.collection", "std", "collection", "arraylist", "array", "list"},
+			[]string{"std.collection.arraylist", "std.collection", "std", "collection", "arraylist"}},
+		{"list.add(x)", []string{"list.add", "list", "add"}, []string{"list.add", "list", "add"}},
+		// 注解保留 @ 前缀
+		{"@Derive[ToString]", []string{"@derive", "derive", "tostring", "string"}, []string{"@derive", "derive", "tostring"}},
+		{"x + 1", nil, nil},
+	}
+	for _, tt := range tests {
+		if got := se.extractCodeWords(tt.text, true); !reflect.DeepEqual(got, tt.withParts) {
+			t.Errorf("extractCodeWords(%q, true) = %q, want %q", tt.text, got, tt.withParts)
+		}
+		if got := se.extractCodeWords(tt.text, false); !reflect.DeepEqual(got, tt.whole) {
+			t.Errorf("extractCodeWords(%q, false) = %q, want %q", tt.text, got, tt.whole)
+		}
+	}
+}
+
+func TestMergeWords(t *testing.T) {
+	tests := []struct {
+		words []string
+		more  []string
+		want  []string
+	}{
+		{[]string{"array", "list"}, []string{"list", "map", "array", "set"}, []string{"array", "list", "map", "set"}},
+		{nil, []string{"a", "b", "a"}, []string{"a", "b"}},
+		{[]string{"a", "b"}, nil, []string{"a", "b"}},
+		{nil, nil, nil},
+	}
+	for _, tt := range tests {
+		if got := mergeWords(tt.words, tt.more); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeWords(%q, %q) = %q, want %q", tt.words, tt.more, got, tt.want)
+		}
+	}
+}
+
+func TestKeywordIndexNoDuplicates(t *testing.T) {
+	se, _ := loadRelevanceFixture(t)
+	for word, docIDs := range se.keywordIndex {
+		seen := make(map[string]bool, len(docIDs))
+		for _, docID := range docIDs {
+			if seen[docID] {
+				t.Errorf("keyword %q lists document %s more than once", word, docID)
+			}
+			seen[docID] = true
+		}
+	}
+}
@@ -21,6 +21,9 @@ const (
 	DescriptionWeight   = 6.0  // 描述匹配
 	ContentMatchWeight  = 3.0  // 内容匹配
 	FilenameMatchWeight = 5.0  // 文件名匹配
+	TableCellMatchWeight = 3.0 // 表格单元格匹配（每行）
+	CodeMatchWeight      = 2.0 // API符号或代码块中的代码词匹配
+	SynonymWeight        = 0.5 // 同义词和中英文对照扩展词的分数系数
 )
 
 // 默认配置
@@ -28,6 +31,40 @@ const (
 	DefaultMaxResults   = 10
 	DefaultMinConfidence = 0.3
 	DefaultMaxSuggestions = 5
+	// 精确匹配和关键词匹配的候选文档少于此数时补充模糊匹配，与分页大小无关，保证总数和维度统计不随 max_results 变化
+	FuzzyMatchThreshold = 20
+	// 最高分低于此值时认为搜索结果较差，会尝试给出拼写建议
+	PoorResultScore = 5.0
+	// 搜索结果中每个源文件默认保留的章节数，其余章节折叠
+	DefaultCollapseSections = 2
+	// 每个统计维度最多返回的取值数
+	MaxFacetValues = 20
+	// 搜索结果片段的默认最大字符数
+	DefaultSnippetLength = 160
+	// 每个搜索结果最多返回的片段数
+	MaxSnippets = 5
+	// 搜索结果 match_text 的最大字符数
+	MatchTextLength = 100
+)
+
+// 响应大小控制
+const (
+	// max_tokens 的最小值，保证每次至少返回一部分内容
+	MinMaxTokens = 200
+	// 截断响应时为续读提示和游标预留的 token 数
+	ContinuationReserve = 60
+)
+
+// 文档分块读取
+const (
+	// 按行计算 offset 和 limit
+	ChunkUnitLine = "line"
+	// 按估算的 token 数计算 offset 和 limit
+	ChunkUnitToken = "token"
+	// 按行读取时每块的默认行数
+	DefaultChunkLines = 200
+	// 按 token 读取时每块的默认 token 数
+	DefaultChunkTokens = 2000
 )
 
 // 文档分割配置
@@ -40,6 +77,74 @@ const (
 	EnableDocumentSplitting = true
 )
 
+// 文档来源
+const (
+	// 官方文档仓库中的文档
+	SourceOfficial = "official"
+	// 本地补充目录中的文档
+	SourceOverlay = "overlay"
+	// 补充目录中的同义词文件名
+	SynonymsFileName = "synonyms.txt"
+)
+
+// 搜索范围模式
+const (
+	// 范围内的文档加分
+	ScopeModeBoost = "boost"
+	// 只返回范围内的文档
+	ScopeModeFilter = "filter"
+	// 范围内文档的分数加成倍数
+	ScopeBoostFactor = 1.5
+)
+
+// 搜索模式
+const (
+	// 关键词搜索
+	SearchModeKeyword = "keyword"
+	// 语义搜索（潜在语义索引）
+	SearchModeSemantic = "semantic"
+	// 关键词与语义结果按倒数排名融合
+	SearchModeHybrid = "hybrid"
+	// 语义搜索结果的最低余弦相似度
+	MinSemanticScore = 0.1
+	// 倒数排名融合（RRF）的排名常数
+	RRFRankConstant = 60
+)
+
+// 文档类型（搜索结果统计维度）
+const (
+	// 语言手册和教程
+	DocTypeGuide = "guide"
+	// API文档
+	DocTypeAPI = "api"
+	// 开发工具文档
+	DocTypeTool = "tool"
+)
+
+// 配置参考条目类型
+const (
+	// cjpm.toml 配置项
+	ConfigKindConfig = "config"
+	// 命令行选项
+	ConfigKindOption = "option"
+	// cjpm 子命令
+	ConfigKindCommand = "command"
+)
+
+// 文档根格式
+const (
+	// Markdown 文档
+	RootFormatMarkdown = "markdown"
+	// 仓颉源码，从文档注释生成API文档
+	RootFormatCangjie = "cj"
+)
+
+// 文档根优先级配置
+const (
+	// 每级优先级对搜索分数的加成比例
+	RootPriorityBoost = 0.1
+)
+
 // 分类映射
 var CategoryNames = map[DocumentCategory]string{
 	CategoryManual: "基础手册",
@@ -49,6 +154,9 @@ var CategoryNames = map[DocumentCategory]string{
 	CategoryOhos:   "OpenHarmony",
 }
 
+// 内置分类（按显示顺序）
+var BuiltinCategories = []DocumentCategory{CategoryManual, CategoryLibs, CategoryTools, CategoryExtra, CategoryOhos}
+
 // 分类描述
 var CategoryDescriptions = map[DocumentCategory]string{
 	CategoryManual: "仓颉语言基础教程和编程概念",
@@ -31,6 +31,36 @@ type Document struct {
 	LastModified  time.Time        `json:"last_modified"`
 	Content       string           `json:"content,omitempty"`
 	ContentPreview string          `json:"content_preview,omitempty"`
+	Source        string           `json:"source,omitempty"`        // 文档来源：official/overlay 或额外文档根的名称
+	Package       string           `json:"package,omitempty"`       // API文档所属的包，如 std.collection
+	Tables        []Table          `json:"tables,omitempty"`        // 文档中的 Markdown 表格
+	ParentTitle   string           `json:"parent_title,omitempty"`  // 分割后的章节所属父文档的标题
+}
+
+// Table 文档中的 Markdown 表格
+type Table struct {
+	Index   int        `json:"index"`   // 表格在文档中的序号（从1开始）
+	Section string     `json:"section"` // 表格所在章节的标题
+	Line    int        `json:"line"`    // 表格起始行号（从1开始）
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// TableRef 搜索命中的表格行
+type TableRef struct {
+	Index   int    `json:"index"`   // 表格序号
+	Row     int    `json:"row"`     // 行号（从1开始，0表示表头）
+	Section string `json:"section"` // 表格所在章节
+}
+
+// DocRoot 额外的文档根目录，如团队内部库文档、设计指南
+type DocRoot struct {
+	Name        string           `json:"name"`         // 文档根名称，显示为文档来源
+	Path        string           `json:"path"`         // 目录路径
+	Category    DocumentCategory `json:"category"`     // 文档分类，可以是内置分类或自定义分类
+	DisplayName string           `json:"display_name"` // 分类显示名称（仅自定义分类）
+	Priority    int              `json:"priority"`     // 优先级，数值越大搜索排名越靠前，ID冲突时优先保留
+	Format      string           `json:"format"`       // 文档格式：markdown（默认）或 cj（从仓颉源码文档注释生成API文档）
 }
 
 // CategoryInfo 分类信息
@@ -61,20 +91,123 @@ type DocumentSummary struct {
 
 // SearchResult 搜索结果
 type SearchResult struct {
-	Document   Document `json:"document"`
-	Score      float64  `json:"score"`
-	MatchType  string   `json:"match_type"` // exact, title, description, content
-	MatchText  string   `json:"match_text"` // 匹配的文本片段
+	Document   Document  `json:"document"`
+	Score      float64   `json:"score"`
+	MatchType  string    `json:"match_type"`      // exact, title, description, content
+	MatchText  string    `json:"match_text"`      // 匹配的文本片段
+	Table      *TableRef `json:"table,omitempty"` // 匹配位于表格中时的表格位置
+	Group      *SearchGroup `json:"group,omitempty"` // 同一源文件命中多个章节时的分组信息
+	Explanation *ScoreExplanation `json:"explanation,omitempty"` // 分数的组成，只在请求 Explain 时记录
+	Snippets   []Snippet `json:"snippets,omitempty"` // 与查询最相关的几段文本，只在请求 Snippets 时生成
+}
+
+// Snippet 文档中与查询相关的一段文本
+type Snippet struct {
+	Text     string   `json:"text"`               // 片段文本，命中的词用 ** 标记
+	Headings []string `json:"headings,omitempty"` // 片段所在章节的标题路径
+	Line     int      `json:"line"`               // 片段起始行号（从1开始）
+	Score    float64  `json:"score"`              // 片段分数
+}
+
+// ScoreExplanation 搜索分数的组成，用于排查排序问题
+type ScoreExplanation struct {
+	Components    []ScoreComponent `json:"components"`       // 各项得分，之和为加成前的分数
+	Base          float64          `json:"base"`             // 加成前的分数
+	Boosts        []ScoreBoost     `json:"boosts,omitempty"` // 依次乘到分数上的加成
+	Score         float64          `json:"score"`            // 最终分数
+	Normalization string           `json:"normalization"`    // 分数的计算方式
+}
+
+// ScoreComponent 一项得分：某个阶段中某个词在某个字段的命中
+type ScoreComponent struct {
+	Stage  string  `json:"stage"`           // exact、keyword、synonym、fuzzy、semantic 或 rrf
+	Term   string  `json:"term,omitempty"`  // 命中的词
+	Field  string  `json:"field"`           // title、description、keywords、filename、table、code、content、index、vector 或排名来源
+	Weight float64 `json:"weight"`          // 单次命中的权重
+	Count  int     `json:"count,omitempty"` // 命中次数（rrf 为排名）
+	Score  float64 `json:"score"`           // 该项得分
+}
+
+// ScoreBoost 分数加成
+type ScoreBoost struct {
+	Name   string  `json:"name"`   // priority（文档根优先级）或 scope（项目范围）
+	Factor float64 `json:"factor"` // 分数乘数
+}
+
+// SearchPage 一页搜索结果
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`            // 全部结果数（分组折叠后）
+	Facets  SearchFacets   `json:"facets,omitempty"` // 全部命中文档按维度的统计，只在请求 Facets 时计算
+	DidYouMean string      `json:"did_you_mean,omitempty"` // 纠正拼写后的查询，查询有拼写错误或第一页结果较差时给出
+}
+
+// SearchFacets 命中文档按维度（category、subcategory、package、difficulty、doc_type、language）的统计
+type SearchFacets map[string][]FacetValue
+
+// FacetValue 维度中的一个取值及命中文档数
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchGroup 同一源文件（分割前的父文档）命中的章节分组，同组结果共享同一个分组
+type SearchGroup struct {
+	ParentID    string `json:"parent_id"`    // 父文档ID，未分割的文档为自身ID
+	ParentTitle string `json:"parent_title"` // 父文档标题
+	Matches     int    `json:"matches"`      // 命中的章节总数
+	Shown       int    `json:"shown"`        // 保留在结果中的章节数
+	Collapsed   int    `json:"collapsed"`    // 被折叠的章节数
 }
 
 // SearchRequest 搜索请求
 type SearchRequest struct {
 	Query        string           `json:"query"`
 	Category     DocumentCategory `json:"category,omitempty"`
+	Source       string           `json:"source,omitempty"`
+	Scope        *SearchScope     `json:"scope,omitempty"`
+	Mode         string           `json:"mode,omitempty"` // keyword（默认）、semantic 或 hybrid
 	MaxResults   int              `json:"max_results,omitempty"`
+	Offset       int              `json:"offset,omitempty"` // 分页偏移，跳过排名靠前的结果
+	Collapse     int              `json:"collapse,omitempty"` // 每个源文件最多保留的章节数，0 表示不分组
+	ParentID     string           `json:"parent_id,omitempty"` // 只搜索该父文档的章节，用于展开折叠的分组
+	Facets       bool             `json:"facets,omitempty"`    // 是否统计全部命中文档的维度分布
+	Explain      bool             `json:"explain,omitempty"`   // 是否记录每个结果的分数组成
+	Snippets     int              `json:"snippets,omitempty"`  // 每个结果返回的片段数，0 表示只返回 match_text
+	SnippetLength int             `json:"snippet_length,omitempty"` // 每个片段的最大字符数，0 表示使用默认值
 	MinConfidence float64         `json:"min_confidence,omitempty"`
 }
 
+// SearchScope 搜索范围，如当前项目用到的包对应的文档
+type SearchScope struct {
+	Name   string          `json:"name"`    // 范围名称，如项目路径
+	Mode   string          `json:"mode"`    // boost（范围内文档加分）或 filter（只返回范围内文档）
+	DocIDs map[string]bool `json:"-"`       // 范围内的文档ID
+}
+
+// APISymbol 标准库文档中声明的公开符号，如 class ArrayList、func println
+type APISymbol struct {
+	Name    string `json:"name"`             // 符号名，如 ArrayList
+	Kind    string `json:"kind"`             // 声明类型：class/struct/interface/enum/func/type 等
+	Package string `json:"package"`          // 所属包，如 std.collection
+	DocID   string `json:"doc_id"`           // 定义所在文档ID
+	Section string `json:"section"`          // 定义所在章节标题，可用于 cangjie_get_doc 的 section 参数
+	Parent  string `json:"parent,omitempty"` // 成员所属的类型，顶层符号为空
+}
+
+// ConfigEntry cjpm/cjc 的配置项或命令行选项
+type ConfigEntry struct {
+	Key         string   `json:"key"`                   // 配置项或选项名，如 output-type、--output-type
+	Aliases     []string `json:"aliases,omitempty"`     // 别名，如 -o
+	Tool        string   `json:"tool"`                  // cjpm 或 cjc
+	Kind        string   `json:"kind"`                  // config（cjpm.toml 配置项）、option（命令行选项）或 command（子命令）
+	Type        string   `json:"type,omitempty"`        // 类型或取值范围
+	Default     string   `json:"default,omitempty"`     // 默认值
+	Description string   `json:"description,omitempty"` // 说明
+	DocID       string   `json:"doc_id"`                // 来源文档ID
+	Section     string   `json:"section,omitempty"`     // 来源章节标题
+}
+
 // SuggestionRequest 建议请求
 type SuggestionRequest struct {
 	Context         string `json:"context"`
@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"cangje-docs-mcp/pkg/types"
 )
@@ -14,8 +16,23 @@ import (
 const (
 	// 仓颉文档仓库URL
 	CangjieRepoURL = "https://gitcode.com/Cangjie/CangjieCorpus.git"
+
+	// 克隆时等待其他进程释放锁的最长时间（对方可能也在克隆，需要等它完成）
+	cloneLockTimeout = 10 * time.Minute
+	// 更新时等待锁的最长时间，超时则以只读方式使用现有文档
+	updateLockTimeout = 10 * time.Second
 )
 
+// ErrLocalChanges 文档目录中存在未提交的修改或未跟踪的文件，强制更新会将其删除
+var ErrLocalChanges = errors.New("文档目录存在本地修改")
+
+// EnsureOptions 文档初始化选项
+type EnsureOptions struct {
+	AutoUpdate  bool         // 是否自动更新已有文档
+	Remotes     []RepoRemote // 按顺序尝试的远程仓库列表，为空时使用 DefaultRepoRemotes
+	ForceUpdate bool         // 更新时是否丢弃文档目录中的本地修改
+}
+
 // GetDefaultDocumentDir 获取默认文档目录
 func GetDefaultDocumentDir() (string, error) {
 	// Windows: 使用可执行文件同目录
@@ -39,15 +56,29 @@ func GetDefaultDocumentDir() (string, error) {
 
 // EnsureDocuments 确保文档存在并可访问
 // 如果文档不存在，会自动克隆；如果存在，会自动更新
-func EnsureDocuments(docDir string, autoUpdate bool) error {
+func EnsureDocuments(docDir string, opts EnsureOptions) error {
+	remotes := opts.Remotes
+	if len(remotes) == 0 {
+		remotes = DefaultRepoRemotes
+	}
+
 	// 检查文档目录是否存在
 	_, statErr := os.Stat(docDir)
 
 	// 如果文档目录存在
 	if statErr == nil {
-		if autoUpdate {
+		if opts.AutoUpdate {
 			fmt.Fprintf(os.Stderr, "正在更新仓颉文档...\n")
-			if err := UpdateDocuments(docDir); err != nil {
+			if err := UpdateDocuments(docDir, remotes, opts.ForceUpdate); err != nil {
+				if errors.Is(err, ErrLockTimeout) {
+					fmt.Fprintf(os.Stderr, "其他进程正在更新文档，将以只读方式使用现有文档\n")
+					return nil
+				}
+				if errors.Is(err, ErrLocalChanges) {
+					fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+					fmt.Fprintf(os.Stderr, "已跳过更新以保留本地修改。请将自定义文档移到补充目录 %s，或使用 -force-update 强制更新\n", DefaultOverlayDir(docDir))
+					return nil
+				}
 				fmt.Fprintf(os.Stderr, "警告: 文档更新失败: %v\n", err)
 				fmt.Fprintf(os.Stderr, "将继续使用现有文档\n")
 				return nil // 更新失败不阻塞启动
@@ -60,10 +91,9 @@ func EnsureDocuments(docDir string, autoUpdate bool) error {
 	// 文档目录不存在，需要克隆
 	if os.IsNotExist(statErr) {
 		fmt.Fprintf(os.Stderr, "仓颉文档不存在，正在从远程仓库克隆...\n")
-		fmt.Fprintf(os.Stderr, "仓库: %s\n", CangjieRepoURL)
 		fmt.Fprintf(os.Stderr, "目标目录: %s\n", docDir)
 
-		if err := CloneDocuments(docDir); err != nil {
+		if err := CloneDocuments(docDir, remotes); err != nil {
 			return fmt.Errorf("克隆文档失败: %w", err)
 		}
 
@@ -75,64 +105,106 @@ func EnsureDocuments(docDir string, autoUpdate bool) error {
 }
 
 // CloneDocuments 克隆仓颉文档仓库
-func CloneDocuments(docDir string) error {
+// 按顺序尝试各个远程仓库（上次成功的优先），直到某个克隆成功
+// 克隆过程持有文档目录锁；若等待期间其他进程已完成克隆，则直接返回
+func CloneDocuments(docDir string, remotes []RepoRemote) error {
 	// 创建父目录
 	parentDir := filepath.Dir(docDir)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 
+	lock, err := AcquireLock(lockPathFor(docDir), cloneLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// 等待锁期间其他进程可能已经完成克隆
+	if _, err := os.Stat(docDir); err == nil {
+		fmt.Fprintf(os.Stderr, "文档已由其他进程克隆完成\n")
+		return nil
+	}
+
 	// 检查 git 是否可用
 	if _, err := exec.LookPath("git"); err != nil {
 		return fmt.Errorf("系统未安装 git，请先安装 git: %w", err)
 	}
 
-	// 执行 git clone
-	cmd := exec.Command("git", "clone", "--depth", "1", CangjieRepoURL, docDir)
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
+	var failures []string
+	for _, remote := range orderRemotes(remotes, LastWorkingRemote(docDir)) {
+		fmt.Fprintf(os.Stderr, "仓库: %s\n", remote.URL)
 
-	if err := cmd.Run(); err != nil {
-		// 清理可能部分创建的目录
+		// 执行 git clone
+		err := runGit(remote.Timeout, "clone", "--depth", "1", remote.URL, docDir)
+		if err == nil {
+			saveLastWorkingRemote(docDir, remote.URL)
+			return nil
+		}
+
+		// 清理可能部分创建的目录（持有锁，不会误删其他进程的克隆）
 		os.RemoveAll(docDir)
-		return err
+		fmt.Fprintf(os.Stderr, "警告: 从 %s 克隆失败: %v，尝试下一个镜像\n", remote.URL, err)
+		failures = append(failures, fmt.Sprintf("%s: %v", remote.URL, err))
 	}
 
-	return nil
+	return fmt.Errorf("所有远程仓库均克隆失败: %s", strings.Join(failures, "; "))
 }
 
 // UpdateDocuments 更新仓颉文档仓库
-func UpdateDocuments(docDir string) error {
+// 按顺序尝试各个远程仓库拉取（上次成功的优先），成功后将 origin 指向该地址
+// 文档目录存在本地修改时返回 ErrLocalChanges，除非 force 为 true
+// 更新过程持有文档目录锁；等待超时返回 ErrLockTimeout
+func UpdateDocuments(docDir string, remotes []RepoRemote, force bool) error {
 	// 检查 git 是否可用
 	if _, err := exec.LookPath("git"); err != nil {
 		return fmt.Errorf("系统未安装 git: %w", err)
 	}
 
+	lock, err := AcquireLock(lockPathFor(docDir), updateLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	// 检查是否是 git 仓库
 	gitDir := filepath.Join(docDir, ".git")
 	if _, err := os.Stat(gitDir); err != nil {
 		return fmt.Errorf("不是有效的 git 仓库: %w", err)
 	}
 
+	// 检查本地修改，避免 reset/clean 删除用户添加的内容
+	if !force {
+		changes, err := localChanges(docDir)
+		if err != nil {
+			return fmt.Errorf("git status 失败: %w", err)
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("%w（%d 个文件，如 %s）", ErrLocalChanges, len(changes), changes[0])
+		}
+	}
+
 	// 执行 git fetch
-	cmd := exec.Command("git", "-C", docDir, "fetch", "--all")
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git fetch 失败: %w", err)
+	if err := fetchFromRemotes(docDir, remotes); err != nil {
+		return err
 	}
 
 	// 检测默认分支名称（可能是 main 或 master）
 	branch := getDefaultBranch(docDir)
 
 	// 执行 git reset --hard (强制更新)
-	cmd = exec.Command("git", "-C", docDir, "reset", "--hard", "origin/"+branch)
+	cmd := exec.Command("git", "-C", docDir, "reset", "--hard", "origin/"+branch)
 	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("git reset 失败: %w", err)
 	}
 
+	// 工作区干净时无需清理
+	if !force {
+		return nil
+	}
+
 	// 清理未跟踪的文件
 	cmd = exec.Command("git", "-C", docDir, "clean", "-fd")
 	cmd.Stdout = os.Stderr
@@ -145,6 +217,61 @@ func UpdateDocuments(docDir string) error {
 	return nil
 }
 
+// fetchFromRemotes 依次尝试从各个远程仓库拉取到 origin 的远程跟踪分支
+func fetchFromRemotes(docDir string, remotes []RepoRemote) error {
+	if len(remotes) == 0 {
+		remotes = DefaultRepoRemotes
+	}
+
+	lastURL := LastWorkingRemote(docDir)
+	if lastURL == "" {
+		lastURL = getOriginURL(docDir)
+	}
+
+	var failures []string
+	for _, remote := range orderRemotes(remotes, lastURL) {
+		err := runGit(remote.Timeout, "-C", docDir, "fetch", remote.URL, "+refs/heads/*:refs/remotes/origin/*")
+		if err == nil {
+			if remote.URL != getOriginURL(docDir) {
+				// 让 origin 指向可用的镜像，方便手动执行 git 命令
+				exec.Command("git", "-C", docDir, "remote", "set-url", "origin", remote.URL).Run()
+			}
+			saveLastWorkingRemote(docDir, remote.URL)
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "警告: 从 %s 拉取失败: %v，尝试下一个镜像\n", remote.URL, err)
+		failures = append(failures, fmt.Sprintf("%s: %v", remote.URL, err))
+	}
+
+	return fmt.Errorf("git fetch 失败: %s", strings.Join(failures, "; "))
+}
+
+// localChanges 返回工作区中已修改或未跟踪的文件列表（不含被忽略的文件）
+func localChanges(docDir string) ([]string, error) {
+	output, err := exec.Command("git", "-C", docDir, "status", "--porcelain").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) > 3 {
+			changes = append(changes, strings.TrimSpace(line[3:]))
+		}
+	}
+	return changes, nil
+}
+
+// getOriginURL 获取仓库 origin 的地址
+func getOriginURL(docDir string) string {
+	output, err := exec.Command("git", "-C", docDir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
 // getDefaultBranch 获取仓库的默认分支名称
 func getDefaultBranch(docDir string) string {
 	// 尝试获取当前分支的远程跟踪分支
@@ -173,6 +300,17 @@ func getDefaultBranch(docDir string) string {
 	return "main"
 }
 
+// DefaultOverlayDir 返回文档目录对应的默认补充文档目录
+// 补充目录与文档仓库分开存放，更新文档时不会被覆盖或清理
+func DefaultOverlayDir(docDir string) string {
+	return strings.TrimRight(docDir, `/\`) + ".local"
+}
+
+// DefaultIndexFile 返回持久化搜索索引的默认路径，位于文档目录旁，不受文档更新影响
+func DefaultIndexFile(docDir string) string {
+	return strings.TrimRight(docDir, `/\`) + ".index"
+}
+
 // GetDocumentDir 获取文档目录（优先使用指定的，否则使用默认的）
 func GetDocumentDir(specifiedDir string) (string, error) {
 	if specifiedDir != "" {
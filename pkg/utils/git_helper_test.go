@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requireGit 没有安装 git 时跳过测试
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+}
+
+// git 在指定目录执行 git 命令
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// newBareRemote 创建一个包含 README.md 的本地裸仓库，返回裸仓库路径和工作目录
+func newBareRemote(t *testing.T, content string) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	bare := filepath.Join(root, "remote.git")
+
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(t, work, "init", "-q", "-b", "main")
+	commitFile(t, work, "README.md", content)
+	git(t, root, "clone", "-q", "--bare", work, bare)
+	git(t, work, "remote", "add", "origin", bare)
+	return bare, work
+}
+
+// commitFile 写入文件并提交
+func commitFile(t *testing.T, work, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, work, "add", name)
+	git(t, work, "commit", "-q", "-m", "update "+name)
+}
+
+func TestParseRemotes(t *testing.T) {
+	remotes, err := ParseRemotes(" https://a.example/repo.git#30s , /srv/mirror.git ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 2 {
+		t.Fatalf("got %d remotes, want 2", len(remotes))
+	}
+	if remotes[0].URL != "https://a.example/repo.git" || remotes[0].Timeout != 30*time.Second {
+		t.Errorf("remotes[0] = %+v", remotes[0])
+	}
+	if remotes[1].URL != "/srv/mirror.git" || remotes[1].Timeout != DefaultRemoteTimeout {
+		t.Errorf("remotes[1] = %+v", remotes[1])
+	}
+
+	if remotes, _ := ParseRemotes(""); len(remotes) != len(DefaultRepoRemotes) {
+		t.Errorf("empty spec should fall back to defaults, got %+v", remotes)
+	}
+	if _, err := ParseRemotes("https://a.example/repo.git#soon"); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
+
+func TestOrderRemotes(t *testing.T) {
+	remotes := []RepoRemote{{URL: "a"}, {URL: "b"}, {URL: "c"}}
+
+	var urls []string
+	for _, remote := range orderRemotes(remotes, "b") {
+		urls = append(urls, remote.URL)
+	}
+	if got := strings.Join(urls, ","); got != "b,a,c" {
+		t.Errorf("orderRemotes = %s, want b,a,c", got)
+	}
+}
+
+func TestCloneDocumentsFailsOverToNextRemote(t *testing.T) {
+	requireGit(t)
+	bare, _ := newBareRemote(t, "corpus v1\n")
+	docDir := filepath.Join(t.TempDir(), "CangjieCorpus")
+
+	remotes := []RepoRemote{
+		{URL: filepath.Join(t.TempDir(), "missing.git"), Timeout: 30 * time.Second},
+		{URL: bare, Timeout: 30 * time.Second},
+	}
+	if err := CloneDocuments(docDir, remotes); err != nil {
+		t.Fatalf("CloneDocuments: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(docDir, "README.md"))
+	if err != nil || string(content) != "corpus v1\n" {
+		t.Fatalf("README.md = %q, %v", content, err)
+	}
+	if got := LastWorkingRemote(docDir); got != bare {
+		t.Errorf("LastWorkingRemote = %q, want %q", got, bare)
+	}
+	if _, err := os.Stat(lockPathFor(docDir)); !os.IsNotExist(err) {
+		t.Errorf("lock file should be released, stat err = %v", err)
+	}
+}
+
+func TestCloneDocumentsAllRemotesFail(t *testing.T) {
+	requireGit(t)
+	docDir := filepath.Join(t.TempDir(), "CangjieCorpus")
+
+	remotes := []RepoRemote{
+		{URL: filepath.Join(t.TempDir(), "missing-a.git"), Timeout: 30 * time.Second},
+		{URL: filepath.Join(t.TempDir(), "missing-b.git"), Timeout: 30 * time.Second},
+	}
+	if err := CloneDocuments(docDir, remotes); err == nil {
+		t.Fatal("expected error when every remote fails")
+	}
+	if _, err := os.Stat(docDir); !os.IsNotExist(err) {
+		t.Errorf("partial clone should be removed, stat err = %v", err)
+	}
+}
+
+func TestUpdateDocumentsFailsOverAndRemembersRemote(t *testing.T) {
+	requireGit(t)
+	primary, _ := newBareRemote(t, "corpus v1\n")
+	mirror, mirrorWork := newBareRemote(t, "corpus v1\n")
+	docDir := filepath.Join(t.TempDir(), "CangjieCorpus")
+
+	if err := CloneDocuments(docDir, []RepoRemote{{URL: primary, Timeout: 30 * time.Second}}); err != nil {
+		t.Fatalf("CloneDocuments: %v", err)
+	}
+
+	// 主仓库不可用，镜像有新提交
+	if err := os.RemoveAll(primary); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, mirrorWork, "README.md", "corpus v2\n")
+	git(t, mirrorWork, "push", "-q", "--force", "origin", "main")
+
+	remotes := []RepoRemote{
+		{URL: primary, Timeout: 30 * time.Second},
+		{URL: mirror, Timeout: 30 * time.Second},
+	}
+	if err := UpdateDocuments(docDir, remotes, false); err != nil {
+		t.Fatalf("UpdateDocuments: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(docDir, "README.md"))
+	if err != nil || string(content) != "corpus v2\n" {
+		t.Fatalf("README.md = %q, %v", content, err)
+	}
+	if got := LastWorkingRemote(docDir); got != mirror {
+		t.Errorf("LastWorkingRemote = %q, want %q", got, mirror)
+	}
+	if got := getOriginURL(docDir); got != mirror {
+		t.Errorf("origin = %q, want %q", got, mirror)
+	}
+	if got := orderRemotes(remotes, LastWorkingRemote(docDir))[0].URL; got != mirror {
+		t.Errorf("next attempt should start with %q, got %q", mirror, got)
+	}
+}
+
+func TestUpdateDocumentsPreservesLocalChanges(t *testing.T) {
+	requireGit(t)
+	bare, work := newBareRemote(t, "corpus v1\n")
+	docDir := filepath.Join(t.TempDir(), "CangjieCorpus")
+	remotes := []RepoRemote{{URL: bare, Timeout: 30 * time.Second}}
+
+	if err := CloneDocuments(docDir, remotes); err != nil {
+		t.Fatalf("CloneDocuments: %v", err)
+	}
+	commitFile(t, work, "README.md", "corpus v2\n")
+	git(t, work, "push", "-q", "origin", "main")
+
+	notes := filepath.Join(docDir, "team_notes.md")
+	if err := os.WriteFile(notes, []byte("# notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateDocuments(docDir, remotes, false); !errors.Is(err, ErrLocalChanges) {
+		t.Fatalf("UpdateDocuments err = %v, want ErrLocalChanges", err)
+	}
+	if _, err := os.Stat(notes); err != nil {
+		t.Fatalf("local file should be kept: %v", err)
+	}
+
+	if err := UpdateDocuments(docDir, remotes, true); err != nil {
+		t.Fatalf("forced UpdateDocuments: %v", err)
+	}
+	if _, err := os.Stat(notes); !os.IsNotExist(err) {
+		t.Errorf("forced update should clean local file, stat err = %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(docDir, "README.md"))
+	if string(content) != "corpus v2\n" {
+		t.Errorf("README.md = %q, want corpus v2", content)
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 等待锁时的轮询间隔
+const lockPollInterval = 200 * time.Millisecond
+
+// ErrLockTimeout 等待锁超时，说明其他进程正在操作同一文档目录
+var ErrLockTimeout = errors.New("等待文档目录锁超时，其他进程正在操作文档")
+
+// FileLock 基于操作系统文件锁（Unix 上的 flock，Windows 上的 LockFileEx）的跨进程锁
+// 持有进程退出或崩溃时由操作系统释放锁，残留的锁文件不会阻塞其他进程；
+// 锁文件中记录持有者的 PID、主机名和获取时间，便于排查
+type FileLock struct {
+	path string
+	file *os.File
+}
+
+// lockPathFor 返回文档目录对应的锁文件路径
+// 锁文件放在文档目录旁边，避免克隆失败清理目录时被一并删除
+func lockPathFor(docDir string) string {
+	return strings.TrimRight(docDir, `/\`) + ".lock"
+}
+
+// AcquireLock 获取锁，最多等待 timeout；timeout 为 0 时只尝试一次
+func AcquireLock(path string, timeout time.Duration) (*FileLock, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		lock, err := tryAcquireLock(path)
+		if err != nil {
+			return nil, err
+		}
+		if lock != nil {
+			return lock, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// tryAcquireLock 尝试一次获取锁，锁被其他进程持有时返回 nil
+func tryAcquireLock(path string) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建锁文件失败: %w", err)
+	}
+
+	locked, err := lockFile(file)
+	if err != nil || !locked {
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("锁定锁文件失败: %w", err)
+		}
+		return nil, nil
+	}
+
+	// 上一个持有者释放时会删除锁文件：锁住的可能是已删除的文件，此时路径已指向新文件，需要重试
+	opened, err := file.Stat()
+	current, statErr := os.Stat(path)
+	if err != nil || statErr != nil || !os.SameFile(opened, current) {
+		unlockFile(file)
+		file.Close()
+		return nil, nil
+	}
+
+	hostname, _ := os.Hostname()
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d\n%s\n%s\n", os.Getpid(), hostname, time.Now().Format(time.RFC3339))
+	return &FileLock{path: path, file: file}, nil
+}
+
+// Release 释放锁并删除锁文件
+// 先删除再解锁，等待者锁住已删除的文件后会发现路径已变化并重试；
+// Windows 上不能删除打开的文件，解锁关闭后再删除，其他进程正打开锁文件时保留它
+func (l *FileLock) Release() error {
+	if l.file == nil {
+		return nil
+	}
+	removeErr := os.Remove(l.path)
+	unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+
+	if removeErr != nil && !os.IsNotExist(removeErr) {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+			return fmt.Errorf("删除锁文件失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package utils
+
+import "os"
+
+// lockFile 当前平台不支持文件锁，总是成功（不提供跨进程互斥）
+func lockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile 当前平台不支持文件锁
+func unlockFile(file *os.File) error {
+	return nil
+}
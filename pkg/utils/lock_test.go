@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquireLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.lock")
+	lock, err := AcquireLock(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(path, 0); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("second AcquireLock err = %v, want ErrLockTimeout", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed, stat err = %v", err)
+	}
+
+	lock, err = AcquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("AcquireLock after release: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireLockConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.lock")
+	var active, maxActive, acquired int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				lock, err := AcquireLock(path, 30*time.Second)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := atomic.AddInt32(&active, 1)
+				for {
+					m := atomic.LoadInt32(&maxActive)
+					if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&active, -1)
+				atomic.AddInt32(&acquired, 1)
+				if err := lock.Release(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("%d holders at the same time, want 1", maxActive)
+	}
+	if acquired != 12 {
+		t.Errorf("acquired %d times, want 12", acquired)
+	}
+}
+
+func TestAcquireLockStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.lock")
+	hostname, _ := os.Hostname()
+	// 已退出进程留下的锁文件：PID 不存在，修改时间早已过期
+	content := fmt.Sprintf("%d\n%s\n%s\n", 1<<22+7, hostname, time.Now().Add(-time.Hour).Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("stale lock file blocks AcquireLock: %v", err)
+	}
+	defer lock.Release()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid, _ := strconv.Atoi(strings.SplitN(string(data), "\n", 2)[0]); pid != os.Getpid() {
+		t.Errorf("lock file records pid %d, want %d", pid, os.Getpid())
+	}
+}
+
+// TestLockHelperProcess 在子进程中获取锁并一直持有，直到被父进程结束
+func TestLockHelperProcess(t *testing.T) {
+	path := os.Getenv("LOCK_HELPER_PATH")
+	if path == "" {
+		t.Skip("helper process for TestAcquireLockHolderKilled")
+	}
+	if _, err := AcquireLock(path, 5*time.Second); err != nil {
+		fmt.Println("error:", err)
+		os.Exit(2)
+	}
+	fmt.Println("locked")
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func TestAcquireLockHolderKilled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.lock")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), "LOCK_HELPER_PATH="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "locked" {
+		t.Fatalf("helper process did not acquire the lock: %q %v", line, err)
+	}
+	if _, err := AcquireLock(path, 0); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("lock held by another process, AcquireLock err = %v, want ErrLockTimeout", err)
+	}
+
+	// 持有进程被强制结束后锁由操作系统释放，残留的锁文件不影响获取
+	cmd.Process.Kill()
+	cmd.Wait()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("killed holder should leave its lock file behind: %v", err)
+	}
+	lock, err := AcquireLock(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock after holder was killed: %v", err)
+	}
+	lock.Release()
+}
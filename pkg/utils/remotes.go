@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// 单个远程仓库的默认超时时间
+	DefaultRemoteTimeout = 3 * time.Minute
+)
+
+// RepoRemote 文档仓库的一个远程地址
+type RepoRemote struct {
+	URL     string        // 仓库地址
+	Timeout time.Duration // 克隆/拉取该地址的超时时间
+}
+
+// DefaultRepoRemotes 默认远程仓库列表，按顺序尝试
+// 只内置官方地址，不内置无法验证与官方同步的第三方镜像，镜像需要通过 -mirrors 配置
+var DefaultRepoRemotes = []RepoRemote{
+	{URL: CangjieRepoURL, Timeout: DefaultRemoteTimeout},
+}
+
+// ParseRemotes 解析远程仓库列表
+// 格式为逗号分隔的地址，每个地址可用 # 追加超时时间，例如：
+//
+//	https://gitcode.com/Cangjie/CangjieCorpus.git#2m,https://example.com/CangjieCorpus.git#30s
+//
+// 未指定超时的地址使用 DefaultRemoteTimeout；spec 为空时返回 DefaultRepoRemotes
+func ParseRemotes(spec string) ([]RepoRemote, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return DefaultRepoRemotes, nil
+	}
+
+	var remotes []RepoRemote
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		remote := RepoRemote{URL: entry, Timeout: DefaultRemoteTimeout}
+		if idx := strings.LastIndex(entry, "#"); idx >= 0 {
+			timeout, err := time.ParseDuration(entry[idx+1:])
+			if err != nil {
+				return nil, fmt.Errorf("无效的超时时间 %q: %w", entry[idx+1:], err)
+			}
+			if timeout <= 0 {
+				return nil, fmt.Errorf("超时时间必须大于0: %q", entry)
+			}
+			remote.URL = strings.TrimSpace(entry[:idx])
+			remote.Timeout = timeout
+		}
+
+		if remote.URL == "" {
+			return nil, fmt.Errorf("远程仓库地址为空: %q", entry)
+		}
+		remotes = append(remotes, remote)
+	}
+
+	if len(remotes) == 0 {
+		return DefaultRepoRemotes, nil
+	}
+	return remotes, nil
+}
+
+// orderRemotes 将上次成功的远程仓库排到最前面，其余保持原有顺序
+func orderRemotes(remotes []RepoRemote, lastURL string) []RepoRemote {
+	if lastURL == "" {
+		return remotes
+	}
+
+	ordered := make([]RepoRemote, 0, len(remotes))
+	for _, remote := range remotes {
+		if remote.URL == lastURL {
+			ordered = append(ordered, remote)
+		}
+	}
+	for _, remote := range remotes {
+		if remote.URL != lastURL {
+			ordered = append(ordered, remote)
+		}
+	}
+	return ordered
+}
+
+// remoteStatePathFor 返回记录上次成功远程仓库的状态文件路径
+func remoteStatePathFor(docDir string) string {
+	return strings.TrimRight(docDir, `/\`) + ".remote"
+}
+
+// LastWorkingRemote 返回上次成功克隆或拉取文档所用的远程仓库地址
+func LastWorkingRemote(docDir string) string {
+	content, err := os.ReadFile(remoteStatePathFor(docDir))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// saveLastWorkingRemote 记录成功的远程仓库地址，失败不影响主流程
+func saveLastWorkingRemote(docDir, url string) {
+	if err := os.WriteFile(remoteStatePathFor(docDir), []byte(url+"\n"), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 记录远程仓库失败: %v\n", err)
+	}
+}
+
+// runGit 以超时方式执行 git 命令，输出重定向到 stderr 以免干扰 MCP 通信
+func runGit(timeout time.Duration, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	// 禁止 git 交互式询问凭据，避免无法访问的镜像一直阻塞到超时
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("超时 (%s)", timeout)
+	}
+	return err
+}
@@ -0,0 +1,357 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis 截断文本时追加的省略号
+const Ellipsis = "..."
+
+// segment 截断时不可拆分的一段文本
+type segment struct {
+	text   string
+	kind   segmentKind
+	marker string // 强调标记（**、_、~~ 等）
+	inner  string // 链接文字或代码内容
+	fence  string // 代码片段两侧的反引号
+}
+
+type segmentKind int
+
+const (
+	segmentText     segmentKind = iota // 一个字符及其后的组合字符
+	segmentEmphasis                    // 强调标记
+	segmentCode                        // `代码`
+	segmentLink                        // [文字](地址) 或 ![文字](地址)
+	segmentTag                         // <标签> 或 <自动链接>
+)
+
+// measureFunc 计算文本长度的方式：字符数或显示宽度
+type measureFunc func(string) int
+
+// DisplayWidth 返回文本在等宽终端中的显示宽度：中日韩文字和全角符号计2，组合字符和控制字符计0，其余计1
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth 返回单个字符的显示宽度
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case r < 0x20 || r == 0x7f || isZeroWidth(r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isZeroWidth 判断是否为不占宽度的组合字符、变体选择符或零宽连接符
+func isZeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0xfe00 && r <= 0xfe0f)
+}
+
+// wideRanges 东亚宽字符和常用 emoji 的范围
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},   // 谚文字母
+	{0x2e80, 0x303e},   // 中日韩部首、标点
+	{0x3041, 0x33ff},   // 平假名、片假名、注音、中日韩兼容字符
+	{0x3400, 0x4dbf},   // 中日韩统一表意文字扩展A
+	{0x4e00, 0x9fff},   // 中日韩统一表意文字
+	{0xa000, 0xa4cf},   // 彝文
+	{0xac00, 0xd7a3},   // 谚文音节
+	{0xf900, 0xfaff},   // 中日韩兼容表意文字
+	{0xfe30, 0xfe4f},   // 中日韩兼容形式
+	{0xff00, 0xff60},   // 全角字符
+	{0xffe0, 0xffe6},   // 全角符号
+	{0x1f300, 0x1f64f}, // emoji
+	{0x1f900, 0x1f9ff}, // emoji 补充
+	{0x20000, 0x3fffd}, // 中日韩统一表意文字扩展B及以后
+}
+
+// isWide 判断是否为宽字符
+func isWide(r rune) bool {
+	for _, wide := range wideRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Truncate 按显示宽度截断文本，结果（包括省略号）不超过 maxWidth
+// 不会截断多字节字符和组合字符，不会切开代码片段、链接和 HTML 标签，未闭合的强调标记会补全
+func Truncate(s string, maxWidth int) string {
+	return truncate(s, maxWidth, DisplayWidth)
+}
+
+// TruncateRunes 按字符数截断文本，结果（包括省略号）不超过 maxRunes 个字符，规则同 Truncate
+func TruncateRunes(s string, maxRunes int) string {
+	return truncate(s, maxRunes, utf8.RuneCountInString)
+}
+
+// RunePrefix 返回文本的前 n 个字符，不加省略号，用于建立索引等不输出的场合
+func RunePrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// SkipRunes 去掉文本开头最多 n 个字符，停在不切开 Markdown 语法的位置（可能少去掉一些）
+// 被去掉的部分中未闭合的强调标记会补在开头
+func SkipRunes(s string, n int) string {
+	var open []string
+	skipped, offset := 0, 0
+	for offset < len(s) {
+		seg := nextSegment(s, offset)
+		size := utf8.RuneCountInString(seg.text)
+		if skipped+size > n {
+			break
+		}
+		skipped += size
+		offset += len(seg.text)
+		if seg.kind == segmentEmphasis {
+			open = toggleMarker(open, seg.marker)
+		}
+	}
+	return strings.Join(open, "") + s[offset:]
+}
+
+// truncate 按 measure 截断文本并追加省略号
+func truncate(s string, limit int, measure measureFunc) string {
+	if measure(s) <= limit {
+		return s
+	}
+	if limit <= 0 {
+		return ""
+	}
+	if limit <= measure(Ellipsis) {
+		return Ellipsis[:limit]
+	}
+	return cut(s, limit-measure(Ellipsis), measure) + Ellipsis
+}
+
+// cut 返回不超过 budget 的最长前缀，只在片段边界截断，并补全未闭合的强调标记
+func cut(s string, budget int, measure measureFunc) string {
+	var builder strings.Builder
+	var open []string
+	used, end := 0, 0 // end 为去掉末尾空白片段后的长度
+	for i := 0; i < len(s); {
+		seg := nextSegment(s, i)
+		i += len(seg.text)
+		next := open
+		if seg.kind == segmentEmphasis {
+			next = toggleMarker(append([]string(nil), open...), seg.marker)
+		}
+		size := measure(seg.text)
+		if used+size+measure(closers(next)) <= budget {
+			builder.WriteString(seg.text)
+			used += size
+			open = next
+			if strings.TrimSpace(seg.text) != "" {
+				end = builder.Len()
+			}
+			continue
+		}
+
+		// 放不下时：链接保留截断后的文字；开头就放不下的代码片段截断内容后重新包上反引号
+		rest := budget - used - measure(closers(open))
+		switch {
+		case seg.kind == segmentLink:
+			if label := cut(seg.inner, rest, measure); label != "" {
+				builder.WriteString(label)
+				end = builder.Len()
+			}
+		case seg.kind == segmentCode && used == 0:
+			if inner := cut(seg.inner, rest-2*measure(seg.fence), measure); inner != "" {
+				builder.WriteString(seg.fence + inner + seg.fence)
+				end = builder.Len()
+			}
+		}
+		break
+	}
+	return builder.String()[:end] + closers(open)
+}
+
+// toggleMarker 遇到与栈顶相同的强调标记时闭合，否则作为新的开始标记入栈
+func toggleMarker(open []string, marker string) []string {
+	if len(open) > 0 && open[len(open)-1] == marker {
+		return open[:len(open)-1]
+	}
+	return append(open, marker)
+}
+
+// closers 按相反顺序返回闭合未闭合强调标记所需的文本
+func closers(open []string) string {
+	var builder strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		builder.WriteString(open[i])
+	}
+	return builder.String()
+}
+
+// nextSegment 识别从 i 开始的片段
+func nextSegment(s string, i int) segment {
+	switch s[i] {
+	case '\\':
+		if i+1 < len(s) {
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			return segment{text: s[i : i+1+size]}
+		}
+	case '`':
+		if seg, ok := codeSpan(s, i); ok {
+			return seg
+		}
+	case '[':
+		if seg, ok := link(s, i); ok {
+			return seg
+		}
+	case '!':
+		if i+1 < len(s) && s[i+1] == '[' {
+			if seg, ok := link(s, i+1); ok {
+				seg.text = s[i : i+1+len(seg.text)]
+				return seg
+			}
+		}
+	case '<':
+		// HTML 标签或不含空格的自动链接
+		if end := strings.IndexAny(s[i+1:], "<>\n"); end > 0 && s[i+1+end] == '>' {
+			if isTagStart(s[i+1]) || !strings.ContainsRune(s[i+1:i+1+end], ' ') {
+				return segment{text: s[i : i+2+end], kind: segmentTag}
+			}
+		}
+	case '*', '_', '~':
+		if seg, ok := emphasis(s, i); ok {
+			return seg
+		}
+	}
+	return cluster(s, i)
+}
+
+// isTagStart 判断是否为 HTML 标签名的开头
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// cluster 返回一个字符及其后的组合字符、变体选择符和零宽连接符连接的字符
+func cluster(s string, i int) segment {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	end := i + size
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if r == utf8.RuneError || !isZeroWidth(r) {
+			break
+		}
+		end += size
+		// 零宽连接符连接后面的字符（如组合 emoji）
+		if r == 0x200d && end < len(s) {
+			if next, size := utf8.DecodeRuneInString(s[end:]); next != utf8.RuneError && unicode.IsGraphic(next) && !unicode.IsSpace(next) {
+				end += size
+			}
+		}
+	}
+	return segment{text: s[i:end]}
+}
+
+// codeSpan 识别 `代码`：在同一行内找到与开头相同个数的反引号结束，找不到时开头的反引号作为普通文本
+func codeSpan(s string, i int) (segment, bool) {
+	fence := runOf(s, i, '`')
+	line := len(s)
+	if k := strings.IndexByte(s[i:], '\n'); k >= 0 {
+		line = i + k
+	}
+	for j := i + len(fence); j < line; {
+		k := strings.Index(s[j:line], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		closing := runOf(s, k, '`')
+		if len(closing) == len(fence) {
+			return segment{text: s[i : k+len(fence)], kind: segmentCode, inner: s[i+len(fence) : k], fence: fence}, true
+		}
+		j = k + len(closing)
+	}
+	return segment{}, false
+}
+
+// link 识别 [文字](地址)，文字和地址中允许嵌套的括号
+func link(s string, i int) (segment, bool) {
+	labelEnd := matching(s, i, '[', ']')
+	if labelEnd < 0 || labelEnd+1 >= len(s) || s[labelEnd+1] != '(' {
+		return segment{}, false
+	}
+	end := matching(s, labelEnd+1, '(', ')')
+	if end < 0 {
+		return segment{}, false
+	}
+	return segment{text: s[i : end+1], kind: segmentLink, inner: s[i+1 : labelEnd]}, true
+}
+
+// matching 返回与 s[i] 处的开括号配对的闭括号位置，跳过转义字符，遇到换行或找不到时返回 -1
+func matching(s string, i int, open, close byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '\n':
+			return -1
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// emphasis 识别强调标记；两侧都是空白的 * 和单词内部的 _ 是普通文本
+func emphasis(s string, i int) (segment, bool) {
+	marker := runOf(s, i, s[i])
+	if s[i] == '~' && len(marker) < 2 {
+		return segment{}, false
+	}
+	before, _ := utf8.DecodeLastRuneInString(s[:i])
+	after, _ := utf8.DecodeRuneInString(s[i+len(marker):])
+	if i == 0 {
+		before = ' '
+	}
+	if i+len(marker) == len(s) {
+		after = ' '
+	}
+	switch {
+	case unicode.IsSpace(before) && unicode.IsSpace(after):
+		return segment{}, false
+	case s[i] == '_' && isWordRune(before) && isWordRune(after):
+		return segment{}, false
+	}
+	return segment{text: marker, kind: segmentEmphasis, marker: marker}, true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// runOf 返回从 i 开始由字符 c 组成的连续文本
+func runOf(s string, i int, c byte) string {
+	j := i
+	for j < len(s) && s[j] == c {
+		j++
+	}
+	return s[i:j]
+}
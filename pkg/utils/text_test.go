@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"仓颉", 4},
+		{"ＡＢ", 4},
+		{"é", 1},
+		{"😀", 2},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.text); got != tt.width {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.text, got, tt.width)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 8, "hello..."},
+		{"仓颉编程语言文档", 9, "仓颉编..."},
+		{"仓颉编程语言文档", 10, "仓颉编..."},
+		{"使用 `HashMap<String, Int64>` 存储", 12, "使用..."},
+		{"`HashMap<String, Int64>` 存储", 12, "`HashMap`..."},
+		{"参见 [集合类型](../collections.md) 一节", 16, "参见 集合类型..."},
+		{"参见 [集合类型](../collections.md) 一节", 38, "参见 [集合类型](../collections.md)..."},
+		{"这是**重要的说明文字**", 14, "这是**重**..."},
+		{"这是**重要的说明文字**", 16, "这是**重要**..."},
+		{"a <br/> b c d e f", 5, "a..."},
+		{"cafe\u0301 cafe\u0301", 7, "cafe\u0301..."},
+		{"snake_case_name is long", 12, "snake_cas..."},
+		{"abc", 2, ".."},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.text, tt.width)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := TruncateRunes("仓颉编程语言文档", 6); got != "仓颉编..." {
+		t.Errorf("TruncateRunes = %q", got)
+	}
+	if got := TruncateRunes("仓颉编程", 4); got != "仓颉编程" {
+		t.Errorf("TruncateRunes = %q", got)
+	}
+}
+
+func TestRunePrefix(t *testing.T) {
+	if got := RunePrefix("仓颉abc", 3); got != "仓颉a" {
+		t.Errorf("RunePrefix = %q", got)
+	}
+	if got := RunePrefix("仓颉", 5); got != "仓颉" {
+		t.Errorf("RunePrefix = %q", got)
+	}
+}
+
+func TestSkipRunes(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"仓颉编程语言", 2, "编程语言"},
+		{"调用 `list.append(x)` 添加元素", 5, "`list.append(x)` 添加元素"},
+		{"**粗体文字**后面", 4, "**文字**后面"},
+		{"abc", 10, ""},
+	}
+	for _, tt := range tests {
+		if got := SkipRunes(tt.text, tt.n); got != tt.want {
+			t.Errorf("SkipRunes(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+// splitSegments 把文本拆成不可拆分的片段，片段按顺序拼接即为原文
+func splitSegments(s string) []segment {
+	var segments []segment
+	for i := 0; i < len(s); {
+		seg := nextSegment(s, i)
+		segments = append(segments, seg)
+		i += len(seg.text)
+	}
+	return segments
+}
+
+// checkTruncated 检查截断结果：合法 UTF-8、不超过限制、去掉省略号和补全的强调标记后是原文在片段边界上的前缀
+func checkTruncated(t *testing.T, text, got string, limit int, measure measureFunc) {
+	t.Helper()
+	if utf8.ValidString(text) && !utf8.ValidString(got) {
+		t.Fatalf("invalid UTF-8 %q from %q", got, text)
+	}
+	if limit >= 0 && measure(got) > limit {
+		t.Fatalf("%q is longer than %d", got, limit)
+	}
+	if measure(text) <= limit {
+		if got != text {
+			t.Fatalf("%q changed to %q although it fits", text, got)
+		}
+		return
+	}
+	if limit <= len(Ellipsis) {
+		return
+	}
+	prefix := strings.TrimSuffix(got, Ellipsis)
+	if prefix == got {
+		t.Fatalf("%q has no ellipsis", got)
+	}
+
+	// 链接和代码片段放不下时会降级，不再是原文的前缀
+	boundary := map[int]bool{0: true}
+	offset := 0
+	for _, seg := range splitSegments(text) {
+		if seg.kind == segmentLink || seg.kind == segmentCode {
+			return
+		}
+		offset += len(seg.text)
+		boundary[offset] = true
+	}
+	for trimmed := prefix; !strings.HasPrefix(text, trimmed) || !boundary[len(trimmed)]; {
+		if trimmed == "" || !strings.ContainsRune("*_~", rune(trimmed[len(trimmed)-1])) {
+			t.Fatalf("%q is not a prefix of %q ending at a segment boundary", prefix, text)
+		}
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+}
+
+func FuzzTruncate(f *testing.F) {
+	seeds := []string{
+		"仓颉编程语言文档",
+		"使用 `HashMap<String, Int64>` 存储",
+		"参见 [集合类型](../collections.md) 一节",
+		"这是**重要的说明文字**，_斜体_ 和 ~~删除~~",
+		"é́ 👨‍👩‍👧 ｆｕｌｌ",
+		"\\*转义\\* <a href=\"x\">链接</a> <https://cangjie-lang.cn>",
+		"``a`b`` ![图](img.png) [嵌套 [括号]](a(b)c)",
+		"\xff\xfe 非法字节",
+	}
+	for _, seed := range seeds {
+		f.Add(seed, 10)
+	}
+	f.Fuzz(func(t *testing.T, text string, limit int) {
+		limit %= 64
+		checkTruncated(t, text, Truncate(text, limit), limit, DisplayWidth)
+		checkTruncated(t, text, TruncateRunes(text, limit), limit, utf8.RuneCountInString)
+	})
+}
+
+func FuzzSkipRunes(f *testing.F) {
+	f.Add("调用 `list.append(x)` 添加元素", 5)
+	f.Add("**粗体文字**后面 [链接](a.md)", 3)
+	f.Fuzz(func(t *testing.T, text string, n int) {
+		n %= 64
+		got := SkipRunes(text, n)
+		if utf8.ValidString(text) && !utf8.ValidString(got) {
+			t.Fatalf("invalid UTF-8 %q from %q", got, text)
+		}
+		if rest := strings.TrimLeft(got, "*_~"); !strings.HasSuffix(text, rest) {
+			t.Fatalf("%q is not a suffix of %q", rest, text)
+		}
+		if n > 0 && utf8.RuneCountInString(strings.TrimLeft(got, "*_~")) > utf8.RuneCountInString(text) {
+			t.Fatalf("%q is longer than %q", got, text)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// 估算规则：汉字、假名、谚文每个字符约1个 token；英文单词和标识符约4个字符1个 token；
+// 代码和 JSON 中连续的标点、运算符约2个字符1个 token；换行计1个，其他空白不计
+const (
+	charsPerWordToken   = 4
+	charsPerOtherRune   = 2
+	charsPerSymbolToken = 2
+)
+
+// EstimateTokens 估算文本的 token 数，针对中文文档和代码调整，偏向高估
+func EstimateTokens(s string) int {
+	tokens := 0
+	word, other, symbol := 0, 0, 0
+	flush := func() {
+		tokens += (word + charsPerWordToken - 1) / charsPerWordToken
+		tokens += (other + charsPerOtherRune - 1) / charsPerOtherRune
+		tokens += (symbol + charsPerSymbolToken - 1) / charsPerSymbolToken
+		word, other, symbol = 0, 0, 0
+	}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if isWide(r) {
+				flush()
+				tokens++
+				continue
+			}
+			if symbol > 0 {
+				flush()
+			}
+			if r < utf8.RuneSelf {
+				word++
+			} else {
+				other++
+			}
+		case r == '\n':
+			flush()
+			tokens++
+		case unicode.IsSpace(r) || isZeroWidth(r):
+			flush()
+		case isWide(r):
+			// 全角标点
+			flush()
+			tokens++
+		default:
+			// 标点、运算符和其他符号
+			if word > 0 || other > 0 {
+				flush()
+			}
+			symbol++
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"hello world", 2, 4},
+		{"仓颉编程语言", 6, 6},
+		{"使用 let 定义不可变变量。", 10, 13},
+		{"public func abs(x: Int64): Int64 {\n    return x\n}", 14, 22},
+		{"let map = HashMap<String, Int64>()", 10, 16},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("EstimateTokens(%q) = %d, want %d..%d", tt.text, got, tt.min, tt.max)
+		}
+	}
+}